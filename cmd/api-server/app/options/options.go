@@ -3,8 +3,15 @@ package options
 import (
 	"errors"
 	"os"
+	"time"
 
 	"github.com/akamensky/argparse"
+
+	"rightsizing-api-server/internal/api/common/forecasting"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/datasource"
+	grpcclient "rightsizing-api-server/internal/grpc"
 )
 
 type Options struct {
@@ -15,7 +22,56 @@ type Options struct {
 	Port     *int
 	GrpcHost *string
 	GrpcPort *string
-	parser   *argparse.Parser
+	// deadline of the requests, none if 0
+	RequestTimeout *string
+	// message size limits of the analysis server connection
+	GrpcMaxSendMsgSize *int
+	GrpcMaxRecvMsgSize *int
+	// deadlines and retries of the analysis server calls
+	GrpcTimeout         *string
+	GrpcForecastTimeout *string
+	GrpcMaxAttempts     *int
+	// circuit breaker and health checks of the analysis server
+	GrpcBreakerThreshold    *int
+	GrpcBreakerCooldown     *string
+	GrpcHealthCheckInterval *string
+	// TLS of the analysis server connection
+	GrpcTLS           *bool
+	GrpcTLSCAFile     *string
+	GrpcTLSCertFile   *string
+	GrpcTLSKeyFile    *string
+	GrpcTLSServerName *string
+	// metrics backend of the usage and quota
+	Datasource        *string
+	PrometheusURL     *string
+	PrometheusStep    *string
+	PrometheusTimeout *string
+	// OpenSearch datasource, the credentials are read from the environment
+	OpenSearchURL      *string
+	OpenSearchIndex    *string
+	OpenSearchInterval *string
+	OpenSearchTimeout  *string
+	OpenSearchInsecure *bool
+	// default recommendation strategy
+	Recommender *string
+	// default forecast model
+	Forecaster *string
+	// decaying histogram recommender
+	HistogramHalfLife             *string
+	HistogramTargetPercentile     *float64
+	HistogramLowerBoundPercentile *float64
+	HistogramUpperBoundPercentile *float64
+	HistogramSafetyMargin         *float64
+	// pricing configuration file
+	PricingFile *string
+	// schedule configuration file
+	ScheduleFile *string
+	// metric catalogue file
+	MetricsFile *string
+	// continuous aggregates of the Promscale datasource
+	Aggregates     *string
+	AggregatesOnly *bool
+	parser         *argparse.Parser
 }
 
 func NewOptions() (*Options, error) {
@@ -36,6 +92,10 @@ func NewOptions() (*Options, error) {
 		Help:    "The port used by api-server",
 		Default: 8000,
 	})
+	option.RequestTimeout = parser.String("", "request-timeout", &argparse.Options{
+		Help:    "The deadline of a request including its calls to the grpc server, e.g. 1m (0 for none)",
+		Default: "0s",
+	})
 	option.Mode = parser.Selector("m", "mode", []string{"release", "development", "debug"}, &argparse.Options{
 		Help:    "Choose release/development mode (default debug mode)",
 		Default: "debug",
@@ -49,6 +109,137 @@ func NewOptions() (*Options, error) {
 		Help:    "The port used by grpc client",
 		Default: "50051",
 	})
+	grpcOptions := grpcclient.DefaultOptions()
+	option.GrpcMaxSendMsgSize = parser.Int("", "grpc-max-send-msg-size", &argparse.Options{
+		Help:    "The largest message in bytes sent to the grpc server, larger series are streamed in chunks",
+		Default: grpcOptions.MaxSendMsgSize,
+	})
+	option.GrpcMaxRecvMsgSize = parser.Int("", "grpc-max-recv-msg-size", &argparse.Options{
+		Help:    "The largest message in bytes received from the grpc server",
+		Default: grpcOptions.MaxRecvMsgSize,
+	})
+	option.GrpcTimeout = parser.String("", "grpc-timeout", &argparse.Options{
+		Help:    "The deadline of a rightsizing call to the grpc server",
+		Default: grpcOptions.Timeout.String(),
+	})
+	option.GrpcForecastTimeout = parser.String("", "grpc-forecast-timeout", &argparse.Options{
+		Help:    "The deadline of a forecast call to the grpc server",
+		Default: grpcOptions.ForecastTimeout.String(),
+	})
+	option.GrpcMaxAttempts = parser.Int("", "grpc-max-attempts", &argparse.Options{
+		Help:    "The attempts (1~5) of a call failing with UNAVAILABLE, including the first one",
+		Default: grpcOptions.MaxAttempts,
+	})
+	option.GrpcBreakerThreshold = parser.Int("", "grpc-breaker-threshold", &argparse.Options{
+		Help:    "The consecutive failures of the grpc server opening the circuit breaker",
+		Default: grpcOptions.BreakerThreshold,
+	})
+	option.GrpcBreakerCooldown = parser.String("", "grpc-breaker-cooldown", &argparse.Options{
+		Help:    "The time the circuit breaker stays open before the grpc server is tried again",
+		Default: grpcOptions.BreakerCooldown.String(),
+	})
+	option.GrpcHealthCheckInterval = parser.String("", "grpc-health-check-interval", &argparse.Options{
+		Help:    "The interval of the health checks of the grpc server (0 for none)",
+		Default: grpcOptions.HealthCheckInterval.String(),
+	})
+	option.GrpcTLS = parser.Flag("", "grpc-tls", &argparse.Options{
+		Help: "Connect to the grpc server with TLS",
+	})
+	option.GrpcTLSCAFile = parser.String("", "grpc-tls-ca-file", &argparse.Options{
+		Help: "CA certificate file verifying the grpc server (system certificates if empty)",
+	})
+	option.GrpcTLSCertFile = parser.String("", "grpc-tls-cert-file", &argparse.Options{
+		Help: "Client certificate file for mutual TLS with the grpc server",
+	})
+	option.GrpcTLSKeyFile = parser.String("", "grpc-tls-key-file", &argparse.Options{
+		Help: "Client private key file matching --grpc-tls-cert-file",
+	})
+	option.GrpcTLSServerName = parser.String("", "grpc-tls-server-name", &argparse.Options{
+		Help: "The server name verified instead of --grpc-host",
+	})
+	option.Datasource = parser.Selector("", "datasource", datasource.Names, &argparse.Options{
+		Help:    "The metrics backend of the usage and quota, TimescaleDB with Promscale, the Prometheus HTTP API or OpenSearch. The others need TimescaleDB (HOST) only for the results store and webhooks",
+		Default: datasource.Promscale,
+	})
+	prometheusOptions := datasource.DefaultPrometheusOptions()
+	option.PrometheusURL = parser.String("", "prometheus-url", &argparse.Options{
+		Help:    "The URL of the Prometheus HTTP API, e.g. of Prometheus or Thanos Query",
+		Default: prometheusOptions.URL,
+	})
+	option.PrometheusStep = parser.String("", "prometheus-step", &argparse.Options{
+		Help:    "The resolution of the usage queried from Prometheus",
+		Default: prometheusOptions.Step.String(),
+	})
+	option.PrometheusTimeout = parser.String("", "prometheus-timeout", &argparse.Options{
+		Help:    "The deadline of a query to Prometheus",
+		Default: prometheusOptions.Timeout.String(),
+	})
+	openSearchOptions := datasource.DefaultOpenSearchOptions()
+	option.OpenSearchURL = parser.String("", "opensearch-url", &argparse.Options{
+		Help:    "The URL of OpenSearch, the credentials are read from OPENSEARCH_USERNAME and OPENSEARCH_PASSWORD",
+		Default: openSearchOptions.URL,
+	})
+	option.OpenSearchIndex = parser.String("", "opensearch-index", &argparse.Options{
+		Help:    "The index pattern of the metric documents in OpenSearch",
+		Default: openSearchOptions.Index,
+	})
+	option.OpenSearchInterval = parser.String("", "opensearch-interval", &argparse.Options{
+		Help:    "The resolution of the usage aggregated from OpenSearch",
+		Default: openSearchOptions.Interval.String(),
+	})
+	option.OpenSearchTimeout = parser.String("", "opensearch-timeout", &argparse.Options{
+		Help:    "The deadline of a search in OpenSearch",
+		Default: openSearchOptions.Timeout.String(),
+	})
+	option.OpenSearchInsecure = parser.Flag("", "opensearch-insecure", &argparse.Options{
+		Help: "Skip the verification of the OpenSearch server certificate",
+	})
+	option.Recommender = parser.Selector("", "recommender", rightsizing.RecommenderNames, &argparse.Options{
+		Help:    "The default recommendation strategy, can be overridden by the recommender query parameter",
+		Default: rightsizing.GrpcRecommender,
+	})
+	option.Forecaster = parser.Selector("", "forecaster", forecasting.ForecasterNames, &argparse.Options{
+		Help:    "The default forecast model, can be overridden by the model query parameter",
+		Default: forecasting.ProphetForecaster,
+	})
+
+	histogramOptions := rightsizing.DefaultHistogramOptions()
+	option.HistogramHalfLife = parser.String("", "histogram-half-life", &argparse.Options{
+		Help:    "The half-life of sample weights in the decaying histogram",
+		Default: histogramOptions.HalfLife.String(),
+	})
+	option.HistogramTargetPercentile = parser.Float("", "histogram-target-percentile", &argparse.Options{
+		Help:    "The percentile (0~1) of the decaying histogram used as target",
+		Default: histogramOptions.TargetPercentile,
+	})
+	option.HistogramLowerBoundPercentile = parser.Float("", "histogram-lower-bound-percentile", &argparse.Options{
+		Help:    "The percentile (0~1) of the decaying histogram used as lower bound",
+		Default: histogramOptions.LowerBoundPercentile,
+	})
+	option.HistogramUpperBoundPercentile = parser.Float("", "histogram-upper-bound-percentile", &argparse.Options{
+		Help:    "The percentile (0~1) of the decaying histogram used as upper bound",
+		Default: histogramOptions.UpperBoundPercentile,
+	})
+	option.HistogramSafetyMargin = parser.Float("", "histogram-safety-margin", &argparse.Options{
+		Help:    "The safety margin added to the decaying histogram estimation",
+		Default: histogramOptions.SafetyMargin,
+	})
+	option.PricingFile = parser.String("", "pricing-file", &argparse.Options{
+		Help: "The pricing configuration file (per vCPU-hour and GiB-hour rates, node pool/namespace overrides)",
+	})
+	option.ScheduleFile = parser.String("", "schedule-file", &argparse.Options{
+		Help: "The schedule configuration file (cron specs of the periodic rightsizing and forecast)",
+	})
+	option.MetricsFile = parser.String("", "metrics-file", &argparse.Options{
+		Help: "The metric catalogue file (YAML or JSON usage metrics of pods and VMs, the built-in ones if left out)",
+	})
+	option.Aggregates = parser.Selector("", "aggregates", database.AggregateModes, &argparse.Options{
+		Help:    "On startup, report the drift of the continuous aggregates of the metrics (10m, 1h, 1d) or sync them with the promscale datasource",
+		Default: database.AggregatesCheck,
+	})
+	option.AggregatesOnly = parser.Flag("", "aggregates-only", &argparse.Options{
+		Help: "Sync the continuous aggregates and their refresh policies, report the drift and exit, e.g. as an admin job",
+	})
 
 	err := parser.Parse(os.Args)
 	if err != nil {
@@ -71,9 +262,158 @@ func (o *Options) Validate() error {
 	if *o.GrpcHost == "" || *o.GrpcPort == "" {
 		return errors.New("grpc host and port both must be present")
 	}
+
+	if _, err := o.GetRequestTimeout(); err != nil {
+		return err
+	}
+
+	if _, err := o.GrpcOptions(); err != nil {
+		return err
+	}
+
+	switch *o.Datasource {
+	case datasource.Prometheus:
+		if _, err := o.PrometheusOptions(); err != nil {
+			return err
+		}
+	case datasource.OpenSearch:
+		if _, err := o.OpenSearchOptions(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := o.HistogramOptions(); err != nil {
+		return err
+	}
 	return nil
 }
 
+func (o *Options) GetRequestTimeout() (time.Duration, error) {
+	timeout, err := time.ParseDuration(*o.RequestTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, errors.New("request timeout must not be negative")
+	}
+	return timeout, nil
+}
+
+func (o *Options) GrpcOptions() (grpcclient.Options, error) {
+	grpcOptions := grpcclient.DefaultOptions()
+	grpcOptions.MaxSendMsgSize = *o.GrpcMaxSendMsgSize
+	grpcOptions.MaxRecvMsgSize = *o.GrpcMaxRecvMsgSize
+	grpcOptions.MaxAttempts = *o.GrpcMaxAttempts
+	grpcOptions.BreakerThreshold = *o.GrpcBreakerThreshold
+
+	durations := []struct {
+		value  string
+		target *time.Duration
+	}{
+		{*o.GrpcTimeout, &grpcOptions.Timeout},
+		{*o.GrpcForecastTimeout, &grpcOptions.ForecastTimeout},
+		{*o.GrpcBreakerCooldown, &grpcOptions.BreakerCooldown},
+		{*o.GrpcHealthCheckInterval, &grpcOptions.HealthCheckInterval},
+	}
+	for _, duration := range durations {
+		value, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return grpcclient.Options{}, err
+		}
+		*duration.target = value
+	}
+
+	grpcOptions.TLS = grpcclient.TLSOptions{
+		Enabled:    *o.GrpcTLS,
+		CAFile:     *o.GrpcTLSCAFile,
+		CertFile:   *o.GrpcTLSCertFile,
+		KeyFile:    *o.GrpcTLSKeyFile,
+		ServerName: *o.GrpcTLSServerName,
+	}
+	if err := grpcOptions.Validate(); err != nil {
+		return grpcclient.Options{}, err
+	}
+	return grpcOptions, nil
+}
+
+func (o *Options) PrometheusOptions() (datasource.PrometheusOptions, error) {
+	step, err := time.ParseDuration(*o.PrometheusStep)
+	if err != nil {
+		return datasource.PrometheusOptions{}, err
+	}
+	timeout, err := time.ParseDuration(*o.PrometheusTimeout)
+	if err != nil {
+		return datasource.PrometheusOptions{}, err
+	}
+
+	prometheusOptions := datasource.PrometheusOptions{
+		URL:     *o.PrometheusURL,
+		Step:    step,
+		Timeout: timeout,
+	}
+	if err := prometheusOptions.Validate(); err != nil {
+		return datasource.PrometheusOptions{}, err
+	}
+	return prometheusOptions, nil
+}
+
+func (o *Options) OpenSearchOptions() (datasource.OpenSearchOptions, error) {
+	interval, err := time.ParseDuration(*o.OpenSearchInterval)
+	if err != nil {
+		return datasource.OpenSearchOptions{}, err
+	}
+	timeout, err := time.ParseDuration(*o.OpenSearchTimeout)
+	if err != nil {
+		return datasource.OpenSearchOptions{}, err
+	}
+
+	openSearchOptions := datasource.DefaultOpenSearchOptions()
+	openSearchOptions.URL = *o.OpenSearchURL
+	openSearchOptions.Index = *o.OpenSearchIndex
+	openSearchOptions.Interval = interval
+	openSearchOptions.Timeout = timeout
+	openSearchOptions.InsecureSkipVerify = *o.OpenSearchInsecure
+	if err := openSearchOptions.Validate(); err != nil {
+		return datasource.OpenSearchOptions{}, err
+	}
+	return openSearchOptions, nil
+}
+
+func (o *Options) HistogramOptions() (rightsizing.HistogramOptions, error) {
+	halfLife, err := time.ParseDuration(*o.HistogramHalfLife)
+	if err != nil {
+		return rightsizing.HistogramOptions{}, err
+	}
+	if halfLife <= 0 {
+		return rightsizing.HistogramOptions{}, errors.New("histogram half-life must be positive")
+	}
+
+	percentiles := []float64{
+		*o.HistogramTargetPercentile,
+		*o.HistogramLowerBoundPercentile,
+		*o.HistogramUpperBoundPercentile,
+	}
+	for _, percentile := range percentiles {
+		if percentile <= 0 || percentile > 1 {
+			return rightsizing.HistogramOptions{}, errors.New("histogram percentiles must be between 0 and 1")
+		}
+	}
+	if *o.HistogramLowerBoundPercentile > *o.HistogramUpperBoundPercentile {
+		return rightsizing.HistogramOptions{}, errors.New("histogram lower bound percentile must not exceed upper bound percentile")
+	}
+	if *o.HistogramSafetyMargin < 0 {
+		return rightsizing.HistogramOptions{}, errors.New("histogram safety margin must not be negative")
+	}
+
+	return rightsizing.HistogramOptions{
+		HalfLife:             halfLife,
+		TargetPercentile:     *o.HistogramTargetPercentile,
+		LowerBoundPercentile: *o.HistogramLowerBoundPercentile,
+		UpperBoundPercentile: *o.HistogramUpperBoundPercentile,
+		SafetyMargin:         *o.HistogramSafetyMargin,
+	}, nil
+}
+
 func (o *Options) Usage(err error) string {
 	return o.parser.Usage(err)
 }
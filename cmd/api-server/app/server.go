@@ -25,11 +25,24 @@ import (
 	"gorm.io/gorm"
 
 	"rightsizing-api-server/cmd/api-server/app/options"
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/forecasting"
+	"rightsizing-api-server/internal/api/common/query"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+	"rightsizing-api-server/internal/api/common/stream"
+	"rightsizing-api-server/internal/api/common/table"
+	"rightsizing-api-server/internal/api/namespace"
 	"rightsizing-api-server/internal/api/pod"
 	"rightsizing-api-server/internal/api/vm"
+	"rightsizing-api-server/internal/api/webhook"
+	"rightsizing-api-server/internal/api/workload"
 	cache2 "rightsizing-api-server/internal/cache"
-	db "rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/datasource"
 	grpcclient "rightsizing-api-server/internal/grpc"
+	"rightsizing-api-server/internal/pricing"
+	"rightsizing-api-server/internal/scheduler"
+	"rightsizing-api-server/internal/store"
 	"rightsizing-api-server/internal/worker"
 )
 
@@ -38,22 +51,77 @@ type Server struct {
 	client     *grpcclient.Client
 	db         *gorm.DB
 	grpcClient *grpc.ClientConn
+	stopProbe  context.CancelFunc
 	worker     *worker.Worker
 	logger     *zap.Logger
 }
 
 func NewServer(opts *options.Options, logger *zap.Logger, errCh chan<- error) *Server {
-	// connect TimescaleDB (postgres)
-	db, err := db.Connect()
-	if err != nil {
-		logger.Fatal("Unable to connect to TimescaleDB", zap.Error(err))
+	// connect TimescaleDB (postgres), which the other datasources need only
+	// for the results store and webhooks
+	var db *gorm.DB
+	if *opts.Datasource == datasource.Promscale || database.Configured() {
+		var err error
+		db, err = database.Connect()
+		if err != nil {
+			logger.Fatal("Unable to connect to TimescaleDB", zap.Error(err))
+		}
+		if err := database.Migrate(db); err != nil {
+			logger.Fatal("Unable to migrate TimescaleDB", zap.Error(err))
+		}
+	} else {
+		logger.Warn("TimescaleDB is not configured, the results store and webhooks are disabled")
 	}
+	results := store.NewStore(db)
 	// connect rightsizing grpc server
-	grpcConn, err := grpc.Dial(fmt.Sprintf("%s:%s", *opts.GrpcHost, *opts.GrpcPort), grpc.WithInsecure())
+	grpcOptions, err := opts.GrpcOptions()
+	if err != nil {
+		logger.Fatal("Invalid grpc options", zap.Error(err))
+	}
+	grpcConn, err := grpcclient.Dial(fmt.Sprintf("%s:%s", *opts.GrpcHost, *opts.GrpcPort), grpcOptions)
 	if err != nil {
 		logger.Fatal("Unable to connect to grpc client", zap.Error(err))
 	}
-	client := grpcclient.NewClient(grpcConn)
+	client := grpcclient.NewClient(grpcConn, grpcOptions)
+	probeCtx, stopProbe := context.WithCancel(context.Background())
+	if grpcOptions.HealthCheckInterval > 0 {
+		go client.Probe(probeCtx, grpcOptions.HealthCheckInterval, logger.Named("grpc"))
+	}
+	// recommender
+	histogramOptions, err := opts.HistogramOptions()
+	if err != nil {
+		logger.Fatal("Invalid histogram options", zap.Error(err))
+	}
+	recommenders, err := rightsizing.NewRegistry(*opts.Recommender,
+		rightsizing.WithFallback(
+			rightsizing.NewGrpcRecommender(client),
+			rightsizing.NewPercentileRecommender(),
+			logger.Named("recommender")),
+		rightsizing.NewPercentileRecommender(),
+		rightsizing.NewMaxRecommender(),
+		rightsizing.NewHistogramRecommender(histogramOptions),
+	)
+	if err != nil {
+		logger.Fatal("Unable to init recommenders", zap.Error(err))
+	}
+	query.SetRecommenders(recommenders.Names())
+	// forecaster
+	forecasters, err := forecasting.NewRegistry(*opts.Forecaster,
+		forecasting.WithFallback(
+			forecasting.NewGrpcForecaster(client),
+			forecasting.NewHoltWintersForecaster(),
+			logger.Named("forecaster")),
+		forecasting.NewHoltWintersForecaster(),
+		forecasting.NewSeasonalNaiveForecaster(),
+	)
+	if err != nil {
+		logger.Fatal("Unable to init forecasters", zap.Error(err))
+	}
+	// pricing
+	prices, err := pricing.Load(*opts.PricingFile)
+	if err != nil {
+		logger.Fatal("Unable to load pricing", zap.Error(err))
+	}
 	// worker
 	cache, err := cache2.NewCache()
 	if err != nil {
@@ -72,9 +140,12 @@ func NewServer(opts *options.Options, logger *zap.Logger, errCh chan<- error) *S
 	})
 
 	app.Use(cors.New())
-	app.Use(compress.New())
-	app.Use(compress.New())
-	app.Use(etag.New())
+	app.Use(compress.New(compress.Config{
+		Next: stream.IsStream,
+	}))
+	app.Use(etag.New(etag.Config{
+		Next: stream.IsStream,
+	}))
 	app.Use(recover.New())
 	app.Use(requestid.New())
 	app.Use(fiberlogger.New(fiberlogger.Config{
@@ -86,16 +157,110 @@ func NewServer(opts *options.Options, logger *zap.Logger, errCh chan<- error) *S
 		app.Use(pprof.New())
 	}
 
+	// deadline of the request context, which the services pass to the grpc calls
+	if timeout, _ := opts.GetRequestTimeout(); timeout > 0 {
+		app.Use(func(c *fiber.Ctx) error {
+			ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+			defer cancel()
+			c.SetUserContext(ctx)
+			return c.Next()
+		})
+	}
+
+	// metric catalogue, read before the repositories query the metrics
+	if err := loadCatalogue(*opts.MetricsFile); err != nil {
+		logger.Fatal("Unable to load metric catalogue", zap.Error(err))
+	}
+	// continuous aggregates of the metrics
+	if *opts.Datasource == datasource.Promscale && *opts.Aggregates != database.AggregatesNone {
+		if err := manageAggregates(context.Background(), db, *opts.Aggregates, logger.Named("aggregates")); err != nil {
+			if *opts.Aggregates == database.AggregatesSync {
+				logger.Fatal("Unable to sync continuous aggregates", zap.Error(err))
+			}
+			logger.Error("Unable to check continuous aggregates", zap.Error(err))
+		}
+	}
+
+	// metrics repositories
+	var (
+		podRepository      pod.PodRepository
+		vmRepository       vm.VMRepository
+		workloadRepository workload.WorkloadRepository
+	)
+	switch *opts.Datasource {
+	case datasource.Prometheus:
+		prometheusOptions, err := opts.PrometheusOptions()
+		if err != nil {
+			logger.Fatal("Invalid prometheus options", zap.Error(err))
+		}
+		prometheus, err := datasource.NewPrometheusClient(prometheusOptions)
+		if err != nil {
+			logger.Fatal("Unable to init prometheus client", zap.Error(err))
+		}
+		podRepository = pod.NewPrometheusPodRepository(prometheus)
+		vmRepository = vm.NewPrometheusVMRepository(prometheus)
+		workloadRepository = workload.NewPrometheusWorkloadRepository(prometheus)
+	case datasource.OpenSearch:
+		openSearchOptions, err := opts.OpenSearchOptions()
+		if err != nil {
+			logger.Fatal("Invalid opensearch options", zap.Error(err))
+		}
+		openSearch, err := datasource.NewOpenSearchClient(openSearchOptions)
+		if err != nil {
+			logger.Fatal("Unable to init opensearch client", zap.Error(err))
+		}
+		podRepository = pod.NewOpenSearchPodRepository(openSearch)
+		vmRepository = vm.NewOpenSearchVMRepository(openSearch)
+		workloadRepository = workload.NewOpenSearchWorkloadRepository(openSearch)
+	default:
+		podRepository = pod.NewPodRepository(db)
+		vmRepository = vm.NewVMRepository(db)
+		workloadRepository = workload.NewWorkloadRepository(db)
+	}
+	// webhook
+	notifier := webhook.NewNopNotifier()
+	if db != nil {
+		webhookLogger := logger.Named("webhook")
+		webhookRepository := webhook.NewWebhookRepository(db)
+		webhookService := webhook.NewWebhookService(webhook.DefaultOptions(), results, webhookRepository, webhookLogger)
+		webhook.WebhookRouter(app.Group("/api/v1/"), webhookService, webhookLogger)
+		worker.Listen(webhookService.NotifyTask)
+		notifier = webhookService
+	} else {
+		app.All("/api/v1/webhooks*", func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(
+				commonerrors.UnavailableErr("webhooks", "the database is not configured"))
+		})
+	}
 	// pod
 	podLogger := logger.Named("pod")
-	podRepository := pod.NewPodRepository(db)
-	podService := pod.NewPodService(cache, worker, client, podRepository, podLogger)
+	podService := pod.NewPodService(cache, worker, forecasters, recommenders, prices, results, notifier, podRepository, podLogger)
 	pod.PodRouter(app.Group("/api/v1/"), podService, podLogger)
 	// vm
 	vmLogger := logger.Named("vm")
-	vmRepository := vm.NewVMRepository(db)
-	vmService := vm.NewVMService(cache, worker, client, vmRepository, vmLogger)
+	vmService := vm.NewVMService(cache, worker, forecasters, recommenders, prices, results, vmRepository, vmLogger)
 	vm.VMRouter(app.Group("/api/v1/"), vmService, vmLogger)
+	// workload
+	workloadLogger := logger.Named("workload")
+	workloadService := workload.NewWorkloadService(recommenders, prices, podRepository, workloadRepository, workloadLogger)
+	workload.WorkloadRouter(app.Group("/api/v1/"), workloadService, workloadLogger)
+	// namespace
+	namespaceLogger := logger.Named("namespace")
+	namespaceService := namespace.NewNamespaceService(podService, workloadService, namespaceLogger)
+	namespace.NamespaceRouter(app.Group("/api/v1/"), namespaceService, namespaceLogger)
+	// scheduled jobs, registered after the tasks of the services. The jobs
+	// record their results in the results store.
+	if db != nil {
+		schedule, err := scheduler.Load(*opts.ScheduleFile)
+		if err != nil {
+			logger.Fatal("Unable to load schedule", zap.Error(err))
+		}
+		if err := scheduler.Register(worker, schedule, logger.Named("scheduler")); err != nil {
+			logger.Fatal("Unable to register scheduled jobs", zap.Error(err))
+		}
+	} else {
+		logger.Warn("The results store is disabled, the scheduled rightsizing and forecast jobs are skipped")
+	}
 
 	app.Get("/dashboard", monitor.New())
 
@@ -115,6 +280,7 @@ func NewServer(opts *options.Options, logger *zap.Logger, errCh chan<- error) *S
 		client:     client,
 		db:         db,
 		grpcClient: grpcConn,
+		stopProbe:  stopProbe,
 		worker:     worker,
 		logger:     logger,
 	}
@@ -145,6 +311,7 @@ func (app *Server) Shutdown(parentCtx context.Context) error {
 	})
 	g.Go(func() error {
 		app.worker.Stop(ctx)
+		app.stopProbe()
 		// grpc connection은 반드시 worker 다 끝나고 해야함.
 		if err := app.grpcClient.Close(); err != nil {
 			return err
@@ -157,7 +324,93 @@ func (app *Server) Shutdown(parentCtx context.Context) error {
 	return nil
 }
 
+// loadCatalogue replaces the built-in metrics by the ones of the metric
+// catalogue file.
+func loadCatalogue(path string) error {
+	catalogue, err := table.LoadCatalogue(path)
+	if err != nil {
+		return err
+	}
+	if len(catalogue.Pods) > 0 {
+		pod.ContainerMetricTables = table.NewTable(catalogue.Pods)
+	}
+	if len(catalogue.VMs) > 0 {
+		vm.VmMetricTables = table.NewTable(catalogue.VMs)
+	}
+	return nil
+}
+
+// aggregates returns the continuous aggregates of every metric in use.
+func aggregates() []database.Aggregate {
+	var aggregates []database.Aggregate
+	for _, tables := range []table.Table{pod.ContainerMetricTables, vm.VmMetricTables} {
+		for idx := 0; idx < tables.Len(); idx++ {
+			aggregates = append(aggregates, database.NewAggregates(
+				tables.GetIDTableName(idx),
+				tables.GetMetricTableName(idx),
+				tables.GetPercentile(idx))...)
+		}
+	}
+	return aggregates
+}
+
+// manageAggregates reports the drift of the continuous aggregates, which is
+// resolved in the sync mode except for the stale views.
+func manageAggregates(ctx context.Context, db *gorm.DB, mode string, logger *zap.Logger) error {
+	var (
+		manager = database.NewAggregateManager(db, aggregates())
+		drifts  []database.Drift
+		err     error
+	)
+	if mode == database.AggregatesSync {
+		drifts, err = manager.Sync(ctx)
+	} else {
+		drifts, err = manager.Drift(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		logger.Warn("Continuous aggregate drift",
+			zap.String("view", drift.View),
+			zap.String("reason", drift.Reason),
+			zap.Bool("synced", mode == database.AggregatesSync && drift.Reason != database.DriftStale))
+	}
+	if len(drifts) == 0 {
+		logger.Info("Continuous aggregates are up to date")
+	}
+	return nil
+}
+
+// runAggregates syncs the continuous aggregates and exits, for
+// --aggregates-only.
+func runAggregates(opts *options.Options, logger *zap.Logger) error {
+	db, err := database.Connect()
+	if err != nil {
+		logger.Error("Unable to connect to TimescaleDB", zap.Error(err))
+		return err
+	}
+	if err := database.Migrate(db); err != nil {
+		logger.Error("Unable to migrate TimescaleDB", zap.Error(err))
+		return err
+	}
+	if err := loadCatalogue(*opts.MetricsFile); err != nil {
+		logger.Error("Unable to load metric catalogue", zap.Error(err))
+		return err
+	}
+	if err := manageAggregates(context.Background(), db, database.AggregatesSync, logger.Named("aggregates")); err != nil {
+		logger.Error("Unable to sync continuous aggregates", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 func Run(opts *options.Options, logger *zap.Logger) error {
+	if *opts.AggregatesOnly {
+		return runAggregates(opts, logger)
+	}
+
 	// Start api-server
 	apiServerError := make(chan error)
 
@@ -170,7 +423,7 @@ func Run(opts *options.Options, logger *zap.Logger) error {
 		}
 	}()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	select {
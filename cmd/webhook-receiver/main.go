@@ -0,0 +1,61 @@
+// webhook-receiver is a local stand-in for webhook subscribers. It prints
+// every delivery, verifies its signature and can fail the first deliveries
+// to exercise the retries of the api-server.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/akamensky/argparse"
+
+	"rightsizing-api-server/internal/api/webhook"
+)
+
+func main() {
+	parser := argparse.NewParser("webhook-receiver", "Local stand-in for webhook subscribers")
+	addr := parser.String("a", "addr", &argparse.Options{
+		Help:    "The address to listen on",
+		Default: ":9000",
+	})
+	secret := parser.String("s", "secret", &argparse.Options{
+		Help: "The secret of the subscription",
+	})
+	failures := parser.Int("f", "failures", &argparse.Options{
+		Help:    "The number of deliveries answered with 503 before succeeding",
+		Default: 0,
+	})
+	if err := parser.Parse(os.Args); err != nil {
+		fmt.Print(parser.Usage(err))
+		os.Exit(1)
+	}
+
+	var received int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		verified := webhook.Verify(*secret, body, r.Header.Get(webhook.HeaderSignature))
+		log.Printf("event=%s delivery=%s verified=%t %s",
+			r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), verified, body)
+		if !verified {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if atomic.AddInt64(&received, 1) <= int64(*failures) {
+			http.Error(w, "failing on purpose", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
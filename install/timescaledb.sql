@@ -1,3 +1,6 @@
+-- The api server creates these continuous aggregates of the built-in metrics,
+-- with the 1h and 1d ones as well, when it starts with --aggregates sync or
+-- runs with --aggregates-only. Views created by this script are adopted.
 CREATE EXTENSION timescaledb_toolkit;
 
 create materialized view if not exists ":container_cpu_usage:10min"
@@ -26,3 +29,16 @@ SELECT add_continuous_aggregate_policy(':container_memory_working_set_bytes:10mi
 start_offset => INTERVAL '1h',
 end_offset => INTERVAL '10m',
 schedule_interval => INTERVAL '10m');
+
+create materialized view if not exists ":libvirt_domain_info_memory_usage_bytes:10min"
+with (timescaledb.continuous) as
+select time_bucket('10m', time) as bucket,
+series_id,
+approx_percentile(0.9, percentile_agg(value)) as value
+from prom_data.libvirt_domain_info_memory_usage_bytes
+group by bucket, series_id;
+
+SELECT add_continuous_aggregate_policy(':libvirt_domain_info_memory_usage_bytes:10min',
+start_offset => INTERVAL '1h',
+end_offset => INTERVAL '10m',
+schedule_interval => INTERVAL '10m');
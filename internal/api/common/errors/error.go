@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type NotFoundError struct {
 	Type string
@@ -33,3 +36,37 @@ func NotUniqueErr(t, name string) NotUniqueError {
 		Name: name,
 	}
 }
+
+type UnavailableError struct {
+	Feature string
+	Reason  string
+}
+
+func (e UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable, %s", e.Feature, e.Reason)
+}
+
+func UnavailableErr(feature, reason string) UnavailableError {
+	return UnavailableError{
+		Feature: feature,
+		Reason:  reason,
+	}
+}
+
+type InvalidError struct {
+	Parameter string
+	Value     string
+	Allowed   []string
+}
+
+func (e InvalidError) Error() string {
+	return fmt.Sprintf("invalid %s %s, one of %s", e.Parameter, e.Value, strings.Join(e.Allowed, ", "))
+}
+
+func InvalidErr(parameter, value string, allowed []string) InvalidError {
+	return InvalidError{
+		Parameter: parameter,
+		Value:     value,
+		Allowed:   allowed,
+	}
+}
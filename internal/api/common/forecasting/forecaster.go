@@ -0,0 +1,109 @@
+package forecasting
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/resource"
+	pb "rightsizing-api-server/proto"
+)
+
+const (
+	ProphetForecaster       = "prophet"
+	HoltWintersForecaster   = "holt-winters"
+	SeasonalNaiveForecaster = "seasonal-naive"
+)
+
+// ForecasterNames lists the built-in forecast models.
+var ForecasterNames = []string{
+	ProphetForecaster,
+	HoltWintersForecaster,
+	SeasonalNaiveForecaster,
+}
+
+// Forecaster forecasts the usage of a resource. The result holds the yhat,
+// yhat_upper and yhat_lower series like the analysis server returns them.
+type Forecaster interface {
+	Name() string
+	Forecast(ctx context.Context, data resource.TimeseriesData, options resource.ForecastOptions) ([]*pb.ForecastResponse_Result, error)
+}
+
+// Registry holds the available forecasters and the one used when a request
+// does not choose a model.
+type Registry struct {
+	forecasters map[string]Forecaster
+	defaultName string
+}
+
+func NewRegistry(defaultName string, forecasters ...Forecaster) (*Registry, error) {
+	r := &Registry{
+		forecasters: make(map[string]Forecaster, len(forecasters)),
+		defaultName: defaultName,
+	}
+	for _, forecaster := range forecasters {
+		r.forecasters[forecaster.Name()] = forecaster
+	}
+	if _, exist := r.forecasters[defaultName]; !exist {
+		return nil, fmt.Errorf("default forecaster %s is not registered", defaultName)
+	}
+	return r, nil
+}
+
+// Get returns the forecaster registered as name, or the default one if name is empty.
+func (r *Registry) Get(name string) (Forecaster, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	forecaster, exist := r.forecasters[name]
+	if !exist {
+		return nil, commonerrors.NotFoundErr("forecaster", name)
+	}
+	return forecaster, nil
+}
+
+// Resolve returns the options with the defaults applied and the model set to
+// the forecaster which will be used.
+func (r *Registry) Resolve(options resource.ForecastOptions) (resource.ForecastOptions, error) {
+	forecaster, err := r.Get(options.Model)
+	if err != nil {
+		return resource.ForecastOptions{}, err
+	}
+	options = options.WithDefaults()
+	options.Model = forecaster.Name()
+	return options, nil
+}
+
+type fallbackForecaster struct {
+	primary  Forecaster
+	fallback Forecaster
+	logger   *zap.Logger
+}
+
+// WithFallback returns a forecaster that uses fallback whenever primary fails,
+// e.g. when the analysis server is not reachable. It keeps the name of primary.
+func WithFallback(primary, fallback Forecaster, logger *zap.Logger) Forecaster {
+	return &fallbackForecaster{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (f *fallbackForecaster) Name() string {
+	return f.primary.Name()
+}
+
+func (f *fallbackForecaster) Forecast(ctx context.Context, data resource.TimeseriesData, options resource.ForecastOptions) ([]*pb.ForecastResponse_Result, error) {
+	results, err := f.primary.Forecast(ctx, data, options)
+	if err == nil {
+		return results, nil
+	}
+	f.logger.Warn("forecaster failed, use fallback",
+		zap.String("forecaster", f.primary.Name()),
+		zap.String("fallback", f.fallback.Name()),
+		zap.Error(err))
+	return f.fallback.Forecast(ctx, data, options)
+}
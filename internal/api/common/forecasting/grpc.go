@@ -0,0 +1,33 @@
+package forecasting
+
+import (
+	"context"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	grpcclient "rightsizing-api-server/internal/grpc"
+	pb "rightsizing-api-server/proto"
+)
+
+type grpcForecaster struct {
+	client *grpcclient.Client
+}
+
+// NewGrpcForecaster delegates the forecast to the Prophet model of the
+// analysis server.
+func NewGrpcForecaster(client *grpcclient.Client) Forecaster {
+	return &grpcForecaster{
+		client: client,
+	}
+}
+
+func (f *grpcForecaster) Name() string {
+	return ProphetForecaster
+}
+
+func (f *grpcForecaster) Forecast(ctx context.Context, data resource.TimeseriesData, options resource.ForecastOptions) ([]*pb.ForecastResponse_Result, error) {
+	resp, err := f.client.Forecast(ctx, data, options)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
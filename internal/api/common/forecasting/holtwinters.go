@@ -0,0 +1,149 @@
+package forecasting
+
+import (
+	"context"
+	"math"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	pb "rightsizing-api-server/proto"
+)
+
+// smoothing parameters searched when fitting, the ones with the least
+// one-step-ahead squared error are used
+var (
+	alphas = []float64{0.1, 0.3, 0.5, 0.7, 0.9}
+	betas  = []float64{0, 0.01, 0.05, 0.1}
+	gammas = []float64{0.05, 0.1, 0.3, 0.5}
+)
+
+type holtWintersForecaster struct{}
+
+// NewHoltWintersForecaster forecasts with additive triple exponential
+// smoothing. The seasonality is the longest one of the requested daily and
+// weekly seasonalities covered twice by the usage, without seasonality
+// (double exponential smoothing) if none is.
+func NewHoltWintersForecaster() Forecaster {
+	return &holtWintersForecaster{}
+}
+
+func (f *holtWintersForecaster) Name() string {
+	return HoltWintersForecaster
+}
+
+func (f *holtWintersForecaster) Forecast(_ context.Context, data resource.TimeseriesData, options resource.ForecastOptions) ([]*pb.ForecastResponse_Result, error) {
+	s, err := resample(data, options.Step)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.values) < 2 {
+		return nil, errNoUsage
+	}
+	return results(s, fitHoltWinters(s.values, s.seasonLength(options.Seasonality)), options), nil
+}
+
+type holtWinters struct {
+	alpha, beta, gamma float64
+	// season length in steps, 0 without seasonality
+	m      int
+	level  float64
+	trend  float64
+	season []float64
+	// index of the last value
+	last int
+	// variance of the one-step-ahead errors
+	variance float64
+}
+
+func fitHoltWinters(values []float64, m int) *holtWinters {
+	gammaGrid := gammas
+	if m == 0 {
+		gammaGrid = []float64{0}
+	}
+
+	var best *holtWinters
+	for _, alpha := range alphas {
+		for _, beta := range betas {
+			for _, gamma := range gammaGrid {
+				hw := &holtWinters{alpha: alpha, beta: beta, gamma: gamma, m: m}
+				hw.fit(values)
+				if best == nil || hw.variance < best.variance {
+					best = hw
+				}
+			}
+		}
+	}
+	return best
+}
+
+// fit initializes the components from the first two seasons (or values) and
+// smooths the rest of the values.
+func (hw *holtWinters) fit(values []float64) {
+	start := 1
+	if hw.m == 0 {
+		hw.level = values[0]
+		hw.trend = values[1] - values[0]
+	} else {
+		first, second := mean(values[:hw.m]), mean(values[hw.m:2*hw.m])
+		hw.level = first
+		hw.trend = (second - first) / float64(hw.m)
+		hw.season = make([]float64, hw.m)
+		for i := 0; i < hw.m; i++ {
+			hw.season[i] = values[i] - first
+		}
+		start = hw.m
+	}
+
+	var sse float64
+	for t := start; t < len(values); t++ {
+		seasonal := hw.seasonal(t)
+		err := values[t] - (hw.level + hw.trend + seasonal)
+		sse += err * err
+
+		level := hw.alpha*(values[t]-seasonal) + (1-hw.alpha)*(hw.level+hw.trend)
+		hw.trend = hw.beta*(level-hw.level) + (1-hw.beta)*hw.trend
+		hw.level = level
+		if hw.m > 0 {
+			hw.season[t%hw.m] = hw.gamma*(values[t]-level) + (1-hw.gamma)*seasonal
+		}
+	}
+	hw.last = len(values) - 1
+	if n := len(values) - start; n > 0 {
+		hw.variance = sse / float64(n)
+	}
+}
+
+func (hw *holtWinters) seasonal(t int) float64 {
+	if hw.m == 0 {
+		return 0
+	}
+	return hw.season[t%hw.m]
+}
+
+// predict returns the forecasts with the standard errors of the additive
+// Holt-Winters model: var(h) = var * (1 + sum_{j<h} (alpha*(1+j*beta) + gamma*[j%m==0])^2)
+func (hw *holtWinters) predict(steps int) ([]float64, []float64) {
+	var (
+		yhat   = make([]float64, steps)
+		stderr = make([]float64, steps)
+		sum    float64
+	)
+	for h := 1; h <= steps; h++ {
+		yhat[h-1] = hw.level + float64(h)*hw.trend + hw.seasonal(hw.last+h)
+		stderr[h-1] = math.Sqrt(hw.variance * (1 + sum))
+
+		c := hw.alpha * (1 + float64(h)*hw.beta)
+		if hw.m > 0 && h%hw.m == 0 {
+			c += hw.gamma
+		}
+		sum += c * c
+	}
+	return yhat, stderr
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,74 @@
+package forecasting
+
+import (
+	"context"
+	"math"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	pb "rightsizing-api-server/proto"
+)
+
+type seasonalNaiveForecaster struct{}
+
+// NewSeasonalNaiveForecaster forecasts the value of the last season at the
+// same time, e.g. the usage of yesterday with daily seasonality. Without a
+// seasonality covered twice by the usage, the last value is repeated.
+func NewSeasonalNaiveForecaster() Forecaster {
+	return &seasonalNaiveForecaster{}
+}
+
+func (f *seasonalNaiveForecaster) Name() string {
+	return SeasonalNaiveForecaster
+}
+
+func (f *seasonalNaiveForecaster) Forecast(_ context.Context, data resource.TimeseriesData, options resource.ForecastOptions) ([]*pb.ForecastResponse_Result, error) {
+	s, err := resample(data, options.Step)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.values) < 2 {
+		return nil, errNoUsage
+	}
+
+	m := s.seasonLength(options.Seasonality)
+	if m == 0 {
+		m = 1
+	}
+	return results(s, newSeasonalNaive(s.values, m), options), nil
+}
+
+type seasonalNaive struct {
+	values []float64
+	m      int
+	// variance of the errors of forecasting one season ahead
+	variance float64
+}
+
+func newSeasonalNaive(values []float64, m int) *seasonalNaive {
+	var sse float64
+	for t := m; t < len(values); t++ {
+		err := values[t] - values[t-m]
+		sse += err * err
+	}
+	return &seasonalNaive{
+		values:   values,
+		m:        m,
+		variance: sse / float64(len(values)-m),
+	}
+}
+
+// predict returns the value of the last season with the standard error
+// growing with the number of seasons ahead.
+func (sn *seasonalNaive) predict(steps int) ([]float64, []float64) {
+	var (
+		last   = len(sn.values) - 1
+		yhat   = make([]float64, steps)
+		stderr = make([]float64, steps)
+	)
+	for h := 1; h <= steps; h++ {
+		seasons := (h-1)/sn.m + 1
+		yhat[h-1] = sn.values[last+h-seasons*sn.m]
+		stderr[h-1] = math.Sqrt(sn.variance * float64(seasons))
+	}
+	return yhat, stderr
+}
@@ -0,0 +1,99 @@
+package forecasting
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+)
+
+type predictiveRecommender struct {
+	recommender rightsizing.Recommender
+	forecaster  Forecaster
+	options     resource.ForecastOptions
+	logger      *zap.Logger
+}
+
+// NewPredictiveRecommender returns a recommender that recommends the larger of
+// the recommendation of the history and the peak of the forecast upper bound
+// (yhat_upper) over the horizon, so that growing usage gets headroom before
+// it needs it. The recommendation of the history is kept when the forecast
+// fails, e.g. with too short a history. The recommender is a batch
+// recommender if recommender is.
+func NewPredictiveRecommender(recommender rightsizing.Recommender, forecaster Forecaster, options resource.ForecastOptions, logger *zap.Logger) rightsizing.Recommender {
+	r := &predictiveRecommender{
+		recommender: recommender,
+		forecaster:  forecaster,
+		options:     options,
+		logger:      logger,
+	}
+	if batcher, ok := recommender.(rightsizing.BatchRecommender); ok {
+		return &predictiveBatchRecommender{
+			predictiveRecommender: r,
+			batcher:               batcher,
+		}
+	}
+	return r
+}
+
+// Name tells the predictive recommendations apart from the historical ones
+// in the results store, e.g. percentile+prophet.
+func (r *predictiveRecommender) Name() string {
+	return r.recommender.Name() + "+" + r.forecaster.Name()
+}
+
+func (r *predictiveRecommender) Recommend(ctx context.Context, data resource.TimeseriesData) (float64, error) {
+	value, err := r.recommender.Recommend(ctx, data)
+	if err != nil {
+		return 0, err
+	}
+	return r.withForecast(ctx, data, value), nil
+}
+
+// withForecast returns the larger of value and the peak of the forecast upper
+// bound of data, or value if the forecast fails.
+func (r *predictiveRecommender) withForecast(ctx context.Context, data resource.TimeseriesData, value float64) float64 {
+	results, err := r.forecaster.Forecast(ctx, data, r.options)
+	if err != nil {
+		r.logger.Warn("forecast failed, use the historical recommendation",
+			zap.String("forecaster", r.forecaster.Name()),
+			zap.Error(err))
+		return value
+	}
+	for _, result := range results {
+		if result.Name != resource.ForecastUpperBound {
+			continue
+		}
+		for _, point := range result.Data {
+			if point.Value > value {
+				value = point.Value
+			}
+		}
+	}
+	return value
+}
+
+// predictiveBatchRecommender recommends the history of a batch with one call
+// of the batch recommender, and forecasts every series of the batch.
+type predictiveBatchRecommender struct {
+	*predictiveRecommender
+	batcher rightsizing.BatchRecommender
+}
+
+var _ rightsizing.BatchRecommender = (*predictiveBatchRecommender)(nil)
+
+func (r *predictiveBatchRecommender) RecommendBatch(ctx context.Context, series map[string]resource.TimeseriesData) (map[string]float64, error) {
+	values, err := r.batcher.RecommendBatch(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+	for id, data := range series {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		values[id] = r.withForecast(ctx, data, values[id])
+	}
+	return values, nil
+}
@@ -0,0 +1,144 @@
+package forecasting
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	pb "rightsizing-api-server/proto"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+	// full seasons of usage needed to fit a seasonality
+	minSeasons = 2
+)
+
+// seasonalities the native forecasters can fit, longest first
+var seasonPeriods = []struct {
+	name   string
+	period time.Duration
+}{
+	{"weekly", week},
+	{"daily", day},
+}
+
+var errNoUsage = errors.New("not enough usage to forecast")
+
+// series is the usage resampled at a fixed step.
+type series struct {
+	// unix time of the first value
+	start  int64
+	step   int64
+	values []float64
+}
+
+// resample averages the usage in buckets of step and fills the empty buckets
+// by linear interpolation. data is sorted by time.
+func resample(data resource.TimeseriesData, step time.Duration) (*series, error) {
+	if len(data) == 0 {
+		return nil, errNoUsage
+	}
+
+	s := &series{
+		step: int64(step / time.Second),
+	}
+	s.start = data[0].Time - data[0].Time%s.step
+	n := int((data[len(data)-1].Time-s.start)/s.step) + 1
+
+	var (
+		sums   = make([]float64, n)
+		counts = make([]int, n)
+	)
+	for _, point := range data {
+		i := int((point.Time - s.start) / s.step)
+		sums[i] += point.Value
+		counts[i]++
+	}
+
+	s.values = make([]float64, n)
+	last := -1
+	for i := range s.values {
+		if counts[i] == 0 {
+			continue
+		}
+		s.values[i] = sums[i] / float64(counts[i])
+		for j := last + 1; j < i && last >= 0; j++ {
+			ratio := float64(j-last) / float64(i-last)
+			s.values[j] = s.values[last] + (s.values[i]-s.values[last])*ratio
+		}
+		last = i
+	}
+	return s, nil
+}
+
+func (s *series) end() int64 {
+	return s.start + int64(len(s.values)-1)*s.step
+}
+
+// seasonLength returns the number of steps of the longest requested
+// seasonality the series is long enough for, or 0 if none is. All the
+// seasonalities are candidates if none is requested.
+func (s *series) seasonLength(seasonality []string) int {
+	requested := make(map[string]bool, len(seasonality))
+	for _, name := range seasonality {
+		requested[name] = true
+	}
+
+	for _, season := range seasonPeriods {
+		if len(requested) > 0 && !requested[season.name] {
+			continue
+		}
+		m := int(int64(season.period/time.Second) / s.step)
+		if m >= 2 && len(s.values) >= minSeasons*m {
+			return m
+		}
+	}
+	return 0
+}
+
+// model forecasts the given number of steps after the last value and the
+// standard error of each forecast.
+type model interface {
+	predict(steps int) (yhat, stderr []float64)
+}
+
+// results returns the forecast of the model from the origin until the
+// horizon, in the same series as the analysis server.
+func results(s *series, m model, options resource.ForecastOptions) []*pb.ForecastResponse_Result {
+	origin := options.Origin
+	if origin.IsZero() {
+		origin = time.Now()
+	}
+
+	var (
+		now   = origin.Unix()
+		end   = now + int64(options.Horizon/time.Second)
+		steps = int((end - s.end()) / s.step)
+		// normal quantile of the interval width
+		z = math.Sqrt2 * math.Erfinv(options.IntervalWidth)
+	)
+
+	yhat := &pb.ForecastResponse_Result{Name: "yhat"}
+	upper := &pb.ForecastResponse_Result{Name: "yhat_upper"}
+	lower := &pb.ForecastResponse_Result{Name: "yhat_lower"}
+	if steps <= 0 {
+		return []*pb.ForecastResponse_Result{yhat, upper, lower}
+	}
+
+	values, stderr := m.predict(steps)
+	for h := 1; h <= steps; h++ {
+		timestamp := s.end() + int64(h)*s.step
+		if timestamp < now {
+			continue
+		}
+		value := values[h-1]
+		// usage is never negative
+		yhat.Data = append(yhat.Data, &pb.TimeSeriesDatapoint{Timestamp: timestamp, Value: math.Max(value, 0)})
+		upper.Data = append(upper.Data, &pb.TimeSeriesDatapoint{Timestamp: timestamp, Value: math.Max(value+z*stderr[h-1], 0)})
+		lower.Data = append(lower.Data, &pb.TimeSeriesDatapoint{Timestamp: timestamp, Value: math.Max(value-z*stderr[h-1], 0)})
+	}
+	return []*pb.ForecastResponse_Result{yhat, upper, lower}
+}
@@ -1,28 +1,87 @@
 package query
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/resource"
 	"rightsizing-api-server/internal/utils"
 )
 
+// rightsizing modes
+const (
+	// recommend from the usage history
+	ModeHistorical = "historical"
+	// recommend at least the upper bound of the forecast of the usage
+	ModePredictive = "predictive"
+)
+
+// recommenders are the names which the recommender parameter is validated
+// against, any name is accepted if empty. See SetRecommenders.
+var recommenders []string
+
+// SetRecommenders sets the names of the registered recommenders, so that an
+// unknown recommender is a bad request rather than a failure of the service.
+func SetRecommenders(names []string) {
+	recommenders = names
+}
+
 // Query 파라미터들 parsing 하기 위해 사용함
 type parseQuery struct {
 	Namespace string `query:"namespace,omitempty" description:"the namespace of object (optional)"`
 	Name      string `query:"name,omitempty" description:"the name of object"`
 	StartTime string `query:"start,omitempty" json:"-"`
 	EndTime   string `query:"end,omitempty" json:"-"`
+	// recommendation strategy (optional, server default if empty)
+	Recommender string `query:"recommender,omitempty" description:"the recommendation strategy"`
+	Mode        string `query:"mode,omitempty" description:"the rightsizing mode (historical, predictive)"`
+	// forecast options (optional, server default if empty)
+	Model         string  `query:"model,omitempty" description:"the forecast model"`
+	Horizon       string  `query:"horizon,omitempty" description:"the forecast window (e.g. 6h)"`
+	Step          string  `query:"step,omitempty" description:"the interval between forecast datapoints (e.g. 5m)"`
+	IntervalWidth float64 `query:"interval_width,omitempty" description:"the width of the forecast uncertainty interval"`
+	Seasonality   string  `query:"seasonality,omitempty" description:"the seasonalities to fit (daily, weekly, yearly)"`
+	// usage resolution (optional, the finest if empty). step is the usage
+	// interval too unless the mode is predictive, where step is the forecast
+	// interval and resolution the usage interval.
+	Resolution string `query:"resolution,omitempty" description:"the interval between usage datapoints (e.g. 1h)"`
+	MaxPoints  int    `query:"max_points,omitempty" description:"the maximum number of usage datapoints of a series"`
 }
 
 type Query struct {
-	ID        string
-	Namespace string
-	Name      string
-	StartTime time.Time
-	EndTime   time.Time
+	ID          string
+	Namespace   string
+	Name        string
+	StartTime   time.Time
+	EndTime     time.Time
+	Recommender string
+	// recommend for the forecast peak as well, see ModePredictive
+	Predictive bool
+	Forecast   resource.ForecastOptions
+	// interval between the usage datapoints, the finest if zero
+	Resolution time.Duration
+	// context of the request, see Context
+	ctx context.Context
+}
+
+// Context returns the context of the request, done when the request times
+// out. It is the background context for queries built without a request.
+func (q Query) Context() context.Context {
+	if q.ctx == nil {
+		return context.Background()
+	}
+	return q.ctx
+}
+
+// WithContext returns a copy of the query with the context.
+func (q Query) WithContext(ctx context.Context) Query {
+	q.ctx = ctx
+	return q
 }
 
 func (q parseQuery) ParseAndValidate(c *fiber.Ctx) (Query, error) {
@@ -55,15 +114,71 @@ func (q parseQuery) ParseAndValidate(c *fiber.Ctx) (Query, error) {
 		return Query{}, errors.New("the end time should be after the start time")
 	}
 
+	if q.Recommender != "" && len(recommenders) > 0 && !contains(recommenders, q.Recommender) {
+		return Query{}, commonerrors.InvalidErr("recommender", q.Recommender, recommenders)
+	}
+
+	if q.Mode != "" && q.Mode != ModeHistorical && q.Mode != ModePredictive {
+		return Query{}, fmt.Errorf("unknown mode %q", q.Mode)
+	}
+
+	forecast, err := resource.ParseForecastOptions(q.Model, q.Horizon, q.Step, q.IntervalWidth, q.Seasonality)
+	if err != nil {
+		return Query{}, err
+	}
+
+	usageStep := q.Resolution
+	if usageStep == "" && q.Mode != ModePredictive {
+		usageStep = q.Step
+	}
+	resolution, err := parseResolution(usageStep, q.MaxPoints, endTime.Sub(startTime))
+	if err != nil {
+		return Query{}, err
+	}
+
 	return Query{
-		ID:        id,
-		Namespace: q.Namespace,
-		Name:      q.Name,
-		StartTime: startTime,
-		EndTime:   endTime,
+		ID:          id,
+		Namespace:   q.Namespace,
+		Name:        q.Name,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Recommender: q.Recommender,
+		Predictive:  q.Mode == ModePredictive,
+		Forecast:    forecast,
+		Resolution:  resolution,
+		ctx:         c.UserContext(),
 	}, nil
 }
 
+// parseResolution returns the coarser of the resolution and the interval
+// which fits the window in maxPoints datapoints. Empty values are ignored.
+func parseResolution(value string, maxPoints int, window time.Duration) (time.Duration, error) {
+	var resolution time.Duration
+	if value != "" {
+		var err error
+		if resolution, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+		if resolution < 0 {
+			return 0, errors.New("resolution must not be negative")
+		}
+	}
+
+	if maxPoints < 0 {
+		return 0, errors.New("max_points must not be negative")
+	}
+	if maxPoints > 0 {
+		// rounded up to a minute, so that the window holds maxPoints
+		// intervals at most
+		interval := (window + time.Duration(maxPoints) - 1) / time.Duration(maxPoints)
+		interval = (interval + time.Minute - 1).Truncate(time.Minute)
+		if interval > resolution {
+			resolution = interval
+		}
+	}
+	return resolution, nil
+}
+
 func ParseAndValidate(c *fiber.Ctx) (Query, error) {
 	query := &parseQuery{}
 	if err := c.QueryParser(query); err != nil {
@@ -71,3 +186,12 @@ func ParseAndValidate(c *fiber.Ctx) (Query, error) {
 	}
 	return query.ParseAndValidate(c)
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	pb "rightsizing-api-server/proto"
+)
+
+// forecast series compared with the actual usage
+const (
+	ForecastValue      = "yhat"
+	ForecastLowerBound = "yhat_lower"
+)
+
+// Accuracy is the error of a forecast against the actual usage of the same
+// window. Coverage is the ratio of the actual values within the uncertainty
+// interval (yhat_lower, yhat_upper), close to the interval width when the
+// interval is calibrated.
+type Accuracy struct {
+	// number of actual values matched with a forecast point
+	Samples int     `json:"samples"`
+	MAE     float64 `json:"mae"`
+	// mean absolute percentage error in percent, of the non-zero actual values
+	MAPE     float64 `json:"mape"`
+	RMSE     float64 `json:"rmse"`
+	Coverage float64 `json:"coverage"`
+}
+
+// Split returns the datapoints up to cutoff (unix time) and the ones after it.
+// data is sorted by time.
+func (data TimeseriesData) Split(cutoff int64) (TimeseriesData, TimeseriesData) {
+	i := sort.Search(len(data), func(i int) bool {
+		return data[i].Time > cutoff
+	})
+	return data[:i], data[i:]
+}
+
+// NewAccuracy compares the actual usage with the forecast series. Each actual
+// value is compared with the forecast point nearest in time, values farther
+// than half a step from any forecast point are skipped.
+func NewAccuracy(actual TimeseriesData, results []*pb.ForecastResponse_Result, step time.Duration) *Accuracy {
+	series := make(map[string][]*pb.TimeSeriesDatapoint, len(results))
+	for _, result := range results {
+		series[result.Name] = result.Data
+	}
+	var (
+		yhat      = series[ForecastValue]
+		upper     = series[ForecastUpperBound]
+		lower     = series[ForecastLowerBound]
+		tolerance = int64(step/time.Second) / 2
+	)
+
+	var (
+		accuracy              = &Accuracy{}
+		absSum, squareSum     float64
+		percentSum            float64
+		percentCount, covered int
+	)
+	for _, point := range actual {
+		i := nearest(yhat, point.Time)
+		if i < 0 || abs(yhat[i].Timestamp-point.Time) > tolerance {
+			continue
+		}
+
+		err := point.Value - yhat[i].Value
+		absSum += math.Abs(err)
+		squareSum += err * err
+		if point.Value != 0 {
+			percentSum += math.Abs(err / point.Value)
+			percentCount++
+		}
+		if i < len(upper) && i < len(lower) && point.Value >= lower[i].Value && point.Value <= upper[i].Value {
+			covered++
+		}
+		accuracy.Samples++
+	}
+
+	if accuracy.Samples == 0 {
+		return accuracy
+	}
+	n := float64(accuracy.Samples)
+	accuracy.MAE = absSum / n
+	accuracy.RMSE = math.Sqrt(squareSum / n)
+	accuracy.Coverage = float64(covered) / n
+	if percentCount > 0 {
+		accuracy.MAPE = percentSum / float64(percentCount) * 100
+	}
+	return accuracy
+}
+
+// nearest returns the index of the point nearest to timestamp, -1 if there is
+// none. points are sorted by time.
+func nearest(points []*pb.TimeSeriesDatapoint, timestamp int64) int {
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Timestamp >= timestamp
+	})
+	switch {
+	case len(points) == 0:
+		return -1
+	case i == len(points):
+		return i - 1
+	case i > 0 && timestamp-points[i-1].Timestamp < points[i].Timestamp-timestamp:
+		return i - 1
+	}
+	return i
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
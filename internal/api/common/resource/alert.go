@@ -0,0 +1,54 @@
+package resource
+
+import (
+	pb "rightsizing-api-server/proto"
+)
+
+const (
+	QuotaRequest = "request"
+	QuotaLimit   = "limit"
+
+	// forecast series used for capacity alerts
+	ForecastUpperBound = "yhat_upper"
+)
+
+// CapacityAlert is the earliest predicted time at which the upper bound of
+// the forecast reaches the quota of a resource.
+type CapacityAlert struct {
+	Resource  string  `json:"resource"`
+	Quota     string  `json:"quota"`
+	Threshold float64 `json:"threshold"`
+	// unix time of the first forecast point at or above the threshold
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// NewCapacityAlerts returns the alerts of the request and the limit of the
+// resource. Quotas which are not set or not reached are skipped.
+func NewCapacityAlerts(name string, upper []*pb.TimeSeriesDatapoint, request, limit float64) []*CapacityAlert {
+	var alerts []*CapacityAlert
+	for _, quota := range []struct {
+		name  string
+		value float64
+	}{
+		{QuotaRequest, request},
+		{QuotaLimit, limit},
+	} {
+		if quota.value <= 0 {
+			continue
+		}
+		for _, point := range upper {
+			if point.Value >= quota.value {
+				alerts = append(alerts, &CapacityAlert{
+					Resource:  name,
+					Quota:     quota.name,
+					Threshold: quota.value,
+					Time:      point.Timestamp,
+					Value:     point.Value,
+				})
+				break
+			}
+		}
+	}
+	return alerts
+}
@@ -13,3 +13,49 @@ type CachedClusterInfo struct {
 	Name      string
 	Info      map[string]*ResourceUsageInfo
 }
+
+// ResourceSummary is the rollup of requested, used and recommended resources
+// of several objects.
+type ResourceSummary struct {
+	Request     float64 `json:"request"`
+	Usage       float64 `json:"usage"`
+	Recommended float64 `json:"recommended"`
+	// requested amount exceeding the recommended request
+	Reclaimable float64 `json:"reclaimable"`
+	// monthly cost
+	CurrentCost     float64 `json:"current_cost"`
+	RecommendedCost float64 `json:"recommended_cost"`
+	Savings         float64 `json:"savings"`
+}
+
+// Add accumulates info into the summary. Objects without a recommendation are
+// counted as if they keep their current request.
+func (s *ResourceSummary) Add(info *ResourceUsageInfo) {
+	s.Request += info.Request
+	s.Usage += info.CurrentUsage
+	if info.Cost != nil {
+		s.CurrentCost += info.Cost.Current
+		s.RecommendedCost += info.Cost.Recommended
+		s.Savings += info.Cost.Savings
+	}
+
+	if info.Recommendation == nil {
+		s.Recommended += info.Request
+		return
+	}
+	s.Recommended += info.Recommendation.Request
+	if info.Request > info.Recommendation.Request {
+		s.Reclaimable += info.Request - info.Recommendation.Request
+	}
+}
+
+// Merge accumulates another summary into the summary.
+func (s *ResourceSummary) Merge(other *ResourceSummary) {
+	s.Request += other.Request
+	s.Usage += other.Usage
+	s.Recommended += other.Recommended
+	s.Reclaimable += other.Reclaimable
+	s.CurrentCost += other.CurrentCost
+	s.RecommendedCost += other.RecommendedCost
+	s.Savings += other.Savings
+}
@@ -0,0 +1,142 @@
+package resource
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// analysis server defaults, used when an option is not set
+const (
+	DefaultForecastHorizon = 6 * time.Hour
+	DefaultForecastStep    = 5 * time.Minute
+	DefaultIntervalWidth   = 0.1
+
+	minForecastStep = time.Minute
+	// number of forecast datapoints (horizon / step) the analysis server fits at most
+	maxForecastPoints = 2016
+)
+
+// seasonalities of the analysis server model
+var seasonalities = map[string]bool{
+	"daily":  true,
+	"weekly": true,
+	"yearly": true,
+}
+
+// ForecastOptions are the parameters of a forecast. Zero values are replaced
+// by the defaults of the analysis server.
+type ForecastOptions struct {
+	// forecast model, the server default if empty
+	Model string
+	// forecast window from the origin
+	Horizon time.Duration
+	// start of the forecast window, now if zero. Only backtests forecast
+	// from the past, so it is not part of the key.
+	Origin time.Time
+	// interval between the forecast datapoints
+	Step time.Duration
+	// width of the uncertainty interval (yhat_lower, yhat_upper)
+	IntervalWidth float64
+	// seasonalities to fit, detected automatically if empty
+	Seasonality []string
+}
+
+// ParseForecastOptions parses the options from query parameters such as
+// model=holt-winters, horizon=24h, step=10m, interval_width=0.8 and
+// seasonality=daily,weekly. Empty values keep the default.
+func ParseForecastOptions(model, horizon, step string, intervalWidth float64, seasonality string) (ForecastOptions, error) {
+	options := ForecastOptions{
+		Model:         model,
+		IntervalWidth: intervalWidth,
+	}
+
+	var err error
+	if horizon != "" {
+		if options.Horizon, err = time.ParseDuration(horizon); err != nil {
+			return ForecastOptions{}, err
+		}
+	}
+	if step != "" {
+		if options.Step, err = time.ParseDuration(step); err != nil {
+			return ForecastOptions{}, err
+		}
+	}
+	if strings.TrimSpace(seasonality) != "" {
+		for _, name := range strings.Split(seasonality, ",") {
+			options.Seasonality = append(options.Seasonality, strings.TrimSpace(name))
+		}
+	}
+
+	options = options.WithDefaults()
+	if err := options.Validate(); err != nil {
+		return ForecastOptions{}, err
+	}
+	return options, nil
+}
+
+// WithDefaults returns the options with the unset values replaced by the
+// defaults and the seasonalities sorted, so that the same forecasts have the
+// same key.
+func (o ForecastOptions) WithDefaults() ForecastOptions {
+	if o.Horizon == 0 {
+		o.Horizon = DefaultForecastHorizon
+	}
+	if o.Step == 0 {
+		o.Step = DefaultForecastStep
+	}
+	if o.IntervalWidth == 0 {
+		o.IntervalWidth = DefaultIntervalWidth
+	}
+
+	var seasonality []string
+	seen := make(map[string]bool)
+	for _, name := range o.Seasonality {
+		if !seen[name] {
+			seen[name] = true
+			seasonality = append(seasonality, name)
+		}
+	}
+	sort.Strings(seasonality)
+	o.Seasonality = seasonality
+	return o
+}
+
+func (o ForecastOptions) Validate() error {
+	if o.Step < minForecastStep {
+		return fmt.Errorf("step must be at least %s", minForecastStep)
+	}
+	if o.Horizon < o.Step {
+		return fmt.Errorf("horizon must be at least the step")
+	}
+	if o.Horizon/o.Step > maxForecastPoints {
+		return fmt.Errorf("horizon must be at most %d steps", maxForecastPoints)
+	}
+	if o.IntervalWidth <= 0 || o.IntervalWidth >= 1 {
+		return fmt.Errorf("interval_width must be between 0 and 1")
+	}
+	for _, name := range o.Seasonality {
+		if !seasonalities[name] {
+			return fmt.Errorf("unknown seasonality %q", name)
+		}
+	}
+	return nil
+}
+
+// Key identifies the options in the cache key of a forecast task.
+func (o ForecastOptions) Key() string {
+	return fmt.Sprintf("%s_%s_%s_%s_%s", o.Model, o.Horizon, o.Step,
+		strconv.FormatFloat(o.IntervalWidth, 'f', -1, 64), strings.Join(o.Seasonality, ","))
+}
+
+// Parameters returns the options recorded with the forecast in the results store.
+func (o ForecastOptions) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"horizon":        o.Horizon.String(),
+		"step":           o.Step.String(),
+		"interval_width": o.IntervalWidth,
+		"seasonality":    o.Seasonality,
+	}
+}
@@ -0,0 +1,176 @@
+package resource
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"rightsizing-api-server/internal/models"
+)
+
+const (
+	// limit 추천값은 관측된 최대 사용량에 margin을 더한 값으로 계산
+	limitMargin = 0.2
+
+	mebibyte = 1 << 20
+	gibibyte = 1 << 30
+)
+
+// units of the metric catalogue which are formatted as kubernetes quantities
+const (
+	UnitCores = "cores"
+	UnitBytes = "bytes"
+)
+
+// Recommendation is a suggested request/limit pair for a single resource,
+// compared against the quota currently applied to the object.
+type Recommendation struct {
+	Request         float64 `json:"request"`
+	Limit           float64 `json:"limit"`
+	CurrentRequest  float64 `json:"current_request"`
+	CurrentLimit    float64 `json:"current_limit"`
+	RequestDelta    float64 `json:"request_delta"`
+	LimitDelta      float64 `json:"limit_delta"`
+	RequestQuantity string  `json:"request_quantity"`
+	LimitQuantity   string  `json:"limit_quantity"`
+}
+
+// NewRecommendation builds a recommendation from explicit request/limit values.
+// The values are rounded up to the granularity of their kubernetes quantity
+// so that the numbers match what ends up in the manifest.
+func NewRecommendation(unit string, request, limit, currentRequest, currentLimit float64) *Recommendation {
+	request, requestQuantity := FormatQuantity(unit, request)
+	limit, limitQuantity := FormatQuantity(unit, limit)
+
+	return &Recommendation{
+		Request:         request,
+		Limit:           limit,
+		CurrentRequest:  currentRequest,
+		CurrentLimit:    currentLimit,
+		RequestDelta:    request - currentRequest,
+		LimitDelta:      limit - currentLimit,
+		RequestQuantity: requestQuantity,
+		LimitQuantity:   limitQuantity,
+	}
+}
+
+// Recommend sets the recommendation from the optimized usage and the peak of
+// the usage history. Nothing is recommended until an optimized usage exists.
+func (info *ResourceUsageInfo) Recommend() *Recommendation {
+	if info.OptimizedUsage <= 0 {
+		return nil
+	}
+
+	request := info.OptimizedUsage
+	limit := math.Max(info.Usage.Max()*(1+limitMargin), request)
+
+	info.Recommendation = NewRecommendation(info.Unit, request, limit, info.Request, info.Limit)
+	return info.Recommendation
+}
+
+// Max returns the largest value in the time series.
+func (data TimeseriesData) Max() float64 {
+	var max float64
+	for _, point := range data {
+		if point.Value > max {
+			max = point.Value
+		}
+	}
+	return max
+}
+
+// SumRecommendations adds up the recommendations of several containers.
+// It returns nil when none of them has a recommendation.
+func SumRecommendations(unit string, recommendations ...*Recommendation) *Recommendation {
+	var (
+		found                                        bool
+		request, limit, currentRequest, currentLimit float64
+	)
+	for _, r := range recommendations {
+		if r == nil {
+			continue
+		}
+		found = true
+		request += r.Request
+		limit += r.Limit
+		currentRequest += r.CurrentRequest
+		currentLimit += r.CurrentLimit
+	}
+	if !found {
+		return nil
+	}
+	return NewRecommendation(unit, request, limit, currentRequest, currentLimit)
+}
+
+// FormatQuantity rounds value up to a kubernetes quantity and returns both the
+// rounded value and its string form (e.g. 250m, 512Mi).
+// Values of the other units than cores and bytes are left as they are.
+func FormatQuantity(unit string, value float64) (float64, string) {
+	if value <= 0 {
+		return 0, "0"
+	}
+
+	switch unit {
+	case UnitCores:
+		milli := math.Ceil(value * 1000)
+		if math.Mod(milli, 1000) == 0 {
+			return milli / 1000, strconv.FormatInt(int64(milli/1000), 10)
+		}
+		return milli / 1000, fmt.Sprintf("%dm", int64(milli))
+	case UnitBytes:
+		mi := math.Ceil(value / mebibyte)
+		if math.Mod(mi, 1024) == 0 {
+			return mi * mebibyte, fmt.Sprintf("%dGi", int64(mi*mebibyte/gibibyte))
+		}
+		return mi * mebibyte, fmt.Sprintf("%dMi", int64(mi))
+	}
+	return value, strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// RecommendationPoint is a recommendation recorded at a point in time.
+type RecommendationPoint struct {
+	Time           int64   `json:"time"`
+	Recommender    string  `json:"recommender"`
+	OptimizedUsage float64 `json:"optimized_usage"`
+	Request        float64 `json:"request"`
+	Limit          float64 `json:"limit"`
+	CurrentRequest float64 `json:"current_request"`
+	CurrentLimit   float64 `json:"current_limit"`
+}
+
+func NewRecommendationPoint(record *models.RecommendationResult) *RecommendationPoint {
+	return &RecommendationPoint{
+		Time:           record.CreatedAt.Unix(),
+		Recommender:    record.Recommender,
+		OptimizedUsage: record.OptimizedUsage,
+		Request:        record.Request,
+		Limit:          record.Limit,
+		CurrentRequest: record.CurrentRequest,
+		CurrentLimit:   record.CurrentLimit,
+	}
+}
+
+// Cost is the monthly cost of the current and the recommended request.
+type Cost struct {
+	Currency    string  `json:"currency,omitempty"`
+	Current     float64 `json:"current_monthly"`
+	Recommended float64 `json:"recommended_monthly"`
+	Savings     float64 `json:"savings_monthly"`
+}
+
+// SumCosts adds up several costs. It returns nil when none of them is set.
+func SumCosts(costs ...*Cost) *Cost {
+	var sum *Cost
+	for _, cost := range costs {
+		if cost == nil {
+			continue
+		}
+		if sum == nil {
+			sum = &Cost{Currency: cost.Currency}
+		}
+		sum.Current += cost.Current
+		sum.Recommended += cost.Recommended
+		sum.Savings += cost.Savings
+	}
+	return sum
+}
@@ -2,6 +2,7 @@ package resource
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"math"
 	"sync"
 
@@ -29,8 +30,69 @@ type TimeSeriesDatapoint struct {
 }
 
 type ForecastUsage struct {
-	Name  string                               `json:"name"`
+	Name string `json:"name"`
+	// forecast series by name, kept for compatibility.
+	// series of different resources overwrite each other, use Resources instead.
 	Usage map[string][]*pb.TimeSeriesDatapoint `json:"usage"`
+	// forecast series by resource and name
+	Resources map[string]map[string][]*pb.TimeSeriesDatapoint `json:"resources,omitempty"`
+	Alerts    []*CapacityAlert                                `json:"alerts,omitempty"`
+}
+
+func NewForecastUsage(name string) *ForecastUsage {
+	return &ForecastUsage{
+		Name:      name,
+		Usage:     make(map[string][]*pb.TimeSeriesDatapoint),
+		Resources: make(map[string]map[string][]*pb.TimeSeriesDatapoint),
+	}
+}
+
+// Add adds the forecast series of the resource.
+func (f *ForecastUsage) Add(resource string, results []*pb.ForecastResponse_Result) {
+	if _, exist := f.Resources[resource]; !exist {
+		f.Resources[resource] = make(map[string][]*pb.TimeSeriesDatapoint)
+	}
+	for _, result := range results {
+		f.Usage[result.Name] = result.Data
+		f.Resources[resource][result.Name] = result.Data
+	}
+}
+
+// ForecastRun is a forecast recorded in the results store.
+type ForecastRun struct {
+	*models.ForecastResult
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Result     []*ForecastUsage       `json:"result,omitempty"`
+}
+
+func NewForecastRun(record *models.ForecastResult) (*ForecastRun, error) {
+	run := &ForecastRun{
+		ForecastResult: record,
+	}
+	if record.Parameters != "" {
+		if err := json.Unmarshal([]byte(record.Parameters), &run.Parameters); err != nil {
+			return nil, err
+		}
+	}
+	if record.Result != nil {
+		if err := json.Unmarshal([]byte(*record.Result), &run.Result); err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// UsageMap returns the forecast usages keyed by name, or nil if the forecast
+// has not finished.
+func (run *ForecastRun) UsageMap() map[string]*ForecastUsage {
+	if run.Result == nil {
+		return nil
+	}
+	usages := make(map[string]*ForecastUsage, len(run.Result))
+	for _, usage := range run.Result {
+		usages[usage.Name] = usage
+	}
+	return usages
 }
 
 func EncodeForecastUsage(usage []*ForecastUsage) (string, error) {
@@ -57,13 +119,24 @@ func DecodeForecastUsage(data string) ([]*ForecastUsage, error) {
 
 type ResourceUsageInfo struct {
 	lock           sync.RWMutex
-	ResourceName   string         `json:"name"`
-	Usage          TimeseriesData `json:"usage,omitempty" description:"resource usage"`
-	Request        float64        `json:"request,omitempty"`
-	Limit          float64        `json:"limit,omitempty"`
-	CurrentUsage   float64        `json:"current_usage" description:"current usage"`
-	OptimizedUsage float64        `json:"optimized_usage,omitempty"`
-	Status         *string        `json:"status,omitempty" description:"resource status"`
+	ResourceName   string          `json:"name"`
+	Unit           string          `json:"unit,omitempty"`
+	Usage          TimeseriesData  `json:"usage,omitempty" description:"resource usage"`
+	Request        float64         `json:"request,omitempty"`
+	Limit          float64         `json:"limit,omitempty"`
+	CurrentUsage   float64         `json:"current_usage" description:"current usage"`
+	OptimizedUsage float64         `json:"optimized_usage,omitempty"`
+	Recommendation *Recommendation `json:"recommendation,omitempty" description:"recommended request and limit"`
+	Histogram      *Estimation     `json:"histogram,omitempty" description:"decaying histogram estimation"`
+	Cost           *Cost           `json:"cost,omitempty" description:"monthly cost of current and recommended request"`
+	Status         *string         `json:"status,omitempty" description:"resource status"`
+}
+
+// Estimation is the result of the decaying histogram recommender.
+type Estimation struct {
+	Target     float64 `json:"target"`
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
 }
 
 func NewResourceUsage(name string, data []models.TimeSeriesDatapoint) *ResourceUsageInfo {
@@ -0,0 +1,37 @@
+package resource
+
+// Rollup is the usage of the containers of an object, e.g. a pod or a
+// workload, by resource and the total usage of the object by resource.
+type Rollup struct {
+	Total      map[string]*ResourceUsageInfo
+	Containers []map[string]*ResourceUsageInfo
+}
+
+// Recommend fills the request/limit recommendation of every container and
+// the total. It should be called after the containers are rightsized.
+func (r Rollup) Recommend() {
+	recommendations := make(map[string][]*Recommendation)
+	for _, usages := range r.Containers {
+		for name, usage := range usages {
+			recommendations[name] = append(recommendations[name], usage.Recommend())
+		}
+	}
+	for name, usage := range r.Total {
+		usage.Recommendation = SumRecommendations(usage.Unit, recommendations[name]...)
+	}
+}
+
+// Summarize rolls up the requested, used and recommended resources of the
+// containers per resource.
+func (r Rollup) Summarize() map[string]*ResourceSummary {
+	summaries := make(map[string]*ResourceSummary)
+	for _, usages := range r.Containers {
+		for name, usage := range usages {
+			if _, exist := summaries[name]; !exist {
+				summaries[name] = &ResourceSummary{}
+			}
+			summaries[name].Add(usage)
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,52 @@
+package rightsizing
+
+import (
+	"context"
+	"fmt"
+
+	"rightsizing-api-server/internal/api/common/resource"
+	grpcclient "rightsizing-api-server/internal/grpc"
+)
+
+type grpcRecommender struct {
+	client *grpcclient.Client
+}
+
+// NewGrpcRecommender delegates the recommendation to the analysis server.
+func NewGrpcRecommender(client *grpcclient.Client) Recommender {
+	return &grpcRecommender{
+		client: client,
+	}
+}
+
+func (r *grpcRecommender) Name() string {
+	return GrpcRecommender
+}
+
+func (r *grpcRecommender) Recommend(ctx context.Context, data resource.TimeseriesData) (float64, error) {
+	resp, err := r.client.Rightsizing(ctx, data)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Result, nil
+}
+
+func (r *grpcRecommender) RecommendBatch(ctx context.Context, series map[string]resource.TimeseriesData) (map[string]float64, error) {
+	responses, err := r.client.BatchRightsizing(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64, len(series))
+	for id := range series {
+		resp, exist := responses[id]
+		if !exist {
+			return nil, fmt.Errorf("no recommendation of %s", id)
+		}
+		if resp.Message != "" {
+			return nil, fmt.Errorf("failed to recommend %s: %s", id, resp.Message)
+		}
+		values[id] = resp.Result
+	}
+	return values, nil
+}
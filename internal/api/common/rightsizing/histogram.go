@@ -0,0 +1,145 @@
+package rightsizing
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"rightsizing-api-server/internal/api/common/resource"
+)
+
+const (
+	// bucket 크기는 이전 bucket 보다 5%씩 커짐
+	bucketRatio = 1.05
+	// 첫 bucket 크기는 관측된 최대값에 대한 비율로 정함
+	firstBucketRatio = 1e-3
+)
+
+// HistogramOptions configures the decaying histogram recommender.
+// Percentiles are between 0 and 1.
+type HistogramOptions struct {
+	HalfLife             time.Duration
+	TargetPercentile     float64
+	LowerBoundPercentile float64
+	UpperBoundPercentile float64
+	SafetyMargin         float64
+}
+
+func DefaultHistogramOptions() HistogramOptions {
+	return HistogramOptions{
+		HalfLife:             24 * time.Hour,
+		TargetPercentile:     0.9,
+		LowerBoundPercentile: 0.5,
+		UpperBoundPercentile: 0.95,
+		SafetyMargin:         0.15,
+	}
+}
+
+// Estimator estimates the target usage with its lower and upper bounds.
+type Estimator interface {
+	Estimate(data resource.TimeseriesData) *resource.Estimation
+}
+
+type histogramRecommender struct {
+	options HistogramOptions
+}
+
+// NewHistogramRecommender builds an exponentially decaying histogram of the
+// usage history like the VPA recommender does, so that recent samples weigh
+// more than old ones.
+func NewHistogramRecommender(options HistogramOptions) Recommender {
+	return &histogramRecommender{
+		options: options,
+	}
+}
+
+func (r *histogramRecommender) Name() string {
+	return HistogramRecommender
+}
+
+func (r *histogramRecommender) Recommend(_ context.Context, data resource.TimeseriesData) (float64, error) {
+	h := NewDecayingHistogram(data, r.options.HalfLife)
+	return h.Percentile(r.options.TargetPercentile) * (1 + r.options.SafetyMargin), nil
+}
+
+func (r *histogramRecommender) Estimate(data resource.TimeseriesData) *resource.Estimation {
+	var (
+		h      = NewDecayingHistogram(data, r.options.HalfLife)
+		margin = 1 + r.options.SafetyMargin
+	)
+	return &resource.Estimation{
+		Target:     h.Percentile(r.options.TargetPercentile) * margin,
+		LowerBound: h.Percentile(r.options.LowerBoundPercentile) * margin,
+		UpperBound: h.Percentile(r.options.UpperBoundPercentile) * margin,
+	}
+}
+
+// DecayingHistogram is a histogram with exponentially growing buckets whose
+// sample weights halve every half-life, counted back from the latest sample.
+type DecayingHistogram struct {
+	firstBucketSize float64
+	weights         []float64
+	total           float64
+}
+
+func NewDecayingHistogram(data resource.TimeseriesData, halfLife time.Duration) *DecayingHistogram {
+	h := &DecayingHistogram{}
+	if len(data) == 0 {
+		return h
+	}
+
+	var reference int64
+	for _, point := range data {
+		if point.Time > reference {
+			reference = point.Time
+		}
+	}
+	h.firstBucketSize = data.Max() * firstBucketRatio
+	if h.firstBucketSize <= 0 {
+		return h
+	}
+
+	for _, point := range data {
+		if point.Value < 0 || math.IsNaN(point.Value) {
+			continue
+		}
+		age := float64(reference - point.Time)
+		weight := math.Exp2(-age / halfLife.Seconds())
+		h.add(point.Value, weight)
+	}
+	return h
+}
+
+func (h *DecayingHistogram) bucket(value float64) int {
+	return int(math.Floor(math.Log(1+value*(bucketRatio-1)/h.firstBucketSize) / math.Log(bucketRatio)))
+}
+
+func (h *DecayingHistogram) bucketStart(idx int) float64 {
+	return h.firstBucketSize * (math.Pow(bucketRatio, float64(idx)) - 1) / (bucketRatio - 1)
+}
+
+func (h *DecayingHistogram) add(value, weight float64) {
+	idx := h.bucket(value)
+	for len(h.weights) <= idx {
+		h.weights = append(h.weights, 0)
+	}
+	h.weights[idx] += weight
+	h.total += weight
+}
+
+// Percentile returns the end of the bucket where the cumulative weight reaches
+// p (0~1) of the total weight.
+func (h *DecayingHistogram) Percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	threshold := p * h.total
+	var sum float64
+	for idx, weight := range h.weights {
+		sum += weight
+		if sum >= threshold {
+			return h.bucketStart(idx + 1)
+		}
+	}
+	return h.bucketStart(len(h.weights))
+}
@@ -0,0 +1,78 @@
+package rightsizing
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"rightsizing-api-server/internal/api/common/resource"
+)
+
+const (
+	defaultPercentile = 95
+	defaultMargin     = 0.2
+)
+
+type percentileRecommender struct {
+	percentile float64
+	margin     float64
+}
+
+// NewPercentileRecommender works the same as analyze.percentile of the
+// analysis server: the given percentile of the history plus a margin.
+func NewPercentileRecommender() Recommender {
+	return &percentileRecommender{
+		percentile: defaultPercentile,
+		margin:     defaultMargin,
+	}
+}
+
+func (r *percentileRecommender) Name() string {
+	return PercentileRecommender
+}
+
+func (r *percentileRecommender) Recommend(_ context.Context, data resource.TimeseriesData) (float64, error) {
+	values := make([]float64, len(data))
+	for i, point := range data {
+		values[i] = point.Value
+	}
+	return Percentile(values, r.percentile) * (1 + r.margin), nil
+}
+
+// Percentile returns the q-th percentile (0~100) of values using linear
+// interpolation between the closest ranks, like numpy.percentile.
+func Percentile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := q / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+type maxRecommender struct {
+	margin float64
+}
+
+// NewMaxRecommender recommends the peak of the history plus a margin.
+func NewMaxRecommender() Recommender {
+	return &maxRecommender{
+		margin: defaultMargin,
+	}
+}
+
+func (r *maxRecommender) Name() string {
+	return MaxRecommender
+}
+
+func (r *maxRecommender) Recommend(_ context.Context, data resource.TimeseriesData) (float64, error) {
+	return data.Max() * (1 + r.margin), nil
+}
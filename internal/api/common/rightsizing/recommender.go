@@ -0,0 +1,161 @@
+package rightsizing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/resource"
+	grpcclient "rightsizing-api-server/internal/grpc"
+)
+
+const (
+	GrpcRecommender       = "grpc"
+	PercentileRecommender = "percentile"
+	MaxRecommender        = "max"
+	HistogramRecommender  = "histogram"
+)
+
+// RecommenderNames lists the built-in recommendation strategies.
+var RecommenderNames = []string{
+	GrpcRecommender,
+	PercentileRecommender,
+	MaxRecommender,
+	HistogramRecommender,
+}
+
+// Recommender calculates the optimized usage of a resource from its usage history.
+type Recommender interface {
+	Name() string
+	Recommend(ctx context.Context, data resource.TimeseriesData) (float64, error)
+}
+
+// BatchRecommender recommends many series with one call, e.g. to save the
+// round trips to the analysis server. The values are keyed like the series.
+type BatchRecommender interface {
+	Recommender
+	RecommendBatch(ctx context.Context, series map[string]resource.TimeseriesData) (map[string]float64, error)
+}
+
+// Registry holds the available recommenders and the one used when a request
+// does not choose a strategy.
+type Registry struct {
+	recommenders map[string]Recommender
+	defaultName  string
+}
+
+func NewRegistry(defaultName string, recommenders ...Recommender) (*Registry, error) {
+	r := &Registry{
+		recommenders: make(map[string]Recommender, len(recommenders)),
+		defaultName:  defaultName,
+	}
+	for _, recommender := range recommenders {
+		r.recommenders[recommender.Name()] = recommender
+	}
+	if _, exist := r.recommenders[defaultName]; !exist {
+		return nil, fmt.Errorf("default recommender %s is not registered", defaultName)
+	}
+	return r, nil
+}
+
+// Names returns the names of the registered recommenders in order.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.recommenders))
+	for name := range r.recommenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the recommender registered as name, or the default one if name is empty.
+func (r *Registry) Get(name string) (Recommender, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	recommender, exist := r.recommenders[name]
+	if !exist {
+		return nil, commonerrors.NotFoundErr("recommender", name)
+	}
+	return recommender, nil
+}
+
+// Estimate returns the histogram estimation of data if the histogram
+// recommender is registered. It is calculated regardless of the chosen
+// recommender so that both results can be compared.
+func (r *Registry) Estimate(data resource.TimeseriesData) *resource.Estimation {
+	estimator, ok := r.recommenders[HistogramRecommender].(Estimator)
+	if !ok {
+		return nil
+	}
+	return estimator.Estimate(data)
+}
+
+type fallbackRecommender struct {
+	primary  Recommender
+	fallback Recommender
+	logger   *zap.Logger
+}
+
+// WithFallback returns a recommender that uses fallback whenever primary fails,
+// e.g. when the analysis server is not reachable. It keeps the name of primary.
+// While the circuit breaker of the analysis server is open, the failure is
+// returned instead so that RightsizingAll leaves the usages unknown.
+func WithFallback(primary, fallback Recommender, logger *zap.Logger) Recommender {
+	return &fallbackRecommender{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (r *fallbackRecommender) Name() string {
+	return r.primary.Name()
+}
+
+func (r *fallbackRecommender) Recommend(ctx context.Context, data resource.TimeseriesData) (float64, error) {
+	value, err := r.primary.Recommend(ctx, data)
+	if err == nil || errors.Is(err, grpcclient.ErrCircuitOpen) {
+		return value, err
+	}
+	r.logger.Warn("recommender failed, use fallback",
+		zap.String("recommender", r.primary.Name()),
+		zap.String("fallback", r.fallback.Name()),
+		zap.Error(err))
+	return r.fallback.Recommend(ctx, data)
+}
+
+// RecommendBatch uses the batch of primary if it has one, and recommends the
+// whole batch with fallback if primary fails.
+func (r *fallbackRecommender) RecommendBatch(ctx context.Context, series map[string]resource.TimeseriesData) (map[string]float64, error) {
+	values, err := recommendBatch(ctx, r.primary, series)
+	if err == nil || errors.Is(err, grpcclient.ErrCircuitOpen) {
+		return values, err
+	}
+	r.logger.Warn("recommender failed, use fallback",
+		zap.String("recommender", r.primary.Name()),
+		zap.String("fallback", r.fallback.Name()),
+		zap.Error(err))
+	return recommendBatch(ctx, r.fallback, series)
+}
+
+// recommendBatch recommends the series with one call of a batch recommender,
+// or one by one with any other.
+func recommendBatch(ctx context.Context, recommender Recommender, series map[string]resource.TimeseriesData) (map[string]float64, error) {
+	if batcher, ok := recommender.(BatchRecommender); ok {
+		return batcher.RecommendBatch(ctx, series)
+	}
+	values := make(map[string]float64, len(series))
+	for id, data := range series {
+		value, err := recommender.Recommend(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		values[id] = value
+	}
+	return values, nil
+}
@@ -2,16 +2,139 @@ package rightsizing
 
 import (
 	"context"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
 
 	"rightsizing-api-server/internal/api/common/resource"
 	grpcclient "rightsizing-api-server/internal/grpc"
 )
 
-func Rightsizing(ctx context.Context, client *grpcclient.Client, info *resource.ResourceUsageInfo) error {
-	resp, err := client.Rightsizing(ctx, info.Usage)
+const (
+	// usages of at most this many datapoints are too short to be rightsized
+	SampleThreshold = 100
+	// series recommended with one call of a batch recommender
+	batchSize = 50
+	// calls of the recommender running at the same time
+	concurrency = 8
+)
+
+func Rightsizing(ctx context.Context, recommender Recommender, info *resource.ResourceUsageInfo) error {
+	result, err := recommender.Recommend(ctx, info.Usage)
 	if err != nil {
 		return err
 	}
-	info.OptimizedUsage = resp.Result
+	info.OptimizedUsage = result
 	return nil
 }
+
+// RightsizingAll sets the optimized usage of every info. A batch recommender
+// is called with batches of the infos, any other recommender with every info,
+// with at most a few calls running at the same time. The first error cancels
+// the calls left. The infos which the analysis server is unavailable for are
+// left without optimized usage with the unknown status instead of failing.
+func RightsizingAll(ctx context.Context, recommender Recommender, infos []*resource.ResourceUsageInfo) error {
+	size := 1
+	if _, ok := recommender.(BatchRecommender); ok {
+		size = batchSize
+	}
+
+	var (
+		g, gctx = errgroup.WithContext(ctx)
+		sem     = make(chan struct{}, concurrency)
+	)
+	for start := 0; start < len(infos); start += size {
+		end := start + size
+		if end > len(infos) {
+			end = len(infos)
+		}
+		batch := infos[start:end]
+
+		select {
+		case sem <- struct{}{}:
+		case <-gctx.Done():
+			if err := g.Wait(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+		g.Go(func() error {
+			defer func() { <-sem }()
+			var err error
+			if len(batch) == 1 {
+				err = Rightsizing(gctx, recommender, batch[0])
+			} else {
+				err = rightsizingBatch(gctx, recommender, batch)
+			}
+			if err != nil && ctx.Err() == nil && grpcclient.IsUnavailable(err) {
+				unknown(batch)
+				return nil
+			}
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// RightsizingRollups recommends the usages of the containers of all the
+// rollups together, so that a batch recommender needs few calls for many
+// objects, and adds the optimized usages to the totals. The usages of
+// SampleThreshold datapoints or less are left out.
+func RightsizingRollups(ctx context.Context, recommender Recommender, estimator Estimator, rollups ...resource.Rollup) error {
+	var usages []*resource.ResourceUsageInfo
+	for _, rollup := range rollups {
+		for _, container := range rollup.Containers {
+			for _, usage := range container {
+				if len(usage.Usage) > SampleThreshold {
+					usages = append(usages, usage)
+				}
+			}
+		}
+	}
+	if err := RightsizingAll(ctx, recommender, usages); err != nil {
+		return err
+	}
+
+	for _, rollup := range rollups {
+		for _, container := range rollup.Containers {
+			for name, usage := range container {
+				if len(usage.Usage) > SampleThreshold {
+					Estimate(estimator, usage)
+					rollup.Total[name].OptimizedUsage += usage.OptimizedUsage
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func rightsizingBatch(ctx context.Context, recommender Recommender, infos []*resource.ResourceUsageInfo) error {
+	series := make(map[string]resource.TimeseriesData, len(infos))
+	for i, info := range infos {
+		series[strconv.Itoa(i)] = info.Usage
+	}
+	values, err := recommendBatch(ctx, recommender, series)
+	if err != nil {
+		return err
+	}
+	for i, info := range infos {
+		info.OptimizedUsage = values[strconv.Itoa(i)]
+	}
+	return nil
+}
+
+func unknown(infos []*resource.ResourceUsageInfo) {
+	for _, info := range infos {
+		status := resource.StatusUnknown
+		info.OptimizedUsage = 0
+		info.Status = &status
+	}
+}
+
+// Estimate sets the histogram estimation of info, if estimator is given.
+func Estimate(estimator Estimator, info *resource.ResourceUsageInfo) {
+	if estimator == nil {
+		return
+	}
+	info.Histogram = estimator.Estimate(info.Usage)
+}
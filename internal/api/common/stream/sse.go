@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/worker"
+)
+
+const (
+	// Timeout bounds a stream so that abandoned connections are released.
+	Timeout = 30 * time.Minute
+	// HeartbeatInterval is the interval of the comments which keep idle
+	// streams open through proxies and detect clients which have gone away.
+	HeartbeatInterval = 15 * time.Second
+)
+
+// IsStream reports whether the request is for a stream. Middlewares which
+// buffer the response body (compress, etag) must skip streams.
+func IsStream(c *fiber.Ctx) bool {
+	return strings.HasSuffix(c.Path(), "/events")
+}
+
+// WatchFunc sends the events of a task until it finishes.
+type WatchFunc func(ctx context.Context, send func(*worker.Event) error) error
+
+// SSE streams the events of watch as server-sent events. The event name is
+// the event type and the data is the json encoded event. An error of watch is
+// sent as an error event before the stream is closed. A ping comment is sent
+// every HeartbeatInterval, and the stream stops on the first failed write.
+func SSE(c *fiber.Ctx, watch WatchFunc, logger *zap.Logger) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+
+		var (
+			mu       sync.Mutex
+			writeErr error
+		)
+		// write is shared by the events and the heartbeat. It fails once the
+		// client has gone away, which stops the watch.
+		write := func(format string, args ...interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if writeErr != nil {
+				return writeErr
+			}
+			if _, writeErr = fmt.Fprintf(w, format, args...); writeErr == nil {
+				writeErr = w.Flush()
+			}
+			if writeErr != nil {
+				cancel()
+			}
+			return writeErr
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(HeartbeatInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := write(": ping\n\n"); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		send := func(event *worker.Event) error {
+			buf, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			return write("event: %s\ndata: %s\n\n", event.Type, buf)
+		}
+
+		if err := watch(ctx, send); err != nil {
+			logger.Debug("stream closed", zap.Error(err))
+			send(&worker.Event{
+				Type:  worker.EventError,
+				Error: err.Error(),
+				Time:  time.Now(),
+			})
+		}
+		// the writer must not be used once the stream writer returns
+		cancel()
+		wg.Wait()
+	})
+	return nil
+}
@@ -0,0 +1,140 @@
+package table
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// percentile of the continuous aggregates
+const defaultPercentile = 90
+
+var (
+	// label columns are used in queries as they are
+	labelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// tables are schema qualified, and metric names may hold colons
+	tablePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_.:]*$`)
+)
+
+// Metric is a usage metric of the catalogue.
+type Metric struct {
+	// resource name of the usage in the responses, e.g. cpu. The requests and
+	// limits of the resource of the same name are the quota of the usage.
+	Name string `yaml:"name" json:"name"`
+	// unit of the values, cores and bytes are formatted as kubernetes quantities
+	Unit string `yaml:"unit" json:"unit"`
+	// Promscale series table of the metric, e.g. prom_series.container_memory_working_set_bytes
+	IDTable string `yaml:"id_table" json:"id_table"`
+	// continuous aggregate of the samples of the metric
+	AggregateTable string `yaml:"aggregate_table" json:"aggregate_table"`
+	// label columns of the series by their role, e.g. pod: kubernetes_pod_name.
+	// The roles left out are the same as their column.
+	Labels map[string]string `yaml:"labels" json:"labels"`
+	// percentile (0~100) of the samples aggregated into a bucket, 90 if 0
+	Percentile float64 `yaml:"percentile" json:"percentile"`
+	// PromQL of the samples for the Prometheus datasource, %s is the label
+	// filter appended to the matchers. The series of the metric if empty.
+	Query string `yaml:"query" json:"query"`
+	// name of the Prometheus metric for the OpenSearch datasource, the id
+	// table without schema if empty
+	Series string `yaml:"series" json:"series"`
+}
+
+func (m Metric) percentile() float64 {
+	if m.Percentile == 0 {
+		return defaultPercentile
+	}
+	return m.Percentile
+}
+
+func (m Metric) seriesName() string {
+	if m.Series != "" {
+		return m.Series
+	}
+	return m.IDTable[strings.LastIndex(m.IDTable, ".")+1:]
+}
+
+// Catalogue is loaded from the metric catalogue file. A kind of object left
+// out keeps its built-in metrics.
+type Catalogue struct {
+	Pods []Metric `yaml:"pods" json:"pods"`
+	VMs  []Metric `yaml:"vms" json:"vms"`
+}
+
+// LoadCatalogue reads the metric catalogue file, JSON if its extension is
+// .json and YAML otherwise. An empty catalogue is returned if path is empty.
+func LoadCatalogue(path string) (*Catalogue, error) {
+	catalogue := &Catalogue{}
+	if path == "" {
+		return catalogue, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(buf, catalogue)
+	} else {
+		err = yaml.Unmarshal(buf, catalogue)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := catalogue.Validate(); err != nil {
+		return nil, err
+	}
+	return catalogue, nil
+}
+
+func (c *Catalogue) Validate() error {
+	if err := validateMetrics(c.Pods); err != nil {
+		return fmt.Errorf("pod metrics: %w", err)
+	}
+	if err := validateMetrics(c.VMs); err != nil {
+		return fmt.Errorf("vm metrics: %w", err)
+	}
+	return nil
+}
+
+func validateMetrics(metrics []Metric) error {
+	names := make(map[string]bool, len(metrics))
+	for _, metric := range metrics {
+		if metric.Name == "" {
+			return errors.New("metric name must be present")
+		}
+		// names are matched with the resource of the quota in queries
+		if !labelPattern.MatchString(metric.Name) {
+			return fmt.Errorf("invalid metric name %q", metric.Name)
+		}
+		if names[metric.Name] {
+			return fmt.Errorf("duplicate metric %s", metric.Name)
+		}
+		names[metric.Name] = true
+
+		if !tablePattern.MatchString(metric.IDTable) || !tablePattern.MatchString(metric.AggregateTable) {
+			return fmt.Errorf("invalid id table %q or aggregate table %q of %s", metric.IDTable, metric.AggregateTable, metric.Name)
+		}
+		for role, column := range metric.Labels {
+			if !labelPattern.MatchString(column) {
+				return fmt.Errorf("invalid %s label column %q of %s", role, column, metric.Name)
+			}
+		}
+		if metric.Percentile < 0 || metric.Percentile > 100 {
+			return fmt.Errorf("percentile of %s must be between 0 and 100", metric.Name)
+		}
+		if metric.Query != "" && strings.Count(metric.Query, "%s") != 1 {
+			return fmt.Errorf("query of %s must have the label filter %%s once", metric.Name)
+		}
+		if metric.Series != "" && !labelPattern.MatchString(strings.ReplaceAll(metric.Series, ":", "_")) {
+			return fmt.Errorf("invalid series %q of %s", metric.Series, metric.Name)
+		}
+	}
+	return nil
+}
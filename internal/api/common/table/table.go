@@ -6,15 +6,32 @@ type Table struct {
 	MetricName      []string
 	IDTableName     []string
 	MetricTableName []string
+	// unit of the values, e.g. cores or bytes
+	Unit []string
+	// label columns of the series by their role, the role itself if left out
+	Labels []map[string]string
+	// percentile (0~100) of the samples aggregated into a bucket
+	Percentile []float64
+	// PromQL of the samples, %s is the label filter
+	Query []string
+	// name of the Prometheus metric
+	SeriesName []string
 }
 
-func SetupTable(metricNames, idTables, metricTables []string) Table {
-	return Table{
-		MetricName:      metricNames,
-		IDTableName:     idTables,
-		MetricTableName: metricTables,
+// NewTable builds the table of the metrics of the catalogue.
+func NewTable(metrics []Metric) Table {
+	t := Table{}
+	for _, metric := range metrics {
+		t.MetricName = append(t.MetricName, metric.Name)
+		t.IDTableName = append(t.IDTableName, metric.IDTable)
+		t.MetricTableName = append(t.MetricTableName, metric.AggregateTable)
+		t.Unit = append(t.Unit, metric.Unit)
+		t.Labels = append(t.Labels, metric.Labels)
+		t.Percentile = append(t.Percentile, metric.percentile())
+		t.Query = append(t.Query, metric.Query)
+		t.SeriesName = append(t.SeriesName, metric.seriesName())
 	}
-
+	return t
 }
 
 func (t Table) GetIDTableName(idx int) string {
@@ -55,6 +72,46 @@ func (t Table) GetMetricNames() []string {
 	return t.MetricName
 }
 
+func (t Table) GetUnit(idx int) string {
+	if idx >= len(t.Unit) {
+		return ""
+	}
+	return t.Unit[idx]
+}
+
+// GetLabel returns the label column of the role, e.g. the column holding the
+// pod name.
+func (t Table) GetLabel(idx int, role string) string {
+	if idx >= len(t.Labels) {
+		return role
+	}
+	if column, exist := t.Labels[idx][role]; exist {
+		return column
+	}
+	return role
+}
+
+func (t Table) GetPercentile(idx int) float64 {
+	if idx >= len(t.Percentile) {
+		return defaultPercentile
+	}
+	return t.Percentile[idx]
+}
+
+func (t Table) GetQuery(idx int) string {
+	if idx >= len(t.Query) {
+		return ""
+	}
+	return t.Query[idx]
+}
+
+func (t Table) GetSeriesName(idx int) string {
+	if idx >= len(t.SeriesName) {
+		return ""
+	}
+	return t.SeriesName[idx]
+}
+
 func (t Table) Len() int {
 	return len(t.MetricTableName)
 }
@@ -0,0 +1,49 @@
+package namespace
+
+import (
+	"rightsizing-api-server/internal/api/common/query"
+	"rightsizing-api-server/internal/api/common/resource"
+)
+
+type NamespaceService interface {
+	GetAllNamespace(query query.Query) ([]*Namespace, error)
+	GetNamespaceSummary(query query.Query, top int) (*Namespace, error)
+}
+
+type Namespace struct {
+	Name string `json:"name"`
+	Pods int    `json:"pods"`
+	// requested, used and recommended resources per resource
+	Resources map[string]*resource.ResourceSummary `json:"resources"`
+	// the most over-provisioned workloads (summary only)
+	TopOverProvisioned []*WorkloadSlack `json:"top_over_provisioned,omitempty"`
+}
+
+// WorkloadSlack is the reclaimable amount of a workload. Score is the sum of
+// the shares of the namespace reclaimable resources, so that cpu and memory
+// can be ranked together.
+type WorkloadSlack struct {
+	Kind        string             `json:"kind"`
+	Name        string             `json:"name"`
+	Reclaimable map[string]float64 `json:"reclaimable"`
+	Score       float64            `json:"score"`
+}
+
+func NewNamespace(name string) *Namespace {
+	return &Namespace{
+		Name: name,
+		Resources: map[string]*resource.ResourceSummary{
+			"cpu":    {},
+			"memory": {},
+		},
+	}
+}
+
+func (n *Namespace) add(summaries map[string]*resource.ResourceSummary) {
+	for name, summary := range summaries {
+		if _, exist := n.Resources[name]; !exist {
+			n.Resources[name] = &resource.ResourceSummary{}
+		}
+		n.Resources[name].Merge(summary)
+	}
+}
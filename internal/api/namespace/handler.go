@@ -0,0 +1,87 @@
+package namespace
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/api/common/query"
+)
+
+type NamespaceHandler struct {
+	ns     NamespaceService
+	logger *zap.Logger
+}
+
+func NamespaceRouter(route fiber.Router, ns NamespaceService, logger *zap.Logger) {
+	handler := &NamespaceHandler{
+		ns:     ns,
+		logger: logger,
+	}
+
+	route.Get("/namespaces", handler.getAllNamespace)
+	route.Get("/namespaces/:namespace/summary", handler.getNamespaceSummary)
+}
+
+// @Summary namespace별 리소스 요청량, 사용량, 추천값 및 회수 가능한 리소스 총합 제공
+// @Accept  json
+// @Produce json
+// @Param start       query string false "start time"
+// @Param end         query string false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Success 200 {object} Namespace list
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/namespaces [get]
+func (h *NamespaceHandler) getAllNamespace(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	namespaces, err := h.ns.GetAllNamespace(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(namespaces)
+}
+
+// @Summary 특정 namespace의 리소스 총합과 가장 과하게 할당된 workload 목록 제공
+// @Accept  json
+// @Produce json
+// @Param namespace   path  string true  "the namespace"
+// @Param top         query int    false "the number of over-provisioned workloads (default 10)"
+// @Param start       query string false "start time"
+// @Param end         query string false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Success 200 {object} Namespace
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/namespaces/{namespace}/summary [get]
+func (h *NamespaceHandler) getNamespaceSummary(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+	query.Namespace = c.Params("namespace")
+
+	top := c.Query("top")
+	n := 0
+	if top != "" {
+		if n, err = strconv.Atoi(top); err != nil {
+			h.logger.Debug("query parser error", zap.Error(err))
+			return c.Status(fiber.StatusBadRequest).JSON(err)
+		}
+	}
+
+	namespace, err := h.ns.GetNamespaceSummary(query, n)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(namespace)
+}
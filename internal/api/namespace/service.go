@@ -0,0 +1,137 @@
+package namespace
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/query"
+	"rightsizing-api-server/internal/api/pod"
+	"rightsizing-api-server/internal/api/workload"
+)
+
+const defaultTop = 10
+
+type namespaceService struct {
+	podService      pod.PodService
+	workloadService workload.WorkloadService
+	logger          *zap.Logger
+}
+
+var _ NamespaceService = (*namespaceService)(nil)
+
+func NewNamespaceService(
+	podService pod.PodService,
+	workloadService workload.WorkloadService,
+	logger *zap.Logger) NamespaceService {
+	return &namespaceService{
+		podService:      podService,
+		workloadService: workloadService,
+		logger:          logger,
+	}
+}
+
+func (s *namespaceService) GetAllNamespace(query query.Query) ([]*Namespace, error) {
+	s.logger.Debug("namespace rollup",
+		zap.String("id", query.ID),
+		zap.Time("start_time", query.StartTime),
+		zap.Time("end_time", query.EndTime))
+
+	query.Namespace = ""
+	pods, err := s.podService.GetAllPod(query)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceMap := make(map[string]*Namespace)
+	for _, pod := range pods {
+		if _, exist := namespaceMap[pod.Namespace]; !exist {
+			namespaceMap[pod.Namespace] = NewNamespace(pod.Namespace)
+		}
+		namespaceMap[pod.Namespace].Pods += 1
+		namespaceMap[pod.Namespace].add(pod.Summarize())
+	}
+
+	namespaces := make([]*Namespace, 0, len(namespaceMap))
+	for _, namespace := range namespaceMap {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+	return namespaces, nil
+}
+
+func (s *namespaceService) GetNamespaceSummary(query query.Query, top int) (*Namespace, error) {
+	s.logger.Debug("namespace summary",
+		zap.String("id", query.ID),
+		zap.String("namespace", query.Namespace),
+		zap.Int("top", top),
+		zap.Time("start_time", query.StartTime),
+		zap.Time("end_time", query.EndTime))
+
+	if query.Namespace == "" {
+		return nil, commonerrors.NotFoundErr("namespace", "")
+	}
+	if top <= 0 {
+		top = defaultTop
+	}
+
+	podQuery := query
+	podQuery.Name = ""
+	pods, err := s.podService.GetAllPod(podQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := NewNamespace(query.Namespace)
+	for _, pod := range pods {
+		namespace.Pods += 1
+		namespace.add(pod.Summarize())
+	}
+
+	workloads, err := s.workloadService.GetAllWorkload(query)
+	if err != nil {
+		return nil, err
+	}
+	namespace.TopOverProvisioned = topOverProvisioned(workloads, top)
+
+	return namespace, nil
+}
+
+func topOverProvisioned(workloads []*workload.Workload, top int) []*WorkloadSlack {
+	totals := make(map[string]float64)
+	slacks := make([]*WorkloadSlack, 0, len(workloads))
+	for _, w := range workloads {
+		slack := &WorkloadSlack{
+			Kind:        w.Kind,
+			Name:        w.Name,
+			Reclaimable: make(map[string]float64),
+		}
+		for name, summary := range w.Summarize() {
+			slack.Reclaimable[name] = summary.Reclaimable
+			totals[name] += summary.Reclaimable
+		}
+		slacks = append(slacks, slack)
+	}
+
+	for _, slack := range slacks {
+		for name, reclaimable := range slack.Reclaimable {
+			if totals[name] > 0 {
+				slack.Score += reclaimable / totals[name]
+			}
+		}
+	}
+
+	sort.SliceStable(slacks, func(i, j int) bool {
+		return slacks[i].Score > slacks[j].Score
+	})
+	for len(slacks) > 0 && slacks[len(slacks)-1].Score == 0 {
+		slacks = slacks[:len(slacks)-1]
+	}
+	if len(slacks) > top {
+		slacks = slacks[:top]
+	}
+	return slacks
+}
@@ -2,18 +2,34 @@ package pod
 
 import (
 	"context"
+	"time"
 
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
-	grpcclient "rightsizing-api-server/internal/grpc"
+	"rightsizing-api-server/internal/api/common/rightsizing"
 	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/pricing"
+	"rightsizing-api-server/internal/worker"
 )
 
 type PodRepository interface {
 	GetAllPodQuota(query query.Query) ([]*Pod, error)
 	GetAllPod(query query.Query) ([]*Pod, error)
 	GetPod(query query.Query) (*Pod, error)
-	Query(ctx context.Context, naemspace, name, startTime, endTime string) ([]*Container, error)
+	Query(ctx context.Context, naemspace, name, startTime, endTime string, step time.Duration) ([]*Container, error)
+	GetNodePools(ctx context.Context, namespace, label, startTime, endTime string) (map[string]string, error)
+	ListPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error)
+}
+
+// Datasource reads the container metrics from a metrics backend. The usage
+// is returned per metric in the order of ContainerMetricTables, every step or
+// the finest resolution of the backend if step is shorter. The pod name is
+// only a filter if the namespace is given as well.
+type Datasource interface {
+	QueryUsage(ctx context.Context, namespace, name, startTime, endTime string, step time.Duration) ([][]models.Container, error)
+	QueryQuota(ctx context.Context, namespace, name string) (requests, limits []models.ContainerQuota, err error)
+	QueryNodePools(ctx context.Context, namespace, label, startTime, endTime string) ([]models.PodNodePool, error)
+	QueryPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error)
 }
 
 type PodService interface {
@@ -22,34 +38,196 @@ type PodService interface {
 	GetPod(query query.Query) (*Pod, error)
 	GetForecastStatusByID(uuid string) (string, error)
 	GetForecastResultByID(uuid string) (map[string]*resource.ForecastUsage, error)
-	GetForecastStatus(namespace, name string) (string, error)
-	GetForecastResult(namespace, name string) (map[string]*resource.ForecastUsage, error)
+	GetForecastStatus(namespace, name string, options resource.ForecastOptions) (string, error)
+	GetForecastResult(namespace, name string, options resource.ForecastOptions) (map[string]*resource.ForecastUsage, error)
+	GetForecastHistory(namespace, name string, limit int) ([]*resource.ForecastRun, error)
+	GetRecommendationHistory(query query.Query) (*RecommendationHistory, error)
+	GetLatestRecommendations(query query.Query) ([]*LatestRecommendation, error)
 	Forecast(query query.Query) (string, error)
+	ForecastBatch(query query.Query, request *BatchForecastRequest) (string, error)
+	GetForecastBatch(batchID string) (*ForecastBatch, error)
+	WatchForecast(ctx context.Context, uuid string, send func(*worker.Event) error) error
+	GetAtRisk(query query.Query) ([]*AtRiskContainer, error)
+	Backtest(query query.Query, holdout time.Duration) (string, error)
+	GetBacktest(uuid string) (string, *Backtest, error)
 }
 
 type Pod struct {
 	Namespace string `json:"namespace"`
 	Name      string `json:"name"`
+	// node pool used for pricing, if configured
+	NodePool string `json:"node_pool,omitempty"`
 	// Container information
 	Containers []*Container `json:"containers,omitempty"`
 	// total usage infromation
 	Usages map[string]*resource.ResourceUsageInfo `json:"usage,omitempty"`
 }
 
-func (pod *Pod) Rightsizing(client *grpcclient.Client) error {
+// Rollup returns the usage of the containers and the pod total.
+func (pod *Pod) Rollup() resource.Rollup {
+	rollup := resource.Rollup{Total: pod.Usages}
+	for _, container := range pod.Containers {
+		rollup.Containers = append(rollup.Containers, container.Usage)
+	}
+	return rollup
+}
+
+// NewUsages returns an empty usage of every metric of ContainerMetricTables.
+func NewUsages() map[string]*resource.ResourceUsageInfo {
+	usages := make(map[string]*resource.ResourceUsageInfo, ContainerMetricTables.Len())
+	for idx, name := range ContainerMetricTables.GetMetricNames() {
+		usages[name] = &resource.ResourceUsageInfo{
+			ResourceName: name,
+			Unit:         ContainerMetricTables.GetUnit(idx),
+		}
+	}
+	return usages
+}
+
+// Rightsizing recommends the usages of the containers of all the pods
+// together, so that a batch recommender needs few calls for many pods.
+func Rightsizing(ctx context.Context, recommender rightsizing.Recommender, estimator rightsizing.Estimator, pods ...*Pod) error {
+	rollups := make([]resource.Rollup, len(pods))
+	for i, pod := range pods {
+		rollups[i] = pod.Rollup()
+	}
+	return rightsizing.RightsizingRollups(ctx, recommender, estimator, rollups...)
+}
+
+// Recommend fills the request/limit recommendation of every container and
+// the pod total. It should be called after Rightsizing.
+func (pod *Pod) Recommend() {
+	pod.Rollup().Recommend()
+}
+
+// ApplyCost sets the cost of every container and the pod total.
+// It should be called after Recommend.
+func (pod *Pod) ApplyCost(p *pricing.Pricing) {
+	p.ApplyRollup(p.GetRate(pod.Namespace, pod.NodePool), pod.Rollup())
+}
+
+// RecommendationResults converts the recommendations of the containers to
+// records of the results store.
+func (pod *Pod) RecommendationResults(recommender string, startTime, endTime time.Time) []*models.RecommendationResult {
+	var (
+		now     = time.Now()
+		records []*models.RecommendationResult
+	)
 	for _, container := range pod.Containers {
 		for name, usage := range container.Usage {
-			if len(usage.Usage) > 100 {
-				resp, err := client.Rightsizing(context.Background(), usage.Usage)
-				if err != nil {
-					return err
-				}
-				usage.OptimizedUsage = resp.Result
-				pod.Usages[name].OptimizedUsage += resp.Result
+			if usage.Recommendation == nil {
+				continue
 			}
+			records = append(records, &models.RecommendationResult{
+				CreatedAt:      now,
+				ObjectType:     models.ObjectTypePod,
+				Namespace:      pod.Namespace,
+				Name:           pod.Name,
+				Container:      container.Name,
+				Resource:       name,
+				Recommender:    recommender,
+				StartTime:      startTime,
+				EndTime:        endTime,
+				Samples:        len(usage.Usage),
+				OptimizedUsage: usage.OptimizedUsage,
+				Request:        usage.Recommendation.Request,
+				Limit:          usage.Recommendation.Limit,
+				CurrentRequest: usage.Recommendation.CurrentRequest,
+				CurrentLimit:   usage.Recommendation.CurrentLimit,
+			})
 		}
 	}
-	return nil
+	return records
+}
+
+// Summarize rolls up the requested, used and recommended resources of the
+// containers per resource.
+func (pod *Pod) Summarize() map[string]*resource.ResourceSummary {
+	return pod.Rollup().Summarize()
+}
+
+// RecommendationHistory is the time series of the recommendations of a pod
+// per container and resource.
+type RecommendationHistory struct {
+	Namespace  string                                                `json:"namespace"`
+	Name       string                                                `json:"name"`
+	Containers map[string]map[string][]*resource.RecommendationPoint `json:"containers"`
+}
+
+// LatestRecommendation is the most recently recorded recommendation of every
+// container and resource of a pod.
+type LatestRecommendation struct {
+	Namespace  string                                              `json:"namespace"`
+	Name       string                                              `json:"name"`
+	Containers map[string]map[string]*resource.RecommendationPoint `json:"containers"`
+}
+
+// AtRiskContainer is a capacity alert of the latest forecast of a pod.
+// TimeToExhaustion is the number of seconds until the quota is reached.
+type AtRiskContainer struct {
+	Namespace    string `json:"namespace"`
+	Pod          string `json:"pod"`
+	Container    string `json:"container"`
+	ForecastUUID string `json:"forecast_uuid"`
+	*resource.CapacityAlert
+	TimeToExhaustion int64 `json:"time_to_exhaustion"`
+}
+
+// StatusChange is the payload of the container.underallocated webhook.
+type StatusChange struct {
+	Namespace      string                   `json:"namespace"`
+	Pod            string                   `json:"pod"`
+	Container      string                   `json:"container"`
+	Resource       string                   `json:"resource"`
+	PreviousStatus string                   `json:"previous_status,omitempty"`
+	Status         string                   `json:"status"`
+	CurrentUsage   float64                  `json:"current_usage"`
+	Request        float64                  `json:"request"`
+	Limit          float64                  `json:"limit"`
+	Recommendation *resource.Recommendation `json:"recommendation,omitempty"`
+}
+
+// BatchForecastRequest selects the pods of a batch forecast, either as a list
+// or by namespace and label selector (e.g. app=web,tier=frontend).
+type BatchForecastRequest struct {
+	Items     []BatchForecastItem `json:"items,omitempty"`
+	Namespace string              `json:"namespace,omitempty"`
+	Selector  string              `json:"selector,omitempty"`
+}
+
+type BatchForecastItem struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// ForecastBatch is the progress of a batch forecast. Results holds the
+// forecasts of the finished pods keyed by namespace/name.
+type ForecastBatch struct {
+	ID        string                                        `json:"id"`
+	Total     int                                           `json:"total"`
+	Succeeded int                                           `json:"succeeded"`
+	Failed    int                                           `json:"failed"`
+	Progress  float64                                       `json:"progress"`
+	Items     []*ForecastBatchItem                          `json:"items"`
+	Results   map[string]map[string]*resource.ForecastUsage `json:"results"`
+}
+
+type ForecastBatchItem struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UUID      string `json:"uuid"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Backtest is the accuracy of the forecast of the last Holdout of the usage
+// of a pod, fitted on the usage before it, per container and resource.
+type Backtest struct {
+	Namespace  string                                   `json:"namespace"`
+	Name       string                                   `json:"name"`
+	Model      string                                   `json:"model"`
+	Holdout    string                                   `json:"holdout"`
+	Containers map[string]map[string]*resource.Accuracy `json:"containers"`
 }
 
 type Container struct {
@@ -1,11 +1,17 @@
 package pod
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 
 	"rightsizing-api-server/internal/api/common/query"
-	_ "rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/stream"
+	"rightsizing-api-server/internal/worker"
 )
 
 type PodHandler struct {
@@ -25,13 +31,23 @@ func PodRouter(route fiber.Router, ps PodService, logger *zap.Logger) {
 
 	rg := route.Group("/pods")
 	rg.Get("/clusterinfo", handler.getClusterInfo)
+	rg.Get("/at-risk", handler.getAtRisk)
 	// resource usage history
 	rg.Post("/forecast", handler.forecast)
 	rg.Get("/forecast", handler.forecast)
 	rg.Get("/forecast/status", handler.getForecastStatus)
 	rg.Get("/forecast/result", handler.getForecastResult)
+	rg.Get("/forecast/history", handler.getForecastHistory)
+	rg.Post("/forecast/batch", handler.forecastBatch)
+	rg.Get("/forecast/batch/:id/status", handler.getForecastBatch)
+	rg.Post("/forecast/backtest", handler.backtest)
+	rg.Get("/forecast/backtest/:uuid", handler.getBacktest)
 	rg.Get("/forecast/:uuid/status", handler.getForecastStatusByID)
 	rg.Get("/forecast/:uuid/result", handler.getForecastResultByID)
+	rg.Get("/forecast/:uuid/events", handler.streamForecast)
+	// recommendation history
+	rg.Get("/recommendations/latest", handler.getLatestRecommendations)
+	rg.Get("/:namespace/:name/recommendations/history", handler.getRecommendationHistory)
 }
 
 // @Summary 클러스터 전반적인 지표들을 제공
@@ -50,15 +66,47 @@ func (h *PodHandler) getClusterInfo(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(info)
 }
 
+// @Summary forecast 상한값(yhat_upper)이 request 또는 limit에 도달할 것으로 예측되는 container 목록 제공
+// @Description 기본 옵션으로 수행된 pod별 최신 forecast 결과를 기준으로 가장 먼저 도달하는 시점 순으로 정렬하여 제공한다. 이미 지난 시점은 제외한다.
+// @Accept  json
+// @Produce json
+// @Param namespace query string false "the namespace of pods"
+// @Param start     query string false "only the forecasts created after start"
+// @Success 200 {object} []AtRiskContainer
+// @Failure 400 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/at-risk [get]
+func (h *PodHandler) getAtRisk(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	containers, err := h.ps.GetAtRisk(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(containers)
+}
+
 // @Summary pod의 리소스 정보 및 사용량 관련 정보 제공
 // @Description pod의 리소스 quota 정보와 사용량 및 사용량 기반의 최적 사용량을 제공한다.
 // namespace, name을 지정하지 않으면 모든 pod들에 대해 제공한다. 단, 둘 다 명시하거나 둘 다 명시하지 않아야함.
+// 요청 시 분석한 추천값은 결과 저장소에 기록되지 않는다. 추천값 이력은 스케줄된 rightsizing 작업만 기록한다.
 // @Accept  json
 // @Produce json
 // @Param name      path  string  false  "the name of pod"
 // @Param namespace path  string  false  "the namespace of pod"
 // @Param start     query string  false "start time"
 // @Param end       query string  false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Param mode        query string false "historical (default), or predictive to recommend at least the forecast yhat_upper"
+// @Param model       query string false "forecast model of the predictive mode (prophet, holt-winters, seasonal-naive)"
+// @Param horizon     query string false "forecast window of the predictive mode (e.g. 24h, default 6h)"
+// @Param step        query string false "interval between usage datapoints (e.g. 1h, default the finest)"
+// @Param resolution  query string false "interval between usage datapoints instead of step, as step is the forecast interval in the predictive mode"
+// @Param max_points  query int    false "the maximum number of usage datapoints of a series"
 // @Success 200 {object} Pod or Pod list
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -89,12 +137,75 @@ func (h *PodHandler) getRightsizing(c *fiber.Ctx) error {
 	}
 }
 
+// @Summary 가장 최근에 기록된 pod의 container별 추천값 제공
+// @Description 스케줄된 rightsizing 작업이 기록한 추천값을 다시 분석하지 않고 제공한다. 요청 시 분석한 추천값은 기록되지 않는다.
+// @Accept  json
+// @Produce json
+// @Param namespace query string false "the namespace of pods"
+// @Param name      query string false "the name of pod"
+// @Param start     query string false "only the recommendations recorded after start"
+// @Success 200 {object} []LatestRecommendation
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/recommendations/latest [get]
+func (h *PodHandler) getLatestRecommendations(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	recommendations, err := h.ps.GetLatestRecommendations(query)
+	if err != nil {
+		h.logger.Debug("failed to get latest recommendations", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(recommendations)
+}
+
+// @Summary pod의 container별 추천값 변화 이력 제공
+// @Description 스케줄된 rightsizing 작업(schedule 파일의 rightsizing)이 주기적으로 기록한 container별 request/limit 추천값의 시계열을 제공한다. 요청 시 분석한 추천값은 포함되지 않는다.
+// @Accept  json
+// @Produce json
+// @Param namespace   path  string true  "the namespace of pod"
+// @Param name        path  string true  "the name of pod"
+// @Param start       query string false "start time"
+// @Param end         query string false "end time"
+// @Param recommender query string false "only the recommendations of the recommender"
+// @Success 200 {object} RecommendationHistory
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/{namespace}/{name}/recommendations/history [get]
+func (h *PodHandler) getRecommendationHistory(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+	query.Namespace = c.Params("namespace")
+	query.Name = c.Params("name")
+
+	history, err := h.ps.GetRecommendationHistory(query)
+	if err != nil {
+		h.logger.Debug("failed to get history", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(history)
+}
+
 // @Summary Post pod forecast task
 // @Description Create forecast task and result task UUID
 // @Accept  json
 // @Produce json
 // @Param namespace path string true "the namespace of pod"
 // @Param name      path string true "the name of pod"
+// @Param model          query string false "forecast model (prophet, holt-winters, seasonal-naive)"
+// @Param horizon        query string false "forecast window from now (e.g. 24h, default 6h)"
+// @Param step           query string false "interval between forecast datapoints (e.g. 10m, default 5m)"
+// @Param interval_width query number false "width of the uncertainty interval (default 0.1)"
+// @Param seasonality    query string false "seasonalities to fit (daily, weekly, yearly), automatic if empty"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -118,11 +229,145 @@ func (h *PodHandler) forecast(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary 여러 pod의 forecast 작업을 한 번에 생성
+// @Description pod 목록 또는 namespace/label selector로 선택된 pod들의 forecast 작업을 machinery group으로 생성하고 batch id를 제공한다.
+// @Accept  json
+// @Produce json
+// @Param request body  BatchForecastRequest true  "items, or namespace and selector (e.g. app=web,tier=frontend)"
+// @Param start   query string               false "start time"
+// @Param end     query string               false "end time"
+// @Param model   query string               false "forecast model (prophet, holt-winters, seasonal-naive)"
+// @Param horizon query string               false "forecast window from now (e.g. 24h, default 6h)"
+// @Param step    query string               false "interval between forecast datapoints (e.g. 10m, default 5m)"
+// @Param interval_width query number false "width of the uncertainty interval (default 0.1)"
+// @Param seasonality    query string false "seasonalities to fit (daily, weekly, yearly), automatic if empty"
+// @Success 200 {object} object
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/forecast/batch [post]
+func (h *PodHandler) forecastBatch(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	request := &BatchForecastRequest{}
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug("body parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	batchID, err := h.ps.ForecastBatch(query, request)
+	if err != nil {
+		h.logger.Debug("failed to send batch forecast", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(map[string]interface{}{
+		"batch_id": batchID,
+	})
+}
+
+// @Summary batch forecast의 진행 상황 및 결과 제공
+// @Description batch에 속한 pod별 forecast 상태와 완료된 pod들의 결과를 제공한다.
+// @Accept  json
+// @Produce json
+// @Param id path string true "the id of batch forecast"
+// @Success 200 {object} ForecastBatch
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/forecast/batch/{id}/status [get]
+func (h *PodHandler) getForecastBatch(c *fiber.Ctx) error {
+	batch, err := h.ps.GetForecastBatch(c.Params("id"))
+	if err != nil {
+		h.logger.Debug("failed to get batch", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(batch)
+}
+
+// @Summary 특정 pod의 forecast 정확도를 backtest로 측정
+// @Description 사용량의 마지막 holdout 구간을 제외한 사용량으로 holdout 구간을 forecast하고, 실제 사용량과 비교한 container/resource별 MAE, MAPE, RMSE, interval coverage를 machinery 작업으로 계산한다.
+// @Accept  json
+// @Produce json
+// @Param namespace      query string true  "the namespace of pod"
+// @Param name           query string true  "the name of pod"
+// @Param start          query string false "start time"
+// @Param end            query string false "end time"
+// @Param holdout        query string false "last window of the usage to forecast (e.g. 24h, default 6h)"
+// @Param model          query string false "forecast model (prophet, holt-winters, seasonal-naive)"
+// @Param step           query string false "interval between forecast datapoints (e.g. 10m, default 5m)"
+// @Param interval_width query number false "width of the uncertainty interval (default 0.1)"
+// @Param seasonality    query string false "seasonalities to fit (daily, weekly, yearly), automatic if empty"
+// @Success 200 {object} object
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/forecast/backtest [post]
+func (h *PodHandler) backtest(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	holdout, err := time.ParseDuration(c.Query("holdout", resource.DefaultForecastHorizon.String()))
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	uuid, err := h.ps.Backtest(query, holdout)
+	if err != nil {
+		h.logger.Debug("failed to send backtest", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(map[string]interface{}{
+		"uuid": uuid,
+	})
+}
+
+// @Summary backtest 작업의 상태 및 결과 제공
+// @Description 작업이 끝나지 않은 경우 result는 nil 값 제공.
+// @Accept  json
+// @Produce json
+// @Param uuid path string true "the uuid of backtest task"
+// @Success 200 {object} Backtest
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/forecast/backtest/{uuid} [get]
+func (h *PodHandler) getBacktest(c *fiber.Ctx) error {
+	var (
+		uuid = c.Params("uuid")
+	)
+
+	status, backtest, err := h.ps.GetBacktest(uuid)
+	if err != nil {
+		h.logger.Debug("failed to get backtest", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(map[string]interface{}{
+		"status": status,
+		"result": backtest,
+	})
+}
+
 // @Summary 특정 pod의 forecast 완료 여부를 알려줌
 // @Accept  json
 // @Produce json
 // @Param name      path string true "the name of pod"
 // @Param namespace path string true "the namespace of pod"
+// @Param model          query string false "forecast model of the forecast"
+// @Param horizon        query string false "forecast window of the forecast"
+// @Param step           query string false "interval between forecast datapoints of the forecast"
+// @Param interval_width query number false "width of the uncertainty interval of the forecast"
+// @Param seasonality    query string false "seasonalities of the forecast"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -134,7 +379,13 @@ func (h *PodHandler) getForecastStatus(c *fiber.Ctx) error {
 		name      = c.Params("name")
 	)
 
-	status, err := h.ps.GetForecastStatus(namespace, name)
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	status, err := h.ps.GetForecastStatus(namespace, name, query.Forecast)
 	if err != nil {
 		h.logger.Debug("failed to get status", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
@@ -151,6 +402,11 @@ func (h *PodHandler) getForecastStatus(c *fiber.Ctx) error {
 // @Produce json
 // @Param name      path string true "the name of pod"
 // @Param namespace path string true "the namespace of pod"
+// @Param model          query string false "forecast model of the forecast"
+// @Param horizon        query string false "forecast window of the forecast"
+// @Param step           query string false "interval between forecast datapoints of the forecast"
+// @Param interval_width query number false "width of the uncertainty interval of the forecast"
+// @Param seasonality    query string false "seasonalities of the forecast"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -162,7 +418,13 @@ func (h *PodHandler) getForecastResult(c *fiber.Ctx) error {
 		name      = c.Params("name")
 	)
 
-	forecastUsage, err := h.ps.GetForecastResult(namespace, name)
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	forecastUsage, err := h.ps.GetForecastResult(namespace, name, query.Forecast)
 	if err != nil {
 		h.logger.Debug("failed to get result", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
@@ -176,6 +438,45 @@ func (h *PodHandler) getForecastResult(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary 특정 pod의 과거 forecast 실행 목록 제공
+// @Description 결과 저장소에 기록된 forecast 입력, 모델, 시간 및 결과를 최신 순으로 제공한다.
+// @Accept  json
+// @Produce json
+// @Param namespace query string true  "the namespace of pod"
+// @Param name      query string true  "the name of pod"
+// @Param limit     query int    false "the maximum number of runs (default 20)"
+// @Success 200 {object} object
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/pods/forecast/history [get]
+func (h *PodHandler) getForecastHistory(c *fiber.Ctx) error {
+	var (
+		namespace = c.Query("namespace")
+		name      = c.Query("name")
+		limit     = c.Query("limit")
+	)
+
+	n := 0
+	if limit != "" {
+		var err error
+		if n, err = strconv.Atoi(limit); err != nil {
+			h.logger.Debug("query parser error", zap.Error(err))
+			return c.Status(fiber.StatusBadRequest).JSON(err)
+		}
+	}
+
+	runs, err := h.ps.GetForecastHistory(namespace, name, n)
+	if err != nil {
+		h.logger.Debug("failed to get history", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(map[string]interface{}{
+		"history": runs,
+	})
+}
+
 // @Summary 사용자의 요청에 따라 발급한 forecast id를 통해서 forecast 완료 여부 제공
 // @Accept  json
 // @Produce json
@@ -229,3 +530,19 @@ func (h *PodHandler) getForecastResultByID(c *fiber.Ctx) error {
 		"result": forecastUsage,
 	})
 }
+
+// @Summary forecast 작업의 진행 상황을 server-sent events로 제공
+// @Description 상태 변화(state), container별 진행 상황(progress)을 전송하고 마지막으로 결과(result)를 전송한 뒤 종료한다.
+// @Produce text/event-stream
+// @Param uuid path string true "the uuid of forecast task"
+// @Success 200 {object} worker.Event
+// @Router /api/v1/pods/forecast/{uuid}/events [get]
+func (h *PodHandler) streamForecast(c *fiber.Ctx) error {
+	var (
+		uuid = c.Params("uuid")
+	)
+
+	return stream.SSE(c, func(ctx context.Context, send func(*worker.Event) error) error {
+		return h.ps.WatchForecast(ctx, uuid, send)
+	}, h.logger)
+}
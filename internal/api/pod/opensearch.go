@@ -0,0 +1,204 @@
+package pod
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// the quota is the latest one of this period, like the Promscale queries
+const quotaPeriod = 5 * time.Minute
+
+// openSearchDatasource reads the metric documents of cAdvisor and
+// kube-state-metrics from OpenSearch, the usage aggregated by date histograms.
+type openSearchDatasource struct {
+	client *datasource.OpenSearchClient
+}
+
+var _ Datasource = (*openSearchDatasource)(nil)
+
+func (d *openSearchDatasource) QueryUsage(ctx context.Context, namespace, name, startTime, endTime string, step time.Duration) ([][]models.Container, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	numMetric := ContainerMetricTables.Len()
+	containerMetricUsages := make([][]models.Container, numMetric)
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < numMetric; i++ {
+		idx := i
+		g.Go(func() error {
+			labels := metricLabels(idx)
+			series, err := d.client.Histogram(gctx, datasource.OpenSearchQuery{
+				Metric:     ContainerMetricTables.GetSeriesName(idx),
+				GroupBy:    labels,
+				Match:      metricMatch(idx, podMatch(namespace, name)),
+				Exclude:    map[string][]string{labels[2]: {"", "POD"}},
+				Percentile: ContainerMetricTables.GetPercentile(idx),
+				Interval:   step,
+				Start:      start,
+				End:        end,
+			})
+			if err != nil {
+				return err
+			}
+			for _, s := range series {
+				containerMetricUsages[idx] = append(containerMetricUsages[idx], models.Container{
+					ContainerID: metricContainerID(idx, s.Labels),
+					Usage:       s.Points,
+				})
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return containerMetricUsages, nil
+}
+
+func (d *openSearchDatasource) QueryQuota(ctx context.Context, namespace, name string) ([]models.ContainerQuota, []models.ContainerQuota, error) {
+	var (
+		containerRequest []models.ContainerQuota
+		containerLimit   []models.ContainerQuota
+		end              = time.Now()
+		// quota of the resources of the metrics
+		resources = NewUsages()
+	)
+
+	query := func(ctx context.Context, metric string, quotas *[]models.ContainerQuota) error {
+		series, err := d.client.Latest(ctx, datasource.OpenSearchQuery{
+			Metric:  metric,
+			GroupBy: []string{"namespace", "pod", "container", "resource"},
+			Match:   podMatch(namespace, name),
+			Start:   end.Add(-quotaPeriod),
+			End:     end,
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range series {
+			if _, exist := resources[s.Labels["resource"]]; !exist {
+				continue
+			}
+			*quotas = append(*quotas, models.ContainerQuota{
+				ContainerID: containerID(s.Labels),
+				Resource:    s.Labels["resource"],
+				Value:       s.Points[0].Value,
+			})
+		}
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return query(gctx, osRequestQuotaMetric, &containerRequest)
+	})
+	g.Go(func() error {
+		return query(gctx, osLimitQuotaMetric, &containerLimit)
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return containerRequest, containerLimit, nil
+}
+
+func (d *openSearchDatasource) QueryNodePools(ctx context.Context, namespace, label, startTime, endTime string) ([]models.PodNodePool, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods, nodes []datasource.Series
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		pods, err = d.client.Latest(gctx, datasource.OpenSearchQuery{
+			Metric:  osPodInfoMetric,
+			GroupBy: []string{"namespace", "pod", "node"},
+			Match:   podMatch(namespace, ""),
+			Start:   start,
+			End:     end,
+		})
+		return err
+	})
+	g.Go(func() (err error) {
+		nodes, err = d.client.Latest(gctx, datasource.OpenSearchQuery{
+			Metric:  osNodeLabelMetric,
+			GroupBy: []string{"node", label},
+			Start:   start,
+			End:     end,
+		})
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	nodePools := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodePools[node.Labels["node"]] = node.Labels[label]
+	}
+
+	var podNodePools []models.PodNodePool
+	for _, pod := range pods {
+		nodePool, exist := nodePools[pod.Labels["node"]]
+		if !exist {
+			continue
+		}
+		podNodePools = append(podNodePools, models.PodNodePool{
+			Namespace: pod.Labels["namespace"],
+			Pod:       pod.Labels["pod"],
+			NodePool:  nodePool,
+		})
+	}
+	return podNodePools, nil
+}
+
+func (d *openSearchDatasource) QueryPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	match := podMatch(namespace, "")
+	for key, value := range selector {
+		match[labelColumn(key)] = value
+	}
+	series, err := d.client.Latest(ctx, datasource.OpenSearchQuery{
+		Metric:  osPodLabelMetric,
+		GroupBy: []string{"namespace", "pod"},
+		Match:   match,
+		Start:   start,
+		End:     end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]models.PodName, 0, len(series))
+	for _, s := range series {
+		pods = append(pods, models.PodName{
+			Namespace: s.Labels["namespace"],
+			Pod:       s.Labels["pod"],
+		})
+	}
+	return pods, nil
+}
+
+// podMatch matches the namespace, and the pod if the namespace is given.
+func podMatch(namespace, name string) map[string]string {
+	match := make(map[string]string)
+	if namespace != "" {
+		match["namespace"] = namespace
+		if name != "" {
+			match["pod"] = name
+		}
+	}
+	return match
+}
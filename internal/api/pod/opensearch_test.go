@@ -0,0 +1,161 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// responses of the fake OpenSearch by the metric of the search
+var openSearchResponses = map[string]string{
+	"container:container_cpu_usage:rate": `{"aggregations": {"series": {"buckets": [
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "@bucket": 1700000000000},
+		 "value": {"values": [{"key": 90.0, "value": 0.25}]}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "@bucket": 1700000600000},
+		 "value": {"values": [{"key": 90.0, "value": null}]}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "@bucket": 1700001200000},
+		 "value": {"values": [{"key": 90.0, "value": 0.5}]}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "sidecar", "@bucket": 1700000000000},
+		 "value": {"values": [{"key": 90.0, "value": 0.01}]}}
+	]}}}`,
+	"container_memory_working_set_bytes": `{"aggregations": {"series": {"buckets": [
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "@bucket": 1700000000000},
+		 "value": {"values": [{"key": 90.0, "value": 104857600}]}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "@bucket": 1700000600000},
+		 "value": {"values": [{"key": 90.0, "value": 209715200}]}}
+	]}}}`,
+	"kube_pod_container_resource_requests": `{"aggregations": {"series": {"buckets": [
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "cpu"},
+		 "latest": {"hits": {"hits": [{"sort": [1700001200000], "fields": {"prometheus.metrics.kube_pod_container_resource_requests": [0.5]}}]}}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "memory"},
+		 "latest": {"hits": {"hits": [{"sort": [1700001200000], "fields": {"prometheus.metrics.kube_pod_container_resource_requests": [268435456]}}]}}},
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "nvidia_com_gpu"},
+		 "latest": {"hits": {"hits": [{"sort": [1700001200000], "fields": {"prometheus.metrics.kube_pod_container_resource_requests": [1]}}]}}}
+	]}}}`,
+	"kube_pod_container_resource_limits": `{"aggregations": {"series": {"buckets": [
+		{"key": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "cpu"},
+		 "latest": {"hits": {"hits": [{"sort": [1700001200000], "fields": {"prometheus.metrics.kube_pod_container_resource_limits": [1]}}]}}}
+	]}}}`,
+}
+
+func newTestOpenSearch(t *testing.T) (*openSearchDatasource, func()) {
+	t.Helper()
+
+	options := datasource.DefaultOpenSearchOptions()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query json.RawMessage `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		query := string(body.Query)
+		for _, label := range []string{`"prometheus.labels.namespace":"default"`, `"prometheus.labels.pod":"nginx"`} {
+			if !strings.Contains(query, label) {
+				t.Errorf("query %s does not filter %s", query, label)
+			}
+		}
+		for metric, response := range openSearchResponses {
+			if strings.Contains(query, `"field":"`+options.MetricField+metric+`"`) {
+				w.Write([]byte(response))
+				return
+			}
+		}
+		t.Errorf("unexpected query %s", query)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	options.URL = server.URL
+	client, err := datasource.NewOpenSearchClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &openSearchDatasource{client: client}, server.Close
+}
+
+// The containers of OpenSearch are the rows the Promscale datasource reads
+// from the continuous aggregates, except for the series ids, like the
+// containers of Prometheus.
+func TestOpenSearchQueryUsage(t *testing.T) {
+	d, stop := newTestOpenSearch(t)
+	defer stop()
+
+	usages, err := d.QueryUsage(context.Background(), "default", "nginx",
+		time.Unix(1700000000, 0).Format(datasource.TimeLayout),
+		time.Unix(1700001200, 0).Format(datasource.TimeLayout), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nginx := models.ContainerID{Namespace: "default", Pod: "nginx", Name: "nginx"}
+	want := [][]models.Container{
+		// cpu, without the empty bucket like the promscale query
+		{
+			{
+				ContainerID: nginx,
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 0.25},
+					{Time: time.Unix(1700001200, 0), Value: 0.5},
+				},
+			},
+			{
+				ContainerID: models.ContainerID{Namespace: "default", Pod: "nginx", Name: "sidecar"},
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 0.01},
+				},
+			},
+		},
+		// memory
+		{
+			{
+				ContainerID: nginx,
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 104857600},
+					{Time: time.Unix(1700000600, 0), Value: 209715200},
+				},
+			},
+		},
+	}
+	if len(usages) != ContainerMetricTables.Len() {
+		t.Fatalf("got %d metrics, want %d", len(usages), ContainerMetricTables.Len())
+	}
+	for idx := range usages {
+		if !reflect.DeepEqual(usages[idx], want[idx]) {
+			t.Errorf("%s: got %+v, want %+v", ContainerMetricTables.GetMetricNames()[idx], usages[idx], want[idx])
+		}
+	}
+}
+
+// Only the quota of the resources of the metric catalogue is read.
+func TestOpenSearchQueryQuota(t *testing.T) {
+	d, stop := newTestOpenSearch(t)
+	defer stop()
+
+	requests, limits, err := d.QueryQuota(context.Background(), "default", "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nginx := models.ContainerID{Namespace: "default", Pod: "nginx", Name: "nginx"}
+	wantRequests := []models.ContainerQuota{
+		{ContainerID: nginx, Resource: "cpu", Value: 0.5},
+		{ContainerID: nginx, Resource: "memory", Value: 268435456},
+	}
+	wantLimits := []models.ContainerQuota{
+		{ContainerID: nginx, Resource: "cpu", Value: 1},
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests: got %+v, want %+v", requests, wantRequests)
+	}
+	if !reflect.DeepEqual(limits, wantLimits) {
+		t.Errorf("limits: got %+v, want %+v", limits, wantLimits)
+	}
+}
@@ -0,0 +1,229 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// prometheusDatasource reads the metrics of cAdvisor and kube-state-metrics
+// with PromQL, e.g. from Prometheus or Thanos Query.
+type prometheusDatasource struct {
+	client *datasource.PrometheusClient
+}
+
+var _ Datasource = (*prometheusDatasource)(nil)
+
+func (d *prometheusDatasource) QueryUsage(ctx context.Context, namespace, name, startTime, endTime string, step time.Duration) ([][]models.Container, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// the window of the samples is the step of the range query
+	step = d.client.RangeStep(start, end, step)
+	var (
+		numMetric = ContainerMetricTables.Len()
+		match     = podMatch(namespace, name)
+		window    = datasource.Duration(step)
+	)
+
+	containerMetricUsages := make([][]models.Container, numMetric)
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < numMetric; i++ {
+		idx := i
+		g.Go(func() error {
+			series, err := d.client.QueryRange(gctx, usageQuery(idx, match, window), start, end, step)
+			if err != nil {
+				return err
+			}
+			for _, s := range series {
+				containerMetricUsages[idx] = append(containerMetricUsages[idx], models.Container{
+					ContainerID: metricContainerID(idx, s.Labels),
+					Usage:       s.Points,
+				})
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return containerMetricUsages, nil
+}
+
+func (d *prometheusDatasource) QueryQuota(ctx context.Context, namespace, name string) ([]models.ContainerQuota, []models.ContainerQuota, error) {
+	var (
+		containerRequest []models.ContainerQuota
+		containerLimit   []models.ContainerQuota
+		filter           = podFilter(namespace, name)
+		// quota of the resources of the metrics, whose names are label values
+		resources = strings.Join(ContainerMetricTables.GetMetricNames(), "|")
+		now       = time.Now()
+	)
+
+	query := func(ctx context.Context, query string, quotas *[]models.ContainerQuota) error {
+		series, err := d.client.Query(ctx, fmt.Sprintf(query, resources, filter), now)
+		if err != nil {
+			return err
+		}
+		for _, s := range series {
+			if len(s.Points) == 0 {
+				continue
+			}
+			*quotas = append(*quotas, models.ContainerQuota{
+				ContainerID: containerID(s.Labels),
+				Resource:    s.Labels["resource"],
+				Value:       s.Points[0].Value,
+			})
+		}
+		return nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return query(gctx, promRequestQuotaQuery, &containerRequest)
+	})
+	g.Go(func() error {
+		return query(gctx, promLimitQuotaQuery, &containerLimit)
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return containerRequest, containerLimit, nil
+}
+
+func (d *prometheusDatasource) QueryNodePools(ctx context.Context, namespace, label, startTime, endTime string) ([]models.PodNodePool, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pods, nodes []datasource.Series
+		window      = datasource.Duration(end.Sub(start))
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		pods, err = d.client.Query(gctx, fmt.Sprintf(promPodNodeQuery, podFilter(namespace, ""), window), end)
+		return err
+	})
+	g.Go(func() (err error) {
+		nodes, err = d.client.Query(gctx, fmt.Sprintf(promNodePoolQuery, label, window), end)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	nodePools := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		nodePools[node.Labels["node"]] = node.Labels[label]
+	}
+
+	var podNodePools []models.PodNodePool
+	for _, pod := range pods {
+		nodePool, exist := nodePools[pod.Labels["node"]]
+		if !exist {
+			continue
+		}
+		podNodePools = append(podNodePools, models.PodNodePool{
+			Namespace: pod.Labels["namespace"],
+			Pod:       pod.Labels["pod"],
+			NodePool:  nodePool,
+		})
+	}
+	return podNodePools, nil
+}
+
+func (d *prometheusDatasource) QueryPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(selector)+1)
+	if namespace != "" {
+		labels["namespace"] = namespace
+	}
+	for key, value := range selector {
+		labels[labelColumn(key)] = value
+	}
+
+	query := fmt.Sprintf(promPodLabelQuery, datasource.LabelFilter(labels), datasource.Duration(end.Sub(start)))
+	series, err := d.client.Query(ctx, query, end)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]models.PodName, 0, len(series))
+	for _, s := range series {
+		pods = append(pods, models.PodName{
+			Namespace: s.Labels["namespace"],
+			Pod:       s.Labels["pod"],
+		})
+	}
+	return pods, nil
+}
+
+// podFilter filters the namespace, and the pod if the namespace is given.
+func podFilter(namespace, name string) string {
+	return datasource.LabelFilter(podMatch(namespace, name))
+}
+
+func containerID(labels map[string]string) models.ContainerID {
+	return models.ContainerID{
+		Namespace: labels["namespace"],
+		Pod:       labels["pod"],
+		Name:      labels["container"],
+	}
+}
+
+// usageQuery returns the PromQL of the usage of the metric of
+// ContainerMetricTables at idx.
+func usageQuery(idx int, match map[string]string, window string) string {
+	labels := metricLabels(idx)
+	query := ContainerMetricTables.GetQuery(idx)
+	if query == "" {
+		query = fmt.Sprintf(promSeriesQuery, ContainerMetricTables.GetSeriesName(idx), labels[2])
+	}
+	filter := datasource.LabelFilter(metricMatch(idx, match))
+	percentile := ContainerMetricTables.GetPercentile(idx) / 100
+	return fmt.Sprintf(promUsageQuery, strings.Join(labels, ", "), percentile, fmt.Sprintf(query, filter), window)
+}
+
+// metricLabels returns the namespace, pod and container label columns of the
+// metric of ContainerMetricTables at idx.
+func metricLabels(idx int) []string {
+	return []string{
+		ContainerMetricTables.GetLabel(idx, "namespace"),
+		ContainerMetricTables.GetLabel(idx, "pod"),
+		ContainerMetricTables.GetLabel(idx, "container"),
+	}
+}
+
+// metricMatch maps the roles of match to the label columns of the metric of
+// ContainerMetricTables at idx.
+func metricMatch(idx int, match map[string]string) map[string]string {
+	labels := make(map[string]string, len(match))
+	for role, value := range match {
+		labels[ContainerMetricTables.GetLabel(idx, role)] = value
+	}
+	return labels
+}
+
+func metricContainerID(idx int, labels map[string]string) models.ContainerID {
+	columns := metricLabels(idx)
+	return models.ContainerID{
+		Namespace: labels[columns[0]],
+		Pod:       labels[columns[1]],
+		Name:      labels[columns[2]],
+	}
+}
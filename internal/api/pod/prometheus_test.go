@@ -0,0 +1,162 @@
+package pod
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// responses of the fake Prometheus by a part of the query
+var prometheusResponses = map[string]string{
+	"container_cpu_usage_seconds_total": `{"status": "success", "data": {"resultType": "matrix", "result": [
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx"},
+		 "values": [[1700000000, "0.25"], [1700000600, "NaN"], [1700001200, "0.5"]]},
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "sidecar"},
+		 "values": [[1700000000, "0.01"]]}
+	]}}`,
+	"container_memory_working_set_bytes": `{"status": "success", "data": {"resultType": "matrix", "result": [
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx"},
+		 "values": [[1700000000, "104857600"], [1700000600, "209715200"]]}
+	]}}`,
+	"kube_pod_container_resource_requests": `{"status": "success", "data": {"resultType": "vector", "result": [
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "cpu"}, "value": [1700001200, "0.5"]},
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "memory"}, "value": [1700001200, "268435456"]}
+	]}}`,
+	"kube_pod_container_resource_limits": `{"status": "success", "data": {"resultType": "vector", "result": [
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "cpu"}, "value": [1700001200, "1"]},
+		{"metric": {"namespace": "default", "pod": "nginx", "container": "nginx", "resource": "memory"}, "value": [1700001200, "NaN"]}
+	]}}`,
+}
+
+func newTestPrometheus(t *testing.T) (*prometheusDatasource, func()) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.FormValue("query")
+		for _, label := range []string{`namespace="default"`, `pod="nginx"`} {
+			if !strings.Contains(query, label) {
+				t.Errorf("query %s does not filter %s", query, label)
+			}
+		}
+		for metric, body := range prometheusResponses {
+			if strings.Contains(query, metric) {
+				w.Write([]byte(body))
+				return
+			}
+		}
+		t.Errorf("unexpected query %s", query)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	options := datasource.DefaultPrometheusOptions()
+	options.URL = server.URL
+	client, err := datasource.NewPrometheusClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &prometheusDatasource{client: client}, server.Close
+}
+
+func sortContainers(containers []models.Container) {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+}
+
+func sortQuotas(quotas []models.ContainerQuota) {
+	sort.Slice(quotas, func(i, j int) bool {
+		if quotas[i].Name != quotas[j].Name {
+			return quotas[i].Name < quotas[j].Name
+		}
+		return quotas[i].Resource < quotas[j].Resource
+	})
+}
+
+// The containers of Prometheus are the rows the Promscale datasource reads
+// from the continuous aggregates, except for the series ids which Prometheus
+// does not have. The repository matches the containers by their names.
+func TestPrometheusQueryUsage(t *testing.T) {
+	d, stop := newTestPrometheus(t)
+	defer stop()
+
+	usages, err := d.QueryUsage(context.Background(), "default", "nginx",
+		time.Unix(1700000000, 0).Format(datasource.TimeLayout),
+		time.Unix(1700001200, 0).Format(datasource.TimeLayout), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]models.Container{
+		// cpu, without the NaN bucket like the promscale query
+		{
+			{
+				ContainerID: models.ContainerID{Namespace: "default", Pod: "nginx", Name: "nginx"},
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 0.25},
+					{Time: time.Unix(1700001200, 0), Value: 0.5},
+				},
+			},
+			{
+				ContainerID: models.ContainerID{Namespace: "default", Pod: "nginx", Name: "sidecar"},
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 0.01},
+				},
+			},
+		},
+		// memory
+		{
+			{
+				ContainerID: models.ContainerID{Namespace: "default", Pod: "nginx", Name: "nginx"},
+				Usage: []models.TimeSeriesDatapoint{
+					{Time: time.Unix(1700000000, 0), Value: 104857600},
+					{Time: time.Unix(1700000600, 0), Value: 209715200},
+				},
+			},
+		},
+	}
+	if len(usages) != ContainerMetricTables.Len() {
+		t.Fatalf("got %d metrics, want %d", len(usages), ContainerMetricTables.Len())
+	}
+	for idx := range usages {
+		sortContainers(usages[idx])
+		if !reflect.DeepEqual(usages[idx], want[idx]) {
+			t.Errorf("%s: got %+v, want %+v", ContainerMetricTables.GetMetricNames()[idx], usages[idx], want[idx])
+		}
+	}
+}
+
+func TestPrometheusQueryQuota(t *testing.T) {
+	d, stop := newTestPrometheus(t)
+	defer stop()
+
+	requests, limits, err := d.QueryQuota(context.Background(), "default", "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortQuotas(requests)
+	sortQuotas(limits)
+
+	nginx := models.ContainerID{Namespace: "default", Pod: "nginx", Name: "nginx"}
+	wantRequests := []models.ContainerQuota{
+		{ContainerID: nginx, Resource: "cpu", Value: 0.5},
+		{ContainerID: nginx, Resource: "memory", Value: 268435456},
+	}
+	// the NaN limit is left out like the promscale query does
+	wantLimits := []models.ContainerQuota{
+		{ContainerID: nginx, Resource: "cpu", Value: 1},
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests: got %+v, want %+v", requests, wantRequests)
+	}
+	if !reflect.DeepEqual(limits, wantLimits) {
+		t.Errorf("limits: got %+v, want %+v", limits, wantLimits)
+	}
+}
@@ -0,0 +1,175 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/models"
+)
+
+// promscaleDatasource reads the Promscale schema of TimescaleDB, the usage
+// from the continuous aggregates of ContainerMetricTables. The coarsest
+// aggregate which fits the step is read, downsampled if it is finer.
+type promscaleDatasource struct {
+	db         *gorm.DB
+	aggregates *database.AggregateSelector
+}
+
+var _ Datasource = (*promscaleDatasource)(nil)
+
+func (d *promscaleDatasource) QueryUsage(ctx context.Context, namespace, name, startTime, endTime string, step time.Duration) ([][]models.Container, error) {
+	var (
+		numMetric = ContainerMetricTables.Len()
+		// goroutine and thread safe
+		ctxDB = d.db.WithContext(ctx)
+	)
+
+	containerMetricUsages := make([][]models.Container, numMetric)
+	g, _ := errgroup.WithContext(ctx)
+	for i := 0; i < numMetric; i++ {
+		idx := i
+		g.Go(func() error {
+			view, bucket, err := d.aggregates.Select(ctx, ContainerMetricTables.GetMetricTableName(idx), step)
+			if err != nil {
+				return err
+			}
+
+			labels := metricLabels(idx)
+			db := ctxDB.Scopes(ContainerMetricTables.GetIDTable(idx)).
+				Select(fmt.Sprintf("series_id, %s AS namespace, %s AS pod, %s AS container", labels[0], labels[1], labels[2])).
+				Preload("Usage", func(db *gorm.DB) *gorm.DB {
+					db = db.Table(view).
+						Where("value != 'NaN'").
+						Where("bucket >= ? AND bucket <= ?", startTime, endTime)
+					if step > bucket {
+						db = db.Scopes(database.Downsample(step, bucket, ContainerMetricTables.GetPercentile(idx)))
+					}
+					return db.Order("bucket")
+				})
+			if namespace != "" && name != "" {
+				db = db.Where(fmt.Sprintf("%s=? AND %s=?", labels[0], labels[1]), namespace, name)
+			} else if namespace != "" {
+				db = db.Where(fmt.Sprintf("%s=?", labels[0]), namespace)
+			}
+			err = db.Where(fmt.Sprintf("%[1]s!='POD' AND %[1]s != ''", labels[2])).
+				Find(&containerMetricUsages[idx]).
+				Error
+			if err != nil {
+				return err
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return containerMetricUsages, nil
+}
+
+func (d *promscaleDatasource) QueryQuota(ctx context.Context, namespace, name string) ([]models.ContainerQuota, []models.ContainerQuota, error) {
+	var (
+		containerRequest []models.ContainerQuota
+		containerLimit   []models.ContainerQuota
+		// query
+		requestQuery = requestQuotaQuery + allQuotaQuery
+		limitQuery   = limitQuotaQuery + allQuotaQuery
+	)
+
+	var args []interface{}
+	if namespace != "" && name != "" {
+		requestQuery = requestQuotaQuery + targetQuotaQuery
+		limitQuery = limitQuotaQuery + targetQuotaQuery
+		args = []interface{}{namespace, name}
+	} else if namespace != "" {
+		requestQuery = requestQuotaQuery + namespaceQuotaQuery
+		limitQuery = limitQuotaQuery + namespaceQuotaQuery
+		args = []interface{}{namespace}
+	}
+	// quota of the resources of the metrics
+	args = append(args, ContainerMetricTables.GetMetricNames())
+
+	ctxDB := d.db.WithContext(ctx)
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		db := ctxDB.Raw(requestQuery, args...)
+		err := db.Find(&containerRequest).Error
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	g.Go(func() error {
+		db := ctxDB.Raw(limitQuery, args...)
+		err := db.Find(&containerLimit).Error
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return containerRequest, containerLimit, nil
+}
+
+func (d *promscaleDatasource) QueryNodePools(ctx context.Context, namespace, label, startTime, endTime string) ([]models.PodNodePool, error) {
+	var (
+		podNodePools []models.PodNodePool
+		filter       string
+		args         = []interface{}{startTime, endTime}
+	)
+
+	if namespace != "" {
+		filter = nodePoolNamespaceQuery
+		args = append(args, namespace)
+	}
+	args = append(args, startTime, endTime)
+
+	err := d.db.WithContext(ctx).
+		Raw(fmt.Sprintf(nodePoolQuery, filter, label), args...).
+		Find(&podNodePools).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return podNodePools, nil
+}
+
+func (d *promscaleDatasource) QueryPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error) {
+	var (
+		pods   []models.PodName
+		filter string
+		args   = []interface{}{startTime, endTime}
+	)
+
+	if namespace != "" {
+		filter += podLabelNamespaceQuery
+		args = append(args, namespace)
+	}
+	// sorted for the same query on the same selector
+	keys := make([]string, 0, len(selector))
+	for key := range selector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		filter += fmt.Sprintf(podLabelFilterQuery, labelColumn(key))
+		args = append(args, selector[key])
+	}
+
+	err := d.db.WithContext(ctx).
+		Raw(fmt.Sprintf(podLabelQuery, filter), args...).
+		Find(&pods).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
@@ -1,28 +1,29 @@
 package pod
 
 import (
+	"rightsizing-api-server/internal/api/common/resource"
 	"rightsizing-api-server/internal/api/common/table"
 )
 
-var (
-	MetricName = []string{
-		"cpu",
-		"memory",
-	}
-)
-
-var (
-	IDTableName = []string{
-		"prom_series.container:container_cpu_usage:rate",
-		"prom_series.container_memory_working_set_bytes",
-	}
-	MetricTableName = []string{
-		":container_cpu_usage:10min",
-		":container_memory_working_set_bytes:10min",
-	}
-)
+// DefaultMetrics are the usage metrics of containers, replaced by the pods of
+// the metric catalogue. The label roles are namespace, pod and container.
+var DefaultMetrics = []table.Metric{
+	{
+		Name:           "cpu",
+		Unit:           resource.UnitCores,
+		IDTable:        "prom_series.container:container_cpu_usage:rate",
+		AggregateTable: ":container_cpu_usage:10min",
+		Query:          `rate(container_cpu_usage_seconds_total{container!="",container!="POD"%s}[5m])`,
+	},
+	{
+		Name:           "memory",
+		Unit:           resource.UnitBytes,
+		IDTable:        "prom_series.container_memory_working_set_bytes",
+		AggregateTable: ":container_memory_working_set_bytes:10min",
+	},
+}
 
-var ContainerMetricTables = table.SetupTable(MetricName, IDTableName, MetricTableName)
+var ContainerMetricTables = table.NewTable(DefaultMetrics)
 
 const (
 	requestQuotaQuery = `SELECT DISTINCT ON (namespace_id, pod_id, container_id, resource_id) 
@@ -39,6 +40,67 @@ val(container_id) container,
 val(resource_id) resource, 
 value
 FROM prom_metric.kube_pod_container_resource_limits `
-	allQuotaQuery    = `WHERE time >= now() - interval '5m' AND value != 'Nan' AND val(resource_id) IN ('cpu', 'memory') ORDER BY namespace_id, pod_id, container_id, resource_id, time DESC`
-	targetQuotaQuery = `WHERE time >= now() - interval '5m' AND val(namespace_id) = ? AND val(pod_id) = ? AND value != 'NaN' AND val(resource_id) IN ('cpu', 'memory') ORDER BY namespace_id, pod_id, container_id, resource_id, time DESC`
+	allQuotaQuery       = `WHERE time >= now() - interval '5m' AND value != 'Nan' AND val(resource_id) IN ? ORDER BY namespace_id, pod_id, container_id, resource_id, time DESC`
+	targetQuotaQuery    = `WHERE time >= now() - interval '5m' AND val(namespace_id) = ? AND val(pod_id) = ? AND value != 'NaN' AND val(resource_id) IN ? ORDER BY namespace_id, pod_id, container_id, resource_id, time DESC`
+	namespaceQuotaQuery = `WHERE time >= now() - interval '5m' AND val(namespace_id) = ? AND value != 'NaN' AND val(resource_id) IN ? ORDER BY namespace_id, pod_id, container_id, resource_id, time DESC`
+)
+
+// node pool label column is validated by the pricing loader
+const (
+	nodePoolQuery = `WITH pods AS (
+SELECT DISTINCT ON (namespace_id, pod_id) 
+val(namespace_id) namespace, 
+val(pod_id) pod, 
+val(node_id) node 
+FROM prom_metric.kube_pod_info 
+WHERE time >= ? AND time <= ? %s
+ORDER BY namespace_id, pod_id, time DESC
+), nodes AS (
+SELECT DISTINCT ON (node_id) 
+val(node_id) node, 
+val(%s_id) node_pool 
+FROM prom_metric.kube_node_labels 
+WHERE time >= ? AND time <= ? 
+ORDER BY node_id, time DESC
+)
+SELECT pods.namespace, pods.pod, nodes.node_pool FROM pods JOIN nodes ON pods.node = nodes.node`
+	nodePoolNamespaceQuery = `AND val(namespace_id) = ? `
+)
+
+// label columns are sanitized by the repository
+const (
+	podLabelQuery = `SELECT DISTINCT ON (namespace_id, pod_id) 
+val(namespace_id) namespace, 
+val(pod_id) pod 
+FROM prom_metric.kube_pod_labels 
+WHERE time >= ? AND time <= ? %s
+ORDER BY namespace_id, pod_id, time DESC`
+	podLabelNamespaceQuery = `AND val(namespace_id) = ? `
+	podLabelFilterQuery    = `AND val(%s_id) = ? `
+)
+
+// PromQL of the Prometheus datasource. The usage of a metric is aggregated
+// like the continuous aggregates, the percentile of every step. The samples
+// of a metric without query are its series, except the pause containers.
+const (
+	promUsageQuery  = `max by (%[1]s) (quantile_over_time(%[2]g, (%[3]s)[%[4]s:1m]))`
+	promSeriesQuery = `%[1]s{%[2]s!="",%[2]s!="POD"%%s}`
+)
+
+const (
+	promRequestQuotaQuery = `max by (namespace, pod, container, resource) (last_over_time(kube_pod_container_resource_requests{resource=~"%[1]s"%[2]s}[5m]))`
+	promLimitQuotaQuery   = `max by (namespace, pod, container, resource) (last_over_time(kube_pod_container_resource_limits{resource=~"%[1]s"%[2]s}[5m]))`
+	promPodNodeQuery      = `max by (namespace, pod, node) (last_over_time(kube_pod_info{node!=""%[1]s}[%[2]s]))`
+	promNodePoolQuery     = `max by (node, %[1]s) (last_over_time(kube_node_labels{%[1]s!=""}[%[2]s]))`
+	promPodLabelQuery     = `max by (namespace, pod) (last_over_time(kube_pod_labels{pod!=""%[1]s}[%[2]s]))`
+)
+
+// metrics of the OpenSearch datasource, the usage metrics are the series of
+// ContainerMetricTables
+const (
+	osRequestQuotaMetric = "kube_pod_container_resource_requests"
+	osLimitQuotaMetric   = "kube_pod_container_resource_limits"
+	osPodInfoMetric      = "kube_pod_info"
+	osNodeLabelMetric    = "kube_node_labels"
+	osPodLabelMetric     = "kube_pod_labels"
 )
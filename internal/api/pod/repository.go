@@ -2,29 +2,52 @@ package pod
 
 import (
 	"context"
+	"regexp"
+	"time"
 
-	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/datasource"
 	"rightsizing-api-server/internal/models"
 )
 
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
 type podRepository struct {
-	db *gorm.DB
+	source Datasource
 }
 
 var _ PodRepository = (*podRepository)(nil)
 
+// NewPodRepository reads the metrics from TimescaleDB with the Promscale schema.
 func NewPodRepository(db *gorm.DB) PodRepository {
 	return &podRepository{
-		db: db,
+		source: &promscaleDatasource{
+			db:         db,
+			aggregates: database.NewAggregateSelector(db),
+		},
+	}
+}
+
+// NewPrometheusPodRepository reads the metrics from the Prometheus HTTP API.
+func NewPrometheusPodRepository(client *datasource.PrometheusClient) PodRepository {
+	return &podRepository{
+		source: &prometheusDatasource{client: client},
+	}
+}
+
+// NewOpenSearchPodRepository reads the metric documents from OpenSearch.
+func NewOpenSearchPodRepository(client *datasource.OpenSearchClient) PodRepository {
+	return &podRepository{
+		source: &openSearchDatasource{client: client},
 	}
 }
 
 func (r *podRepository) GetAllPodQuota(query query.Query) ([]*Pod, error) {
-	containers, err := r.QueryResourceQuota(context.Background(), "", "")
+	containers, err := r.QueryResourceQuota(query.Context(), "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -49,49 +72,18 @@ func (r *podRepository) GetAllPodQuota(query query.Query) ([]*Pod, error) {
 }
 
 func (r *podRepository) QueryResourceQuota(ctx context.Context, namespace, name string) (map[string]*Container, error) {
-	var (
-		containerRequest []models.ContainerQuota
-		containerLimit   []models.ContainerQuota
-		// query
-		requestQuery = requestQuotaQuery + allQuotaQuery
-		limitQuery   = limitQuotaQuery + allQuotaQuery
-	)
-
-	if namespace != "" && name != "" {
-		requestQuery = requestQuotaQuery + targetQuotaQuery
-		limitQuery = limitQuotaQuery + targetQuotaQuery
-	}
-
-	ctxDB := r.db.WithContext(ctx)
-	g, _ := errgroup.WithContext(ctx)
-	g.Go(func() error {
-		db := ctxDB.Raw(requestQuery)
-		if namespace != "" && name != "" {
-			db = ctxDB.Raw(requestQuery, namespace, name)
-		}
-		err := db.Find(&containerRequest).Error
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-	g.Go(func() error {
-		db := ctxDB.Raw(limitQuery)
-		if namespace != "" && name != "" {
-			db = ctxDB.Raw(limitQuery, namespace, name)
-		}
-		err := db.Find(&containerLimit).Error
-		if err != nil {
-			return err
-		}
-		return nil
-	})
-	if err := g.Wait(); err != nil {
+	containerRequest, containerLimit, err := r.source.QueryQuota(ctx, namespace, name)
+	if err != nil {
 		return nil, err
 	}
 
+	// quotas of the resources which are not in the metric catalogue are left out
+	metrics := NewUsages()
 	containers := make(map[string]*Container)
 	for _, request := range containerRequest {
+		if _, exist := metrics[request.Resource]; !exist {
+			continue
+		}
 		name := UniqueContainerNameByField(request.Namespace, request.Pod, request.Name)
 		if _, exist := containers[name]; !exist {
 			containers[name] = &Container{
@@ -104,11 +96,15 @@ func (r *podRepository) QueryResourceQuota(ctx context.Context, namespace, name
 		if _, exist := containers[name].Usage[request.Resource]; !exist {
 			containers[name].Usage[request.Resource] = &resource.ResourceUsageInfo{
 				ResourceName: request.Resource,
+				Unit:         metrics[request.Resource].Unit,
 			}
 		}
 		containers[name].Usage[request.Resource].Request = request.Value
 	}
 	for _, limit := range containerLimit {
+		if _, exist := metrics[limit.Resource]; !exist {
+			continue
+		}
 		name := UniqueContainerNameByField(limit.Namespace, limit.Pod, limit.Name)
 		if _, exist := containers[name]; !exist {
 			containers[name] = &Container{
@@ -121,6 +117,7 @@ func (r *podRepository) QueryResourceQuota(ctx context.Context, namespace, name
 		if _, exist := containers[name].Usage[limit.Resource]; !exist {
 			containers[name].Usage[limit.Resource] = &resource.ResourceUsageInfo{
 				ResourceName: limit.Resource,
+				Unit:         metrics[limit.Resource].Unit,
 			}
 		}
 		containers[name].Usage[limit.Resource].Limit = limit.Value
@@ -134,7 +131,7 @@ func (r *podRepository) GetAllPod(query query.Query) ([]*Pod, error) {
 		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
 	)
 
-	containers, err := r.Query(context.Background(), "", "", startTime, endTime)
+	containers, err := r.Query(query.Context(), query.Namespace, "", startTime, endTime, query.Resolution)
 	if err != nil {
 		return nil, err
 	}
@@ -147,10 +144,7 @@ func (r *podRepository) GetAllPod(query query.Query) ([]*Pod, error) {
 				Namespace:  container.Namespace,
 				Name:       container.Pod,
 				Containers: make([]*Container, 0),
-				Usages: map[string]*resource.ResourceUsageInfo{
-					"cpu":    {ResourceName: "cpu"},
-					"memory": {ResourceName: "memory"},
-				},
+				Usages:     NewUsages(),
 			}
 		}
 		podMap[name].Containers = append(podMap[name].Containers, container)
@@ -178,7 +172,7 @@ func (r *podRepository) GetPod(query query.Query) (*Pod, error) {
 		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
 	)
 
-	containers, err := r.Query(context.Background(), namespace, name, startTime, endTime)
+	containers, err := r.Query(query.Context(), namespace, name, startTime, endTime, query.Resolution)
 	if err != nil {
 		return nil, err
 	}
@@ -187,10 +181,7 @@ func (r *podRepository) GetPod(query query.Query) (*Pod, error) {
 		Namespace:  query.Namespace,
 		Name:       query.Name,
 		Containers: containers,
-		Usages: map[string]*resource.ResourceUsageInfo{
-			"cpu":    {ResourceName: "cpu"},
-			"memory": {ResourceName: "memory"},
-		},
+		Usages:     NewUsages(),
 	}
 
 	for _, container := range pod.Containers {
@@ -204,40 +195,16 @@ func (r *podRepository) GetPod(query query.Query) (*Pod, error) {
 	return pod, nil
 }
 
-func (r *podRepository) Query(ctx context.Context, namespace, name, startTime, endTime string) ([]*Container, error) {
+// Query returns the containers with their usage every step, the finest
+// resolution if step is zero, and their quota.
+func (r *podRepository) Query(ctx context.Context, namespace, name, startTime, endTime string, step time.Duration) ([]*Container, error) {
 	var (
 		numMetric   = ContainerMetricTables.Len()
 		metricNames = ContainerMetricTables.GetMetricNames()
-		// goroutine and thread safe
-		ctxDB = r.db.WithContext(ctx)
 	)
 
-	containerMetricUsages := make([][]models.Container, numMetric)
-	g, _ := errgroup.WithContext(ctx)
-	for i := 0; i < numMetric; i++ {
-		idx := i
-		g.Go(func() error {
-			db := ctxDB.Scopes(ContainerMetricTables.GetIDTable(idx)).
-				Preload("Usage", func(db *gorm.DB) *gorm.DB {
-					return db.Table(ContainerMetricTables.GetMetricTableName(idx)).
-						Where("value != 'NaN'").
-						Where("bucket >= ? AND bucket <= ?", startTime, endTime).
-						Order("bucket")
-				})
-			if namespace != "" && name != "" {
-				db = db.Where("namespace=? AND pod=?", namespace, name)
-			}
-			err := db.Where("container!='POD' AND container != ''").
-				Find(&containerMetricUsages[idx]).
-				Error
-			if err != nil {
-				return err
-			}
-			return nil
-		})
-	}
-
-	if err := g.Wait(); err != nil {
+	containerMetricUsages, err := r.source.QueryUsage(ctx, namespace, name, startTime, endTime, step)
+	if err != nil {
 		return nil, err
 	}
 
@@ -255,6 +222,7 @@ func (r *podRepository) Query(ctx context.Context, namespace, name, startTime, e
 				}
 			}
 			usage := resource.NewResourceUsage(metricName, containerUsage.Usage)
+			usage.Unit = ContainerMetricTables.GetUnit(metricIdx)
 			containerMap[name].Usage[metricName] = usage
 		}
 	}
@@ -283,3 +251,36 @@ func (r *podRepository) Query(ctx context.Context, namespace, name, startTime, e
 	}
 	return containers, nil
 }
+
+// GetNodePools returns the node pool of every pod, keyed by namespace and pod
+// name, see NodePoolOf. label is the kube_node_labels label which holds the
+// node pool name.
+// NodePoolOf returns the node pool of the pod in the node pools of GetNodePools.
+func NodePoolOf(nodePools map[string]string, namespace, name string) string {
+	return nodePools[uniqueName(namespace, name)]
+}
+
+func (r *podRepository) GetNodePools(ctx context.Context, namespace, label, startTime, endTime string) (map[string]string, error) {
+	podNodePools, err := r.source.QueryNodePools(ctx, namespace, label, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePools := make(map[string]string, len(podNodePools))
+	for _, podNodePool := range podNodePools {
+		nodePools[uniqueName(podNodePool.Namespace, podNodePool.Pod)] = podNodePool.NodePool
+	}
+	return nodePools, nil
+}
+
+// ListPods returns the pods of the namespace which match the label selector
+// between startTime and endTime. namespace and selector are optional.
+func (r *podRepository) ListPods(ctx context.Context, namespace string, selector map[string]string, startTime, endTime string) ([]models.PodName, error) {
+	return r.source.QueryPods(ctx, namespace, selector, startTime, endTime)
+}
+
+// labelColumn returns the column of the kubernetes label, sanitized the same
+// way as kube-state-metrics does.
+func labelColumn(key string) string {
+	return "label_" + invalidLabelChars.ReplaceAllString(key, "_")
+}
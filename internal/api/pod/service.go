@@ -2,35 +2,63 @@ package pod
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/RichardKnop/machinery/v1/tasks"
 	"go.uber.org/zap"
 
 	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/forecasting"
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+	"rightsizing-api-server/internal/api/webhook"
 	"rightsizing-api-server/internal/cache"
-	grpcclient "rightsizing-api-server/internal/grpc"
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/pricing"
+	"rightsizing-api-server/internal/store"
 	"rightsizing-api-server/internal/worker"
-	pb "rightsizing-api-server/proto"
 )
 
 const (
-	taskName       = "pod_forecast"
-	overallInfoKey = "overallInfo"
+	taskName         = "pod_forecast"
+	backtestTaskName = "pod_forecast_backtest"
+	overallInfoKey   = "overallInfo"
+	// number of tasks of a batch sent at the same time
+	batchConcurrency = 10
+	// polling interval of the task state while streaming
+	watchInterval = time.Second
+)
+
+// scheduled tasks
+const (
+	RightsizingTaskName       = "pod_rightsizing"
+	NamespaceForecastTaskName = "pod_namespace_forecast"
+)
+
+// statuses of the usage, see GetStatus
+const (
+	StatusOptimized      = "optimized"
+	StatusUnderallocated = "underallocated"
+	StatusOverallocated  = "overallocated"
 )
 
 type podService struct {
-	cache      *cache.Cache
-	worker     *worker.Worker
-	client     *grpcclient.Client
-	repository PodRepository
-	logger     *zap.Logger
+	cache        *cache.Cache
+	worker       *worker.Worker
+	forecasters  *forecasting.Registry
+	recommenders *rightsizing.Registry
+	pricing      *pricing.Pricing
+	store        *store.Store
+	webhooks     webhook.Notifier
+	repository   PodRepository
+	logger       *zap.Logger
 }
 
 var _ PodService = (*podService)(nil)
@@ -38,18 +66,31 @@ var _ PodService = (*podService)(nil)
 func NewPodService(
 	cache *cache.Cache,
 	worker *worker.Worker,
-	client *grpcclient.Client,
+	forecasters *forecasting.Registry,
+	recommenders *rightsizing.Registry,
+	pricing *pricing.Pricing,
+	store *store.Store,
+	webhooks webhook.Notifier,
 	r PodRepository,
 	logger *zap.Logger) PodService {
 	s := &podService{
-		cache:      cache,
-		worker:     worker,
-		client:     client,
-		repository: r,
-		logger:     logger,
+		cache:        cache,
+		worker:       worker,
+		forecasters:  forecasters,
+		recommenders: recommenders,
+		pricing:      pricing,
+		store:        store,
+		webhooks:     webhooks,
+		repository:   r,
+		logger:       logger,
 	}
 
-	worker.RegisterTask(taskName, s.forecastTask)
+	worker.RegisterTasks(map[string]interface{}{
+		taskName:                  s.forecastTask,
+		RightsizingTaskName:       s.rightsizingTask,
+		NamespaceForecastTaskName: s.namespaceForecastTask,
+		backtestTaskName:          s.backtestTask,
+	})
 
 	return s
 }
@@ -58,11 +99,11 @@ func GetStatus(a, b float64) string {
 	const threshold = 0.2
 	eps := math.Abs(a-b) / b
 	if eps < threshold {
-		return "optimized"
+		return StatusOptimized
 	} else if a < b {
-		return "underallocated"
+		return StatusUnderallocated
 	}
-	return "overallocated"
+	return StatusOverallocated
 }
 
 func (ps *podService) GetClusterInfo() (interface{}, error) {
@@ -100,25 +141,29 @@ func (ps *podService) GetClusterInfo() (interface{}, error) {
 		return nil, commonerrors.NotFoundErr("pod", "all")
 	}
 
-	averageUsages := map[string]float64{
-		"cpu":    0,
-		"memory": 0,
-	}
-	resourceStatus := map[string]map[string]int{
-		"cpu": {
-			"optimized":      0,
-			"underallocated": 0,
-			"overallocated":  0,
-		},
-		"memory": {
-			"optimized":      0,
-			"underallocated": 0,
-			"overallocated":  0,
-		},
+	var (
+		metricNames    = ContainerMetricTables.GetMetricNames()
+		averageUsages  = make(map[string]float64, len(metricNames))
+		resourceStatus = make(map[string]map[string]int, len(metricNames))
+		summaries      = make(map[string]*resource.ResourceSummary, len(metricNames))
+		result         = make(map[string]map[string]float64, len(metricNames))
+	)
+	for _, name := range metricNames {
+		averageUsages[name] = 0
+		resourceStatus[name] = map[string]int{
+			StatusOptimized:      0,
+			StatusUnderallocated: 0,
+			StatusOverallocated:  0,
+		}
+		summaries[name] = &resource.ResourceSummary{}
+		result[name] = make(map[string]float64)
 	}
 
 	for _, pod := range pods {
 		for name, usage := range pod.Usages {
+			if _, exist := resourceStatus[name]; !exist {
+				continue
+			}
 			standard := usage.GetStandardQuota()
 			averageUsages[name] += usage.CurrentUsage
 			if standard != -1 {
@@ -132,9 +177,12 @@ func (ps *podService) GetClusterInfo() (interface{}, error) {
 		averageUsages[name] = usage / float64(len(pods))
 	}
 
-	result := map[string]map[string]float64{
-		"cpu":    make(map[string]float64),
-		"memory": make(map[string]float64),
+	for _, pod := range pods {
+		for name, summary := range pod.Summarize() {
+			if _, exist := summaries[name]; exist {
+				summaries[name].Merge(summary)
+			}
+		}
 	}
 
 	for resourceName, _ := range result {
@@ -142,6 +190,13 @@ func (ps *podService) GetClusterInfo() (interface{}, error) {
 		for status, count := range resourceStatus[resourceName] {
 			result[resourceName][status] = float64(count)
 		}
+		result[resourceName]["request"] = summaries[resourceName].Request
+		result[resourceName]["usage"] = summaries[resourceName].Usage
+		result[resourceName]["recommended"] = summaries[resourceName].Recommended
+		result[resourceName]["reclaimable"] = summaries[resourceName].Reclaimable
+		result[resourceName]["current_cost"] = summaries[resourceName].CurrentCost
+		result[resourceName]["recommended_cost"] = summaries[resourceName].RecommendedCost
+		result[resourceName]["savings"] = summaries[resourceName].Savings
 	}
 
 	return result, nil
@@ -150,9 +205,15 @@ func (ps *podService) GetClusterInfo() (interface{}, error) {
 func (ps *podService) GetAllPod(query query.Query) ([]*Pod, error) {
 	ps.logger.Debug("rightsizing pod",
 		zap.String("id", query.ID),
+		zap.String("recommender", query.Recommender),
 		zap.Time("start_time", query.StartTime),
 		zap.Time("end_time", query.EndTime))
 
+	recommender, err := ps.recommender(query)
+	if err != nil {
+		return nil, err
+	}
+
 	pods, err := ps.repository.GetAllPod(query)
 	if err != nil {
 		ps.logger.Error("failed to get pod from database", zap.Error(err))
@@ -163,10 +224,16 @@ func (ps *podService) GetAllPod(query query.Query) ([]*Pod, error) {
 		return nil, commonerrors.NotFoundErr("pod", "all")
 	}
 
+	if err := Rightsizing(query.Context(), recommender, ps.recommenders, pods...); err != nil {
+		return nil, err
+	}
 	for _, pod := range pods {
-		if err := pod.Rightsizing(ps.client); err != nil {
-			return nil, err
-		}
+		pod.Recommend()
+	}
+
+	if err := ps.applyCost(query.Context(), query, pods); err != nil {
+		ps.logger.Error("failed to get node pool from database", zap.Error(err))
+		return nil, err
 	}
 
 	sort.Slice(pods, func(i, j int) bool {
@@ -183,9 +250,15 @@ func (ps *podService) GetPod(query query.Query) (*Pod, error) {
 		zap.String("id", query.ID),
 		zap.String("namespace", query.Namespace),
 		zap.String("pod", query.Name),
+		zap.String("recommender", query.Recommender),
 		zap.Time("start_time", query.StartTime),
 		zap.Time("end_time", query.EndTime))
 
+	recommender, err := ps.recommender(query)
+	if err != nil {
+		return nil, err
+	}
+
 	pod, err := ps.repository.GetPod(query)
 	if err != nil {
 		ps.logger.Error("failed to get pod from database", zap.Error(err))
@@ -196,14 +269,11 @@ func (ps *podService) GetPod(query query.Query) (*Pod, error) {
 		return nil, commonerrors.NotFoundErr("pod", query.Name)
 	}
 
-	if err := pod.Rightsizing(ps.client); err != nil {
+	if err := Rightsizing(query.Context(), recommender, ps.recommenders, pod); err != nil {
 		return nil, err
 	}
 
-	pod.Usages = map[string]*resource.ResourceUsageInfo{
-		"cpu":    {ResourceName: "cpu"},
-		"memory": {ResourceName: "memory"},
-	}
+	pod.Usages = NewUsages()
 	for _, container := range pod.Containers {
 		for name, usage := range container.Usage {
 			pod.Usages[name].Request += usage.Request
@@ -212,24 +282,285 @@ func (ps *podService) GetPod(query query.Query) (*Pod, error) {
 			pod.Usages[name].OptimizedUsage += usage.OptimizedUsage
 		}
 	}
+	pod.Recommend()
+
+	if err := ps.applyCost(query.Context(), query, []*Pod{pod}); err != nil {
+		ps.logger.Error("failed to get node pool from database", zap.Error(err))
+		return nil, err
+	}
 	return pod, nil
 }
 
+// recommender returns the recommender of the query. In the predictive mode it
+// recommends for the forecast peak as well.
+func (ps *podService) recommender(query query.Query) (rightsizing.Recommender, error) {
+	recommender, err := ps.recommenders.Get(query.Recommender)
+	if err != nil || !query.Predictive {
+		return recommender, err
+	}
+
+	options, err := ps.forecasters.Resolve(query.Forecast)
+	if err != nil {
+		return nil, err
+	}
+	forecaster, err := ps.forecasters.Get(options.Model)
+	if err != nil {
+		return nil, err
+	}
+	return forecasting.NewPredictiveRecommender(recommender, forecaster, options, ps.logger), nil
+}
+
+func (ps *podService) applyCost(ctx context.Context, query query.Query, pods []*Pod) error {
+	var nodePools map[string]string
+	if ps.pricing.UseNodePools() {
+		var err error
+		nodePools, err = ps.repository.GetNodePools(ctx, query.Namespace, ps.pricing.NodePoolLabel,
+			query.StartTime.Format("2006-01-02T15:04:05"),
+			query.EndTime.Format("2006-01-02T15:04:05"))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pod := range pods {
+		pod.NodePool = NodePoolOf(nodePools, pod.Namespace, pod.Name)
+		pod.ApplyCost(ps.pricing)
+	}
+	return nil
+}
+
+// saveRecommendations records the recommendations in the results store.
+// Failures are only logged so that they do not fail the request.
+func (ps *podService) saveRecommendations(ctx context.Context, query query.Query, recommender string, pods []*Pod) {
+	var records []*models.RecommendationResult
+	for _, pod := range pods {
+		records = append(records, pod.RecommendationResults(recommender, query.StartTime, query.EndTime)...)
+	}
+	if err := ps.store.SaveRecommendations(ctx, records); err != nil {
+		ps.logger.Error("failed to save recommendations", zap.Error(err))
+	}
+}
+
+// trackStatus records the status of every container and notifies the
+// containers which became underallocated. Failures are only logged.
+func (ps *podService) trackStatus(ctx context.Context, namespace string, pods []*Pod) {
+	previous, err := ps.store.GetContainerStatuses(ctx, models.ObjectTypePod, namespace)
+	if err != nil {
+		ps.logger.Error("failed to get container statuses", zap.Error(err))
+		return
+	}
+
+	var (
+		now     = time.Now()
+		changed []*models.ContainerStatus
+	)
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			for name, usage := range container.Usage {
+				standard := usage.GetStandardQuota()
+				if standard == -1 {
+					continue
+				}
+				record := &models.ContainerStatus{
+					ObjectType: models.ObjectTypePod,
+					Namespace:  pod.Namespace,
+					Name:       pod.Name,
+					Container:  container.Name,
+					Resource:   name,
+					Status:     GetStatus(usage.CurrentUsage, standard),
+					UpdatedAt:  now,
+				}
+				last, exist := previous[record.Key()]
+				if exist && last == record.Status {
+					continue
+				}
+				changed = append(changed, record)
+
+				// the first observation is only recorded
+				if exist && last != StatusUnderallocated && record.Status == StatusUnderallocated {
+					ps.webhooks.Notify(webhook.EventUnderallocated, &StatusChange{
+						Namespace:      pod.Namespace,
+						Pod:            pod.Name,
+						Container:      container.Name,
+						Resource:       name,
+						PreviousStatus: last,
+						Status:         record.Status,
+						CurrentUsage:   usage.CurrentUsage,
+						Request:        usage.Request,
+						Limit:          usage.Limit,
+						Recommendation: usage.Recommendation,
+					})
+				}
+			}
+		}
+	}
+
+	if err := ps.store.SaveContainerStatuses(ctx, changed); err != nil {
+		ps.logger.Error("failed to save container statuses", zap.Error(err))
+	}
+}
+
+func (ps *podService) GetRecommendationHistory(query query.Query) (*RecommendationHistory, error) {
+	ps.logger.Debug("recommendation history",
+		zap.String("id", query.ID),
+		zap.String("namespace", query.Namespace),
+		zap.String("pod", query.Name),
+		zap.String("recommender", query.Recommender),
+		zap.Time("start_time", query.StartTime),
+		zap.Time("end_time", query.EndTime))
+
+	records, err := ps.store.ListRecommendations(query.Context(), models.ObjectTypePod,
+		query.Namespace, query.Name, query.Recommender, query.StartTime, query.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &RecommendationHistory{
+		Namespace:  query.Namespace,
+		Name:       query.Name,
+		Containers: make(map[string]map[string][]*resource.RecommendationPoint),
+	}
+	for _, record := range records {
+		if _, exist := history.Containers[record.Container]; !exist {
+			history.Containers[record.Container] = make(map[string][]*resource.RecommendationPoint)
+		}
+		history.Containers[record.Container][record.Resource] = append(
+			history.Containers[record.Container][record.Resource],
+			resource.NewRecommendationPoint(record))
+	}
+	return history, nil
+}
+
+// GetLatestRecommendations returns the recommendations recorded most recently,
+// e.g. by the scheduled rightsizing, without analyzing the usage again.
+func (ps *podService) GetLatestRecommendations(query query.Query) ([]*LatestRecommendation, error) {
+	records, err := ps.store.LatestRecommendations(query.Context(), models.ObjectTypePod,
+		query.Namespace, query.Name, query.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		podMap = make(map[string]*LatestRecommendation)
+		pods   []*LatestRecommendation
+	)
+	for _, record := range records {
+		name := uniqueName(record.Namespace, record.Name)
+		if _, exist := podMap[name]; !exist {
+			podMap[name] = &LatestRecommendation{
+				Namespace:  record.Namespace,
+				Name:       record.Name,
+				Containers: make(map[string]map[string]*resource.RecommendationPoint),
+			}
+			pods = append(pods, podMap[name])
+		}
+		pod := podMap[name]
+		if _, exist := pod.Containers[record.Container]; !exist {
+			pod.Containers[record.Container] = make(map[string]*resource.RecommendationPoint)
+		}
+		pod.Containers[record.Container][record.Resource] = resource.NewRecommendationPoint(record)
+	}
+
+	if pods == nil {
+		return nil, commonerrors.NotFoundErr("recommendation", query.Name)
+	}
+	return pods, nil
+}
+
+// rightsizingTask rightsizes every pod over the window so that the
+// recommendations are recorded in the results store, which makes up the
+// recommendation history, and the containers which became underallocated are
+// notified.
+func (ps *podService) rightsizingTask(ctx context.Context, window string) error {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return err
+	}
+
+	query := query.Query{
+		StartTime: time.Now().Add(-duration),
+		EndTime:   time.Now(),
+	}.WithContext(ctx)
+	recommender, err := ps.recommenders.Get(query.Recommender)
+	if err != nil {
+		return err
+	}
+
+	pods, err := ps.GetAllPod(query)
+	if err != nil {
+		return err
+	}
+	ps.saveRecommendations(ctx, query, recommender.Name(), pods)
+	ps.trackStatus(ctx, query.Namespace, pods)
+	ps.logger.Info("scheduled rightsizing", zap.Int("pods", len(pods)))
+	return nil
+}
+
+// namespaceForecastTask sends a forecast task for every pod of the namespace
+// which has usage in the window.
+func (ps *podService) namespaceForecastTask(ctx context.Context, namespace, window string) error {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return err
+	}
+
+	query := query.Query{
+		Namespace: namespace,
+		StartTime: time.Now().Add(-duration),
+		EndTime:   time.Now(),
+	}.WithContext(ctx)
+	pods, err := ps.repository.GetAllPod(query)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods {
+		query.Name = pod.Name
+		if _, err := ps.Forecast(query); err != nil {
+			ps.logger.Error("failed to send forecast",
+				zap.String("namespace", pod.Namespace),
+				zap.String("pod", pod.Name),
+				zap.Error(err))
+		}
+	}
+	ps.logger.Info("scheduled forecast", zap.String("namespace", namespace), zap.Int("pods", len(pods)))
+	return nil
+}
+
 func uniqueName(namespace, name string) string {
 	return fmt.Sprintf("pod:%s-%s", namespace, name)
 }
 
+// forecastName is the cache key of the forecast of the pod with the options,
+// so that forecasts with different options are not shared.
+func forecastName(namespace, name string, options resource.ForecastOptions) string {
+	return uniqueName(namespace, name) + ":" + options.Key()
+}
+
 func (ps *podService) Forecast(query query.Query) (string, error) {
-	var (
-		namespace = query.Namespace
-		name      = query.Name
-		startTime = query.StartTime.Format("2006-01-02T15:04:05")
-		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
-	)
+	options, err := ps.forecasters.Resolve(query.Forecast)
+	if err != nil {
+		return "", err
+	}
+	task := forecastSignature(query.Namespace, query.Name, query.StartTime, query.EndTime, options)
 
-	task := &tasks.Signature{
+	taskState, err := ps.worker.SendTaskWithContext(context.Background(), task, forecastName(query.Namespace, query.Name, options))
+	if err != nil {
+		return "", err
+	}
+
+	ps.createForecast(query.Namespace, query.Name, query, options, taskState, "")
+	return taskState.TaskUUID, nil
+}
+
+func forecastSignature(namespace, name string, start, end time.Time, options resource.ForecastOptions) *tasks.Signature {
+	return &tasks.Signature{
 		Name: taskName,
 		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: options.Model,
+			},
 			{
 				Type:  "string",
 				Value: namespace,
@@ -240,85 +571,523 @@ func (ps *podService) Forecast(query query.Query) (string, error) {
 			},
 			{
 				Type:  "string",
-				Value: startTime,
+				Value: start.Format("2006-01-02T15:04:05"),
+			},
+			{
+				Type:  "string",
+				Value: end.Format("2006-01-02T15:04:05"),
+			},
+			{
+				Type:  "string",
+				Value: options.Horizon.String(),
+			},
+			{
+				Type:  "string",
+				Value: options.Step.String(),
+			},
+			{
+				Type:  "float64",
+				Value: options.IntervalWidth,
 			},
 			{
 				Type:  "string",
-				Value: endTime,
+				Value: strings.Join(options.Seasonality, ","),
 			},
 		},
 		RetryCount: 1,
 	}
+}
+
+// createForecast records the sent forecast task in the results store.
+func (ps *podService) createForecast(namespace, name string, query query.Query, options resource.ForecastOptions, taskState *tasks.TaskState, batchID string) {
+	parameters, err := json.Marshal(options.Parameters())
+	if err != nil {
+		ps.logger.Error("failed to encode forecast parameters", zap.Error(err))
+	}
+
+	err = ps.store.CreateForecast(context.Background(), &models.ForecastResult{
+		TaskUUID:   taskState.TaskUUID,
+		ObjectType: models.ObjectTypePod,
+		Namespace:  namespace,
+		Name:       name,
+		Model:      options.Model,
+		StartTime:  query.StartTime,
+		EndTime:    query.EndTime,
+		Parameters: string(parameters),
+		OptionsKey: options.Key(),
+		Status:     taskState.State,
+		BatchID:    batchID,
+	})
+	if err != nil {
+		ps.logger.Error("failed to save forecast", zap.Error(err))
+	}
+}
 
-	taskState, err := ps.worker.SendTaskWithContext(context.Background(), task, uniqueName(namespace, name))
+// ForecastBatch sends a forecast task for every selected pod as a machinery
+// group and returns the group uuid as the batch id.
+func (ps *podService) ForecastBatch(query query.Query, request *BatchForecastRequest) (string, error) {
+	items, err := ps.batchItems(query, request)
 	if err != nil {
 		return "", err
 	}
-	return taskState.TaskUUID, nil
+	if len(items) == 0 {
+		return "", commonerrors.NotFoundErr("pod", request.Selector)
+	}
+
+	options, err := ps.forecasters.Resolve(query.Forecast)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		signatures = make([]*tasks.Signature, len(items))
+		names      = make([]string, len(items))
+	)
+	for i, item := range items {
+		signatures[i] = forecastSignature(item.Namespace, item.Name, query.StartTime, query.EndTime, options)
+		names[i] = forecastName(item.Namespace, item.Name, options)
+	}
+	group, err := tasks.NewGroup(signatures...)
+	if err != nil {
+		return "", err
+	}
+
+	taskStates, err := ps.worker.SendGroupWithContext(context.Background(), group, names, batchConcurrency)
+	if err != nil {
+		return "", err
+	}
+	for i, taskState := range taskStates {
+		ps.createForecast(items[i].Namespace, items[i].Name, query, options, taskState, group.GroupUUID)
+	}
+
+	ps.logger.Debug("batch forecast", zap.String("batch", group.GroupUUID), zap.Int("pods", len(items)))
+	return group.GroupUUID, nil
+}
+
+// batchItems returns the listed pods, or the pods matching the namespace and
+// the label selector.
+func (ps *podService) batchItems(query query.Query, request *BatchForecastRequest) ([]BatchForecastItem, error) {
+	if len(request.Items) > 0 {
+		for _, item := range request.Items {
+			if item.Namespace == "" || item.Name == "" {
+				return nil, errors.New("namespace and name of every item must be present")
+			}
+		}
+		return request.Items, nil
+	}
+
+	if request.Namespace == "" && request.Selector == "" {
+		return nil, errors.New("items, namespace or selector must be present")
+	}
+	selector, err := parseSelector(request.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := ps.repository.ListPods(context.Background(), request.Namespace, selector,
+		query.StartTime.Format("2006-01-02T15:04:05"),
+		query.EndTime.Format("2006-01-02T15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]BatchForecastItem, len(pods))
+	for i, pod := range pods {
+		items[i] = BatchForecastItem{
+			Namespace: pod.Namespace,
+			Name:      pod.Pod,
+		}
+	}
+	return items, nil
+}
+
+// GetForecastBatch returns the status of every forecast of the batch and the
+// results of the finished ones.
+func (ps *podService) GetForecastBatch(batchID string) (*ForecastBatch, error) {
+	records, err := ps.store.ListForecastBatch(context.Background(), batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ForecastBatch{
+		ID:      batchID,
+		Total:   len(records),
+		Items:   make([]*ForecastBatchItem, 0, len(records)),
+		Results: make(map[string]map[string]*resource.ForecastUsage),
+	}
+	for _, record := range records {
+		item := &ForecastBatchItem{
+			Namespace: record.Namespace,
+			Name:      record.Name,
+			UUID:      record.TaskUUID,
+			Error:     record.Error,
+		}
+		batch.Items = append(batch.Items, item)
+
+		item.Status, err = ps.getForecastStatus(record.TaskUUID)
+		if err != nil {
+			item.Status = record.Status
+		}
+
+		switch item.Status {
+		case tasks.StateSuccess:
+			batch.Succeeded++
+			result, err := ps.getForecastResult(record.TaskUUID)
+			if err != nil {
+				ps.logger.Error("failed to get forecast result", zap.String("uuid", record.TaskUUID), zap.Error(err))
+				continue
+			}
+			batch.Results[record.Namespace+"/"+record.Name] = result
+		case tasks.StateFailure:
+			batch.Failed++
+		}
+	}
+	batch.Progress = float64(batch.Succeeded+batch.Failed) / float64(batch.Total)
+	return batch, nil
+}
+
+// parseSelector parses an equality based label selector such as
+// app=web,tier=frontend.
+func parseSelector(selector string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if strings.TrimSpace(selector) == "" {
+		return labels, nil
+	}
+
+	for _, requirement := range strings.Split(selector, ",") {
+		parts := strings.SplitN(strings.Replace(requirement, "==", "=", 1), "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid label selector %q", requirement)
+		}
+		labels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return labels, nil
 }
 
-func (ps *podService) forecastTask(namespace, name, startTime, endTime string) (string, error) {
-	containers, err := ps.repository.Query(context.Background(), namespace, name, startTime, endTime)
+func (ps *podService) forecastTask(ctx context.Context, model, namespace, name, startTime, endTime, horizon, step string, intervalWidth float64, seasonality string) (string, error) {
+	options, err := resource.ParseForecastOptions(model, horizon, step, intervalWidth, seasonality)
+	if err != nil {
+		return "", err
+	}
+
+	forecastUsages, err := ps.forecast(ctx, namespace, name, startTime, endTime, options)
+	if err := ps.store.FinishForecastTask(ctx, forecastUsages, err); err != nil {
+		ps.logger.Error("failed to save forecast result", zap.Error(err))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	encodedUsage, err := resource.EncodeForecastUsage(forecastUsages)
 	if err != nil {
 		return "", err
 	}
 
+	return encodedUsage, nil
+}
+
+func (ps *podService) forecast(ctx context.Context, namespace, name, startTime, endTime string, options resource.ForecastOptions) ([]*resource.ForecastUsage, error) {
+	forecaster, err := ps.forecasters.Get(options.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := ps.repository.Query(ctx, namespace, name, startTime, endTime, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(containers) == 0 {
-		return "", commonerrors.NotFoundErr("pod", name)
+		return nil, commonerrors.NotFoundErr("pod", name)
+	}
+
+	var done, total int
+	for _, container := range containers {
+		total += len(container.Usage)
 	}
 
 	var forecastUsages []*resource.ForecastUsage
 	for _, container := range containers {
-		forecastUsage := &resource.ForecastUsage{
-			Name:  container.Name,
-			Usage: make(map[string][]*pb.TimeSeriesDatapoint),
-		}
-		for _, usage := range container.Usage {
-			res, err := ps.client.Forecast(context.Background(), usage.Usage)
+		forecastUsage := resource.NewForecastUsage(container.Name)
+		for name, usage := range container.Usage {
+			results, err := forecaster.Forecast(ctx, usage.Usage, options)
 			if err != nil {
 				ps.logger.Error("failed while forecast", zap.Error(err))
-				return "", err
-			}
-			for _, result := range res.Result {
-				forecastUsage.Usage[result.Name] = result.Data
+				return nil, err
 			}
+			forecastUsage.Add(name, results)
+			forecastUsage.Alerts = append(forecastUsage.Alerts, resource.NewCapacityAlerts(name,
+				forecastUsage.Resources[name][resource.ForecastUpperBound], usage.Request, usage.Limit)...)
+			done++
+			ps.worker.Progress(ctx, container.Name, name, done, total)
 		}
 		forecastUsages = append(forecastUsages, forecastUsage)
 	}
-	encodedUsage, err := resource.EncodeForecastUsage(forecastUsages)
+	return forecastUsages, nil
+}
+
+// Backtest sends a task forecasting the last holdout of the usage of the pod
+// from the usage before it, and returns its uuid.
+func (ps *podService) Backtest(query query.Query, holdout time.Duration) (string, error) {
+	options := query.Forecast
+	options.Horizon = holdout
+	options, err := ps.forecasters.Resolve(options)
 	if err != nil {
 		return "", err
 	}
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	if holdout >= query.EndTime.Sub(query.StartTime) {
+		return "", fmt.Errorf("holdout must be shorter than the usage window")
+	}
 
-	return encodedUsage, nil
+	// same arguments as the forecast, with the holdout as the horizon
+	task := forecastSignature(query.Namespace, query.Name, query.StartTime, query.EndTime, options)
+	task.Name = backtestTaskName
+
+	taskState, err := ps.worker.SendTaskWithContext(context.Background(), task, "backtest:"+forecastName(query.Namespace, query.Name, options))
+	if err != nil {
+		return "", err
+	}
+	return taskState.TaskUUID, nil
 }
 
-func (ps *podService) GetForecastStatus(namespace, name string) (string, error) {
-	uuid, err := ps.worker.GetUUID(uniqueName(namespace, name))
+func (ps *podService) backtestTask(ctx context.Context, model, namespace, name, startTime, endTime, holdout, step string, intervalWidth float64, seasonality string) (string, error) {
+	options, err := resource.ParseForecastOptions(model, holdout, step, intervalWidth, seasonality)
 	if err != nil {
 		return "", err
 	}
-	return ps.getForecastStatus(uuid)
+
+	backtest, err := ps.backtest(ctx, namespace, name, startTime, endTime, options)
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(backtest)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
 }
 
-func (ps *podService) GetForecastStatusByID(uuid string) (string, error) {
+// backtest forecasts the last horizon of every usage series from the usage
+// before it and compares the forecast with the held out usage.
+func (ps *podService) backtest(ctx context.Context, namespace, name, startTime, endTime string, options resource.ForecastOptions) (*Backtest, error) {
+	forecaster, err := ps.forecasters.Get(options.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := ps.repository.Query(ctx, namespace, name, startTime, endTime, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(containers) == 0 {
+		return nil, commonerrors.NotFoundErr("pod", name)
+	}
+
+	var done, total int
+	for _, container := range containers {
+		total += len(container.Usage)
+	}
+
+	backtest := &Backtest{
+		Namespace:  namespace,
+		Name:       name,
+		Model:      options.Model,
+		Holdout:    options.Horizon.String(),
+		Containers: make(map[string]map[string]*resource.Accuracy),
+	}
+	for _, container := range containers {
+		accuracies := make(map[string]*resource.Accuracy)
+		for name, usage := range container.Usage {
+			accuracy, err := backtestUsage(ctx, forecaster, usage.Usage, options)
+			if err != nil {
+				ps.logger.Error("failed while backtest", zap.Error(err))
+				return nil, err
+			}
+			accuracies[name] = accuracy
+			done++
+			ps.worker.Progress(ctx, container.Name, name, done, total)
+		}
+		backtest.Containers[container.Name] = accuracies
+	}
+	return backtest, nil
+}
+
+// backtestUsage forecasts the last horizon of the usage from the usage before
+// it. The accuracy has no samples if there is not enough usage before.
+func backtestUsage(ctx context.Context, forecaster forecasting.Forecaster, usage resource.TimeseriesData, options resource.ForecastOptions) (*resource.Accuracy, error) {
+	if len(usage) == 0 {
+		return &resource.Accuracy{}, nil
+	}
+
+	cutoff := usage[len(usage)-1].Time - int64(options.Horizon/time.Second)
+	train, test := usage.Split(cutoff)
+	if len(train) < 2 {
+		return &resource.Accuracy{}, nil
+	}
+
+	options.Origin = time.Unix(cutoff, 0)
+	results, err := forecaster.Forecast(ctx, train, options)
+	if err != nil {
+		return nil, err
+	}
+	return resource.NewAccuracy(test, results, options.Step), nil
+}
+
+// GetBacktest returns the status of the backtest task and its result once it
+// succeeded.
+func (ps *podService) GetBacktest(uuid string) (string, *Backtest, error) {
 	status, err := ps.worker.GetTaskStatus(uuid)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != tasks.StateSuccess {
+		return status, nil, nil
+	}
+
+	results, err := ps.worker.GetTaskResult(uuid)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(results) == 0 {
+		return "", nil, commonerrors.NotFoundErr("result not found", uuid)
+	}
+
+	backtest := &Backtest{}
+	if err := json.Unmarshal([]byte(results[0].Interface().(string)), backtest); err != nil {
+		return "", nil, err
+	}
+	return status, backtest, nil
+}
+
+// WatchForecast sends the state transitions and the per container progress of
+// the forecast task, and finally its result.
+func (ps *podService) WatchForecast(ctx context.Context, uuid string, send func(*worker.Event) error) error {
+	status, err := ps.getForecastStatus(uuid)
+	if err != nil {
+		return err
+	}
+	if !worker.IsTerminal(status) {
+		if err := ps.worker.Watch(ctx, uuid, watchInterval, send); err != nil {
+			return err
+		}
+	}
+
+	event := &worker.Event{
+		Type: worker.EventResult,
+		UUID: uuid,
+		Time: time.Now(),
+	}
+	event.State, err = ps.getForecastStatus(uuid)
+	if err != nil {
+		return err
+	}
+	if event.State == tasks.StateSuccess {
+		if event.Result, err = ps.getForecastResult(uuid); err != nil {
+			return err
+		}
+	} else if record, err := ps.store.GetForecast(ctx, uuid); err == nil {
+		event.Error = record.Error
+	}
+	return send(event)
+}
+
+// GetAtRisk returns the capacity alerts of the latest forecasts of the pods
+// with the default options, sorted by time to exhaustion. Forecasts created
+// before the start time and the alerts which have already passed are ignored.
+func (ps *podService) GetAtRisk(query query.Query) ([]*AtRiskContainer, error) {
+	options, err := ps.forecasters.Resolve(resource.ForecastOptions{})
+	if err != nil {
+		return nil, err
+	}
+	records, err := ps.store.LatestForecasts(query.Context(), models.ObjectTypePod, query.Namespace, options.Key(), query.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now        = time.Now().Unix()
+		containers = make([]*AtRiskContainer, 0)
+	)
+	for _, record := range records {
+		run, err := resource.NewForecastRun(record)
+		if err != nil {
+			ps.logger.Error("failed to decode forecast", zap.String("uuid", record.TaskUUID), zap.Error(err))
+			continue
+		}
+		for _, usage := range run.Result {
+			for _, alert := range usage.Alerts {
+				if alert.Time < now {
+					continue
+				}
+				containers = append(containers, &AtRiskContainer{
+					Namespace:        record.Namespace,
+					Pod:              record.Name,
+					Container:        usage.Name,
+					ForecastUUID:     record.TaskUUID,
+					CapacityAlert:    alert,
+					TimeToExhaustion: alert.Time - now,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].Time < containers[j].Time
+	})
+	return containers, nil
+}
+
+// getUUID returns the uuid of the latest forecast of the pod with the options.
+// The latest forecast with the same options in the results store is used when
+// the task is no longer cached.
+func (ps *podService) getUUID(namespace, name string, options resource.ForecastOptions) (string, error) {
+	options, err := ps.forecasters.Resolve(options)
 	if err != nil {
 		return "", err
 	}
-	return status, nil
+	uuid, err := ps.worker.GetUUID(forecastName(namespace, name, options))
+	if err == nil {
+		return uuid, nil
+	}
+	record, storeErr := ps.store.GetLatestForecast(context.Background(), models.ObjectTypePod, namespace, name, options.Key())
+	if storeErr != nil {
+		return "", err
+	}
+	return record.TaskUUID, nil
+}
+
+func (ps *podService) GetForecastStatus(namespace, name string, options resource.ForecastOptions) (string, error) {
+	uuid, err := ps.getUUID(namespace, name, options)
+	if err != nil {
+		return "", err
+	}
+	return ps.getForecastStatus(uuid)
+}
+
+func (ps *podService) GetForecastStatusByID(uuid string) (string, error) {
+	return ps.getForecastStatus(uuid)
 }
 
 func (ps *podService) getForecastStatus(uuid string) (string, error) {
 	status, err := ps.worker.GetTaskStatus(uuid)
 	if err != nil {
-		return "", err
+		// result backend expired, use the recorded status
+		record, storeErr := ps.store.GetForecast(context.Background(), uuid)
+		if storeErr != nil {
+			return "", err
+		}
+		return record.Status, nil
 	}
 	return status, nil
 }
 
-func (ps *podService) GetForecastResult(namespace, name string) (map[string]*resource.ForecastUsage, error) {
-	uuid, err := ps.worker.GetUUID(uniqueName(namespace, name))
+func (ps *podService) GetForecastResult(namespace, name string, options resource.ForecastOptions) (map[string]*resource.ForecastUsage, error) {
+	uuid, err := ps.getUUID(namespace, name, options)
 	if err != nil {
 		return nil, err
 	}
@@ -329,6 +1098,23 @@ func (ps *podService) GetForecastResultByID(uuid string) (map[string]*resource.F
 	return ps.getForecastResult(uuid)
 }
 
+func (ps *podService) GetForecastHistory(namespace, name string, limit int) ([]*resource.ForecastRun, error) {
+	records, err := ps.store.ListForecasts(context.Background(), models.ObjectTypePod, namespace, name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*resource.ForecastRun, 0, len(records))
+	for _, record := range records {
+		run, err := resource.NewForecastRun(record)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
 func (ps *podService) getForecastResult(uuid string) (map[string]*resource.ForecastUsage, error) {
 	results, err := ps.worker.GetTaskResult(uuid)
 	if errors.Is(err, tasks.ErrTaskReturnsNoValue) {
@@ -336,7 +1122,16 @@ func (ps *podService) getForecastResult(uuid string) (map[string]*resource.Forec
 	}
 
 	if err != nil {
-		return nil, err
+		// result backend expired, use the recorded result
+		record, storeErr := ps.store.GetForecast(context.Background(), uuid)
+		if storeErr != nil {
+			return nil, err
+		}
+		run, err := resource.NewForecastRun(record)
+		if err != nil {
+			return nil, err
+		}
+		return run.UsageMap(), nil
 	}
 
 	usages := make(map[string]*resource.ForecastUsage)
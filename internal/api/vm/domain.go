@@ -2,23 +2,36 @@ package vm
 
 import (
 	"context"
+	"time"
 
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/worker"
 )
 
 type VMRepository interface {
 	GetVm(query query.Query) (*Vm, error)
-	Query(ctx context.Context, name, startTime, endTime string) ([]*Vm, error)
+	Query(ctx context.Context, name, startTime, endTime string, step time.Duration) ([]*Vm, error)
+}
+
+// Datasource reads the vm metrics from a metrics backend. The usage is
+// returned per metric in the order of VmMetricTables, every step or the
+// finest resolution of the backend if step is shorter.
+type Datasource interface {
+	QueryUsage(ctx context.Context, name, startTime, endTime string, step time.Duration) ([][]models.Vm, error)
 }
 
 type VMService interface {
 	GetForecastStatusByID(uuid string) (string, error)
 	GetForecastResultByID(uuid string) (map[string]*resource.ForecastUsage, error)
-	GetForecastStatus(name string) (string, error)
-	GetForecastResult(name string) (map[string]*resource.ForecastUsage, error)
+	GetForecastStatus(name string, options resource.ForecastOptions) (string, error)
+	GetForecastResult(name string, options resource.ForecastOptions) (map[string]*resource.ForecastUsage, error)
+	GetForecastHistory(name string, limit int) ([]*resource.ForecastRun, error)
 	Forecast(query query.Query) (string, error)
 	GetVm(query query.Query) (*Vm, error)
+	GetLatestRecommendations(query query.Query) ([]*LatestRecommendation, error)
+	WatchForecast(ctx context.Context, uuid string, send func(*worker.Event) error) error
 }
 
 type Vm struct {
@@ -27,6 +40,43 @@ type Vm struct {
 	Usage map[string]*resource.ResourceUsageInfo `json:"usages"`
 }
 
+// LatestRecommendation is the most recently recorded recommendation of every
+// resource of a vm.
+type LatestRecommendation struct {
+	Name      string                                   `json:"name"`
+	Resources map[string]*resource.RecommendationPoint `json:"resources"`
+}
+
 func (v Vm) UniqueName() string {
 	return "vm/" + v.Name
 }
+
+// RecommendationResults converts the recommendations of the vm to records of
+// the results store.
+func (v Vm) RecommendationResults(recommender string, startTime, endTime time.Time) []*models.RecommendationResult {
+	var (
+		now     = time.Now()
+		records []*models.RecommendationResult
+	)
+	for name, usage := range v.Usage {
+		if usage.Recommendation == nil {
+			continue
+		}
+		records = append(records, &models.RecommendationResult{
+			CreatedAt:      now,
+			ObjectType:     models.ObjectTypeVm,
+			Name:           v.Name,
+			Resource:       name,
+			Recommender:    recommender,
+			StartTime:      startTime,
+			EndTime:        endTime,
+			Samples:        len(usage.Usage),
+			OptimizedUsage: usage.OptimizedUsage,
+			Request:        usage.Recommendation.Request,
+			Limit:          usage.Recommendation.Limit,
+			CurrentRequest: usage.Recommendation.CurrentRequest,
+			CurrentLimit:   usage.Recommendation.CurrentLimit,
+		})
+	}
+	return records
+}
@@ -1,11 +1,16 @@
 package vm
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 
 	"rightsizing-api-server/internal/api/common/query"
 	_ "rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/stream"
+	"rightsizing-api-server/internal/worker"
 )
 
 type VMHandler struct {
@@ -22,6 +27,7 @@ func VMRouter(route fiber.Router, vs VMService, logger *zap.Logger) {
 	// route.Use(auth.JWTMiddleware(), auth.GetDataFromJWT)
 	route.Get("/vms/resource-quota", handler.getAllQuota)
 	route.Get("/vms/:name/resource-quota", handler.getQuota)
+	route.Get("/vms/recommendations/latest", handler.getLatestRecommendations)
 
 	// resource usage history
 	route.Get("/vms/:name", handler.getHistory)
@@ -30,8 +36,10 @@ func VMRouter(route fiber.Router, vs VMService, logger *zap.Logger) {
 	route.Get("/vms/:name/forecast", handler.forecast)
 	route.Get("/vms/:name/forecast/status", handler.getForecastStatus)
 	route.Get("/vms/:name/forecast/result", handler.getForecastResult)
+	route.Get("/vms/:name/forecast/history", handler.getForecastHistory)
 	route.Get("/vms/:uuid/forecast/status", handler.getForecastStatusByID)
 	route.Get("/vms/:uuid/forecast/result", handler.getForecastResultByID)
+	route.Get("/vms/:uuid/forecast/events", handler.streamForecast)
 }
 
 // @Summary Get all vm resource quota
@@ -62,12 +70,19 @@ func (h *VMHandler) getQuota(c *fiber.Ctx) error {
 }
 
 // @Summary Get vm usage history and optimization usage
-// @Description Get all resource usage history and optimization usage value
+// @Description Get all resource usage history and optimization usage value. The recommendations are not recorded, only the scheduled rightsizing records the recommendation history
 // @Accept  json
 // @Produce json
 // @Param name 	path  string  true  "name of the vm"
 // @Param start query string  false "start time"
 // @Param end   query string  false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Param mode        query string false "historical (default), or predictive to recommend at least the forecast yhat_upper"
+// @Param model       query string false "forecast model of the predictive mode (prophet, holt-winters, seasonal-naive)"
+// @Param horizon     query string false "forecast window of the predictive mode (e.g. 24h, default 6h)"
+// @Param step        query string false "interval between usage datapoints (e.g. 1h, default the finest)"
+// @Param resolution  query string false "interval between usage datapoints instead of step, as step is the forecast interval in the predictive mode"
+// @Param max_points  query int    false "the maximum number of usage datapoints of a series"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -77,6 +92,7 @@ func (h *VMHandler) getHistory(c *fiber.Ctx) error {
 	query, err := query.ParseAndValidate(c)
 	if err != nil {
 		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
 	}
 
 	vm, err := h.vs.GetVm(query)
@@ -90,11 +106,41 @@ func (h *VMHandler) getHistory(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(vm)
 }
 
+// @Summary Get the latest vm recommendations
+// @Description Get the recommendations recorded most recently by the scheduled rightsizing. The recommendations of the requests are not recorded
+// @Accept  json
+// @Produce json
+// @Param name  query string false "name of the vm"
+// @Param start query string false "only the recommendations recorded after start"
+// @Success 200 {object} []LatestRecommendation
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/vms/recommendations/latest [get]
+func (h *VMHandler) getLatestRecommendations(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	recommendations, err := h.vs.GetLatestRecommendations(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(recommendations)
+}
+
 // @Summary Post vm forecast task
 // @Description Create forecast task and result task UUID
 // @Accept  json
 // @Produce json
 // @Param name      path string true "the name of vm"
+// @Param model          query string false "forecast model (prophet, holt-winters, seasonal-naive)"
+// @Param horizon        query string false "forecast window from now (e.g. 24h, default 6h)"
+// @Param step           query string false "interval between forecast datapoints (e.g. 10m, default 5m)"
+// @Param interval_width query number false "width of the uncertainty interval (default 0.1)"
+// @Param seasonality    query string false "seasonalities to fit (daily, weekly, yearly), automatic if empty"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -123,6 +169,11 @@ func (h *VMHandler) forecast(c *fiber.Ctx) error {
 // @Accept  json
 // @Produce json
 // @Param name path string true "the name of vm"
+// @Param model          query string false "forecast model of the forecast"
+// @Param horizon        query string false "forecast window of the forecast"
+// @Param step           query string false "interval between forecast datapoints of the forecast"
+// @Param interval_width query number false "width of the uncertainty interval of the forecast"
+// @Param seasonality    query string false "seasonalities of the forecast"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -134,7 +185,13 @@ func (h *VMHandler) getForecastStatus(c *fiber.Ctx) error {
 		name = c.Params("name")
 	)
 
-	id, err := h.vs.GetForecastStatus(name)
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	id, err = h.vs.GetForecastStatus(name, query.Forecast)
 	if err != nil {
 		h.logger.Debug("failed to get status", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
@@ -150,6 +207,11 @@ func (h *VMHandler) getForecastStatus(c *fiber.Ctx) error {
 // @Accept  json
 // @Produce json
 // @Param name path string true "the name of vm"
+// @Param model          query string false "forecast model of the forecast"
+// @Param horizon        query string false "forecast window of the forecast"
+// @Param step           query string false "interval between forecast datapoints of the forecast"
+// @Param interval_width query number false "width of the uncertainty interval of the forecast"
+// @Param seasonality    query string false "seasonalities of the forecast"
 // @Success 200 {object} object
 // @Failure 400 {object} nil
 // @Failure 404 {object} nil
@@ -161,7 +223,13 @@ func (h *VMHandler) getForecastResult(c *fiber.Ctx) error {
 		name = c.Params("name")
 	)
 
-	forecastUsage, err := h.vs.GetForecastResult(name)
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	forecastUsage, err := h.vs.GetForecastResult(name, query.Forecast)
 	if err != nil {
 		h.logger.Debug("failed to get result", zap.Error(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
@@ -176,6 +244,43 @@ func (h *VMHandler) getForecastResult(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary Get vm forecast history
+// @Description Get past forecast runs of the vm from the results store, most recent first
+// @Accept  json
+// @Produce json
+// @Param name  path  string true  "the name of vm"
+// @Param limit query int    false "the maximum number of runs (default 20)"
+// @Success 200 {object} object
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/vms/{name}/forecast/history [get]
+func (h *VMHandler) getForecastHistory(c *fiber.Ctx) error {
+	var (
+		name  = c.Params("name")
+		limit = c.Query("limit")
+	)
+
+	n := 0
+	if limit != "" {
+		var err error
+		if n, err = strconv.Atoi(limit); err != nil {
+			h.logger.Debug("query parser error", zap.Error(err))
+			return c.Status(fiber.StatusBadRequest).JSON(err)
+		}
+	}
+
+	runs, err := h.vs.GetForecastHistory(name, n)
+	if err != nil {
+		h.logger.Debug("failed to get history", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(map[string]interface{}{
+		"history": runs,
+	})
+}
+
 // @Summary Get vm forecast task status by UUID
 // @Description Get forecast task status by UUID
 // @Accept  json
@@ -234,3 +339,19 @@ func (h *VMHandler) getForecastResultByID(c *fiber.Ctx) error {
 		"result": forecastUsage,
 	})
 }
+
+// @Summary Stream vm forecast task progress
+// @Description Stream state transitions and progress as server-sent events, and finally the result
+// @Produce text/event-stream
+// @Param uuid path string true "the uuid of forecast task"
+// @Success 200 {object} worker.Event
+// @Router /api/v1/vms/{uuid}/forecast/events [get]
+func (h *VMHandler) streamForecast(c *fiber.Ctx) error {
+	var (
+		uuid = c.Params("uuid")
+	)
+
+	return stream.SSE(c, func(ctx context.Context, send func(*worker.Event) error) error {
+		return h.vs.WatchForecast(ctx, uuid, send)
+	}, h.logger)
+}
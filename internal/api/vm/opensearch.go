@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"context"
+	"time"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// openSearchDatasource reads the metric documents of the libvirt exporter
+// from OpenSearch, the usage aggregated by date histograms.
+type openSearchDatasource struct {
+	client *datasource.OpenSearchClient
+}
+
+var _ Datasource = (*openSearchDatasource)(nil)
+
+func (d *openSearchDatasource) QueryUsage(ctx context.Context, name, startTime, endTime string, step time.Duration) ([][]models.Vm, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		numMetric      = VmMetricTables.Len()
+		vmMetricUsages = make([][]models.Vm, numMetric)
+	)
+
+	for i := 0; i < numMetric; i++ {
+		label := VmMetricTables.GetLabel(i, "domain")
+		match := make(map[string]string)
+		if name != "" {
+			match[label] = name
+		}
+		series, err := d.client.Histogram(ctx, datasource.OpenSearchQuery{
+			Metric:     VmMetricTables.GetSeriesName(i),
+			GroupBy:    []string{label},
+			Match:      match,
+			Percentile: VmMetricTables.GetPercentile(i),
+			Interval:   step,
+			Start:      start,
+			End:        end,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			vmMetricUsages[i] = append(vmMetricUsages[i], models.Vm{
+				VmID:  models.VmID{Name: s.Labels[label]},
+				Usage: s.Points,
+			})
+		}
+	}
+	return vmMetricUsages, nil
+}
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// prometheusDatasource reads the metrics of the libvirt exporter with PromQL,
+// e.g. from Prometheus or Thanos Query.
+type prometheusDatasource struct {
+	client *datasource.PrometheusClient
+}
+
+var _ Datasource = (*prometheusDatasource)(nil)
+
+func (d *prometheusDatasource) QueryUsage(ctx context.Context, name, startTime, endTime string, step time.Duration) ([][]models.Vm, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// the window of the samples is the step of the range query
+	step = d.client.RangeStep(start, end, step)
+	var (
+		numMetric      = VmMetricTables.Len()
+		vmMetricUsages = make([][]models.Vm, numMetric)
+		window         = datasource.Duration(step)
+	)
+
+	for i := 0; i < numMetric; i++ {
+		label := VmMetricTables.GetLabel(i, "domain")
+		query := VmMetricTables.GetQuery(i)
+		if query == "" {
+			query = fmt.Sprintf(promSeriesQuery, VmMetricTables.GetSeriesName(i), label)
+		}
+		labels := make(map[string]string)
+		if name != "" {
+			labels[label] = name
+		}
+		query = fmt.Sprintf(promUsageQuery, label, VmMetricTables.GetPercentile(i)/100, fmt.Sprintf(query, datasource.LabelFilter(labels)), window)
+
+		series, err := d.client.QueryRange(ctx, query, start, end, step)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			vmMetricUsages[i] = append(vmMetricUsages[i], models.Vm{
+				VmID:  models.VmID{Name: s.Labels[label]},
+				Usage: s.Points,
+			})
+		}
+	}
+	return vmMetricUsages, nil
+}
@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/models"
+)
+
+// promscaleDatasource reads the Promscale schema of TimescaleDB, the usage
+// from the continuous aggregates of VmMetricTables. The coarsest aggregate
+// which fits the step is read, downsampled if it is finer.
+type promscaleDatasource struct {
+	db         *gorm.DB
+	aggregates *database.AggregateSelector
+}
+
+var _ Datasource = (*promscaleDatasource)(nil)
+
+func (d *promscaleDatasource) QueryUsage(ctx context.Context, name, startTime, endTime string, step time.Duration) ([][]models.Vm, error) {
+	var (
+		numMetric      = VmMetricTables.Len()
+		vmMetricUsages = make([][]models.Vm, numMetric)
+		// goroutine and thread safe
+		ctxDB = d.db.WithContext(ctx)
+	)
+
+	for i := 0; i < numMetric; i++ {
+		view, bucket, err := d.aggregates.Select(ctx, VmMetricTables.GetMetricTableName(i), step)
+		if err != nil {
+			return nil, err
+		}
+
+		label := VmMetricTables.GetLabel(i, "domain")
+		db := ctxDB.Scopes(VmMetricTables.GetIDTable(i)).
+			Select(fmt.Sprintf("series_id, %s AS domain", label)).
+			Preload("Usage", func(db *gorm.DB) *gorm.DB {
+				db = db.Table(view).
+					Where("value != 'Nan'").
+					Where("bucket >= ? AND bucket <= ?", startTime, endTime)
+				if step > bucket {
+					db = db.Scopes(database.Downsample(step, bucket, VmMetricTables.GetPercentile(i)))
+				}
+				return db.Order("bucket")
+			})
+		if name != "" {
+			db = db.Where(fmt.Sprintf("%s=?", label), name)
+		}
+		err = db.Find(&vmMetricUsages[i]).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vmMetricUsages, nil
+}
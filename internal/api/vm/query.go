@@ -1,19 +1,27 @@
 package vm
 
 import (
+	"rightsizing-api-server/internal/api/common/resource"
 	"rightsizing-api-server/internal/api/common/table"
 )
 
-var (
-	MetricName = []string{
-		"memory",
-	}
-	IDTableName = []string{
-		"prom_series.libvirt_domain_info_memory_usage_bytes",
-	}
-	MetricTableName = []string{
-		":libvirt_domain_info_memory_usage_bytes:10min",
-	}
-)
+// DefaultMetrics are the usage metrics of VMs, replaced by the vms of the
+// metric catalogue. The label role is domain.
+var DefaultMetrics = []table.Metric{
+	{
+		Name:           "memory",
+		Unit:           resource.UnitBytes,
+		IDTable:        "prom_series.libvirt_domain_info_memory_usage_bytes",
+		AggregateTable: ":libvirt_domain_info_memory_usage_bytes:10min",
+	},
+}
+
+var VmMetricTables = table.NewTable(DefaultMetrics)
 
-var VmMetricTables = table.SetupTable(MetricName, IDTableName, MetricTableName)
+// PromQL of the Prometheus datasource. The usage of a metric is aggregated
+// like the continuous aggregates, the percentile of every step. The samples
+// of a metric without query are its series.
+const (
+	promUsageQuery  = `max by (%[1]s) (quantile_over_time(%[2]g, (%[3]s)[%[4]s:1m]))`
+	promSeriesQuery = `%[1]s{%[2]s!=""%%s}`
+)
@@ -2,22 +2,42 @@ package vm
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
 	"rightsizing-api-server/internal/api/common/errors"
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
-	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/database"
+	"rightsizing-api-server/internal/datasource"
 )
 
 type vmRepository struct {
-	db *gorm.DB
+	source Datasource
 }
 
+// NewVMRepository reads the metrics from TimescaleDB with the Promscale schema.
 func NewVMRepository(db *gorm.DB) VMRepository {
 	return &vmRepository{
-		db: db,
+		source: &promscaleDatasource{
+			db:         db,
+			aggregates: database.NewAggregateSelector(db),
+		},
+	}
+}
+
+// NewPrometheusVMRepository reads the metrics from the Prometheus HTTP API.
+func NewPrometheusVMRepository(client *datasource.PrometheusClient) VMRepository {
+	return &vmRepository{
+		source: &prometheusDatasource{client: client},
+	}
+}
+
+// NewOpenSearchVMRepository reads the metric documents from OpenSearch.
+func NewOpenSearchVMRepository(client *datasource.OpenSearchClient) VMRepository {
+	return &vmRepository{
+		source: &openSearchDatasource{client: client},
 	}
 }
 
@@ -28,7 +48,7 @@ func (r *vmRepository) GetVm(query query.Query) (*Vm, error) {
 		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
 	)
 
-	vms, err := r.Query(context.Background(), name, startTime, endTime)
+	vms, err := r.Query(query.Context(), name, startTime, endTime, query.Resolution)
 	if err != nil {
 		return nil, err
 	}
@@ -42,31 +62,17 @@ func (r *vmRepository) GetVm(query query.Query) (*Vm, error) {
 	return vms[0], nil
 }
 
-func (r *vmRepository) Query(ctx context.Context, name, startTime, endTime string) ([]*Vm, error) {
+// Query returns the vms with their usage every step, the finest resolution
+// if step is zero.
+func (r *vmRepository) Query(ctx context.Context, name, startTime, endTime string, step time.Duration) ([]*Vm, error) {
 	var (
-		numMetric      = VmMetricTables.Len()
-		vmMetricUsages = make([][]models.Vm, numMetric)
-		metricNames    = VmMetricTables.GetMetricNames()
-		// goroutine and thread safe
-		ctxDB = r.db.WithContext(ctx)
-		// time formatting for query
+		numMetric   = VmMetricTables.Len()
+		metricNames = VmMetricTables.GetMetricNames()
 	)
 
-	for i := 0; i < numMetric; i++ {
-		db := ctxDB.Scopes(VmMetricTables.GetIDTable(i)).
-			Preload("Usage", func(db *gorm.DB) *gorm.DB {
-				return db.Table(VmMetricTables.GetMetricTableName(i)).
-					Where("value != 'Nan'").
-					Where("bucket >= ? AND bucket <= ?", startTime, endTime).
-					Order("bucket")
-			})
-		if name != "" {
-			db = db.Where("domain=?", name)
-		}
-		err := db.Find(&vmMetricUsages[i]).Error
-		if err != nil {
-			return nil, err
-		}
+	vmMetricUsages, err := r.source.QueryUsage(ctx, name, startTime, endTime, step)
+	if err != nil {
+		return nil, err
 	}
 
 	vmMap := make(map[string]*Vm, len(vmMetricUsages))
@@ -80,7 +86,9 @@ func (r *vmRepository) Query(ctx context.Context, name, startTime, endTime strin
 					Usage: make(map[string]*resource.ResourceUsageInfo),
 				}
 			}
-			vmMap[name].Usage[metricName] = resource.NewResourceUsage(metricName, vmUsage.Usage)
+			usage := resource.NewResourceUsage(metricName, vmUsage.Usage)
+			usage.Unit = VmMetricTables.GetUnit(metricIdx)
+			vmMap[name].Usage[metricName] = usage
 		}
 	}
 
@@ -2,32 +2,44 @@ package vm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/RichardKnop/machinery/v1/tasks"
 	"go.uber.org/zap"
 
 	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/forecasting"
 	"rightsizing-api-server/internal/api/common/query"
 	"rightsizing-api-server/internal/api/common/resource"
 	"rightsizing-api-server/internal/api/common/rightsizing"
 	"rightsizing-api-server/internal/cache"
-	grpcclient "rightsizing-api-server/internal/grpc"
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/pricing"
+	"rightsizing-api-server/internal/store"
 	"rightsizing-api-server/internal/worker"
-	pb "rightsizing-api-server/proto"
 )
 
 const (
 	taskName = "vm_forecast"
+	// scheduled task
+	RightsizingTaskName = "vm_rightsizing"
+	// polling interval of the task state while streaming
+	watchInterval = time.Second
 )
 
 type vmService struct {
-	cache      *cache.Cache
-	worker     *worker.Worker
-	client     *grpcclient.Client
-	repository VMRepository
-	logger     *zap.Logger
+	cache        *cache.Cache
+	worker       *worker.Worker
+	forecasters  *forecasting.Registry
+	recommenders *rightsizing.Registry
+	pricing      *pricing.Pricing
+	store        *store.Store
+	repository   VMRepository
+	logger       *zap.Logger
 }
 
 var _ VMService = (*vmService)(nil)
@@ -35,19 +47,28 @@ var _ VMService = (*vmService)(nil)
 func NewVMService(
 	cache *cache.Cache,
 	worker *worker.Worker,
-	client *grpcclient.Client,
+	forecasters *forecasting.Registry,
+	recommenders *rightsizing.Registry,
+	pricing *pricing.Pricing,
+	store *store.Store,
 	repository VMRepository,
 	logger *zap.Logger) VMService {
 
 	s := &vmService{
-		cache:      cache,
-		worker:     worker,
-		client:     client,
-		repository: repository,
-		logger:     logger,
+		cache:        cache,
+		worker:       worker,
+		forecasters:  forecasters,
+		recommenders: recommenders,
+		pricing:      pricing,
+		store:        store,
+		repository:   repository,
+		logger:       logger,
 	}
 
-	worker.RegisterTask(taskName, s.forecastTask)
+	worker.RegisterTasks(map[string]interface{}{
+		taskName:            s.forecastTask,
+		RightsizingTaskName: s.rightsizingTask,
+	})
 
 	return s
 }
@@ -56,26 +77,145 @@ func (s *vmService) GetVm(query query.Query) (*Vm, error) {
 	s.logger.Debug("rightsizing vm",
 		zap.String("id", query.ID),
 		zap.String("vm", query.Name),
+		zap.String("recommender", query.Recommender),
 		zap.Time("start_time", query.StartTime),
 		zap.Time("end_time", query.EndTime))
 
+	recommender, err := s.recommender(query)
+	if err != nil {
+		return nil, err
+	}
+
 	vm, err := s.repository.GetVm(query)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, usage := range vm.Usage {
-		if err := rightsizing.Rightsizing(context.Background(), s.client, usage); err != nil {
-			s.logger.Error("failed while rightsizing", zap.Error(err))
-			return nil, err
+	if err := s.rightsizing(query.Context(), query, recommender, vm); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// recommender returns the recommender of the query. In the predictive mode it
+// recommends for the forecast peak as well.
+func (s *vmService) recommender(query query.Query) (rightsizing.Recommender, error) {
+	recommender, err := s.recommenders.Get(query.Recommender)
+	if err != nil || !query.Predictive {
+		return recommender, err
+	}
+
+	options, err := s.forecasters.Resolve(query.Forecast)
+	if err != nil {
+		return nil, err
+	}
+	forecaster, err := s.forecasters.Get(options.Model)
+	if err != nil {
+		return nil, err
+	}
+	return forecasting.NewPredictiveRecommender(recommender, forecaster, options, s.logger), nil
+}
+
+// rightsizing recommends the resources of the vms together.
+func (s *vmService) rightsizing(ctx context.Context, query query.Query, recommender rightsizing.Recommender, vms ...*Vm) error {
+	var usages []*resource.ResourceUsageInfo
+	for _, vm := range vms {
+		for _, usage := range vm.Usage {
+			usages = append(usages, usage)
 		}
 	}
+	if err := rightsizing.RightsizingAll(ctx, recommender, usages); err != nil {
+		s.logger.Error("failed while rightsizing", zap.Error(err))
+		return err
+	}
 
-	return vm, nil
+	for _, vm := range vms {
+		for _, usage := range vm.Usage {
+			rightsizing.Estimate(s.recommenders, usage)
+			usage.Recommend()
+			s.pricing.Apply(s.pricing.GetRate("", ""), usage)
+		}
+	}
+	return nil
+}
+
+// GetLatestRecommendations returns the recommendations recorded most recently,
+// e.g. by the scheduled rightsizing, without analyzing the usage again.
+func (s *vmService) GetLatestRecommendations(query query.Query) ([]*LatestRecommendation, error) {
+	records, err := s.store.LatestRecommendations(query.Context(), models.ObjectTypeVm,
+		"", query.Name, query.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		vmMap = make(map[string]*LatestRecommendation)
+		vms   []*LatestRecommendation
+	)
+	for _, record := range records {
+		if _, exist := vmMap[record.Name]; !exist {
+			vmMap[record.Name] = &LatestRecommendation{
+				Name:      record.Name,
+				Resources: make(map[string]*resource.RecommendationPoint),
+			}
+			vms = append(vms, vmMap[record.Name])
+		}
+		vmMap[record.Name].Resources[record.Resource] = resource.NewRecommendationPoint(record)
+	}
+
+	if vms == nil {
+		return nil, commonerrors.NotFoundErr("recommendation", query.Name)
+	}
+	return vms, nil
+}
+
+// rightsizingTask rightsizes every vm over the window so that the
+// recommendations are recorded in the results store.
+func (s *vmService) rightsizingTask(ctx context.Context, window string) error {
+	duration, err := time.ParseDuration(window)
+	if err != nil {
+		return err
+	}
+
+	query := query.Query{
+		StartTime: time.Now().Add(-duration),
+		EndTime:   time.Now(),
+	}
+	recommender, err := s.recommenders.Get(query.Recommender)
+	if err != nil {
+		return err
+	}
+
+	vms, err := s.repository.Query(ctx, "",
+		query.StartTime.Format("2006-01-02T15:04:05"),
+		query.EndTime.Format("2006-01-02T15:04:05"), 0)
+	if err != nil {
+		return err
+	}
+
+	if err := s.rightsizing(ctx, query, recommender, vms...); err != nil {
+		return err
+	}
+
+	var records []*models.RecommendationResult
+	for _, vm := range vms {
+		records = append(records, vm.RecommendationResults(recommender.Name(), query.StartTime, query.EndTime)...)
+	}
+	if err := s.store.SaveRecommendations(ctx, records); err != nil {
+		s.logger.Error("failed to save recommendations", zap.Error(err))
+	}
+	s.logger.Info("scheduled rightsizing", zap.Int("vms", len(vms)))
+	return nil
 }
 
 func uniqueName(name string) string {
-	return fmt.Sprintf("pod:%s-%s", name)
+	return fmt.Sprintf("vm:%s", name)
+}
+
+// forecastName is the cache key of the forecast of the vm with the options,
+// so that forecasts with different options are not shared.
+func forecastName(name string, options resource.ForecastOptions) string {
+	return uniqueName(name) + ":" + options.Key()
 }
 
 func (s *vmService) Forecast(query query.Query) (string, error) {
@@ -85,9 +225,18 @@ func (s *vmService) Forecast(query query.Query) (string, error) {
 		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
 	)
 
+	options, err := s.forecasters.Resolve(query.Forecast)
+	if err != nil {
+		return "", err
+	}
+
 	task := &tasks.Signature{
 		Name: taskName,
 		Args: []tasks.Arg{
+			{
+				Type:  "string",
+				Value: options.Model,
+			},
 			{
 				Type:  "string",
 				Value: name,
@@ -100,57 +249,160 @@ func (s *vmService) Forecast(query query.Query) (string, error) {
 				Type:  "string",
 				Value: endTime,
 			},
+			{
+				Type:  "string",
+				Value: options.Horizon.String(),
+			},
+			{
+				Type:  "string",
+				Value: options.Step.String(),
+			},
+			{
+				Type:  "float64",
+				Value: options.IntervalWidth,
+			},
+			{
+				Type:  "string",
+				Value: strings.Join(options.Seasonality, ","),
+			},
 		},
 		RetryCount: 1,
 	}
-	taskState, err := s.worker.SendTaskWithContext(context.Background(), task, uniqueName(name))
+	taskState, err := s.worker.SendTaskWithContext(context.Background(), task, forecastName(name, options))
 	if err != nil {
 		return "", err
 	}
+
+	parameters, err := json.Marshal(options.Parameters())
+	if err != nil {
+		s.logger.Error("failed to encode forecast parameters", zap.Error(err))
+	}
+
+	err = s.store.CreateForecast(context.Background(), &models.ForecastResult{
+		TaskUUID:   taskState.TaskUUID,
+		ObjectType: models.ObjectTypeVm,
+		Name:       name,
+		Model:      options.Model,
+		StartTime:  query.StartTime,
+		EndTime:    query.EndTime,
+		Parameters: string(parameters),
+		OptionsKey: options.Key(),
+		Status:     taskState.State,
+	})
+	if err != nil {
+		s.logger.Error("failed to save forecast", zap.Error(err))
+	}
 	return taskState.TaskUUID, nil
 }
 
-func (s *vmService) forecastTask(name, startTime, endTime string) (string, error) {
-	vms, err := s.repository.Query(context.Background(), name, startTime, endTime)
+func (s *vmService) forecastTask(ctx context.Context, model, name, startTime, endTime, horizon, step string, intervalWidth float64, seasonality string) (string, error) {
+	options, err := resource.ParseForecastOptions(model, horizon, step, intervalWidth, seasonality)
+	if err != nil {
+		return "", err
+	}
+
+	forecastUsages, err := s.forecast(ctx, name, startTime, endTime, options)
+	if err := s.store.FinishForecastTask(ctx, forecastUsages, err); err != nil {
+		s.logger.Error("failed to save forecast result", zap.Error(err))
+	}
 	if err != nil {
 		return "", err
 	}
+
+	encodedUsage, err := resource.EncodeForecastUsage(forecastUsages)
+	if err != nil {
+		return "", err
+	}
+
+	return encodedUsage, nil
+}
+
+func (s *vmService) forecast(ctx context.Context, name, startTime, endTime string, options resource.ForecastOptions) ([]*resource.ForecastUsage, error) {
+	forecaster, err := s.forecasters.Get(options.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	vms, err := s.repository.Query(ctx, name, startTime, endTime, 0)
+	if err != nil {
+		return nil, err
+	}
 	if len(vms) == 0 {
-		return "", commonerrors.NotFoundErr("vm", name)
+		return nil, commonerrors.NotFoundErr("vm", name)
 	} else if len(vms) > 1 {
-		return "", commonerrors.NotUniqueErr("vm", name)
+		return nil, commonerrors.NotUniqueErr("vm", name)
 	}
 
 	vm := vms[0]
-	forecastUsage := &resource.ForecastUsage{
-		Name:  vm.Name,
-		Usage: make(map[string][]*pb.TimeSeriesDatapoint),
-	}
+	forecastUsage := resource.NewForecastUsage(vm.Name)
 
-	for _, usage := range vm.Usage {
-		res, err := s.client.Forecast(context.Background(), usage.Usage)
+	done := 0
+	for name, usage := range vm.Usage {
+		results, err := forecaster.Forecast(ctx, usage.Usage, options)
 		if err != nil {
 			s.logger.Error("failed while forecast", zap.Error(err))
-			return "", err
+			return nil, err
 		}
-		for _, result := range res.Result {
-			forecastUsage.Usage[result.Name] = result.Data
+		forecastUsage.Add(name, results)
+		done++
+		s.worker.Progress(ctx, vm.Name, name, done, len(vm.Usage))
+	}
+	return []*resource.ForecastUsage{forecastUsage}, nil
+}
+
+// WatchForecast sends the state transitions and the progress of the forecast
+// task, and finally its result.
+func (s *vmService) WatchForecast(ctx context.Context, uuid string, send func(*worker.Event) error) error {
+	status, err := s.getForecastStatus(uuid)
+	if err != nil {
+		return err
+	}
+	if !worker.IsTerminal(status) {
+		if err := s.worker.Watch(ctx, uuid, watchInterval, send); err != nil {
+			return err
 		}
 	}
-	forecastUsages := []*resource.ForecastUsage{
-		forecastUsage,
+
+	event := &worker.Event{
+		Type: worker.EventResult,
+		UUID: uuid,
+		Time: time.Now(),
+	}
+	event.State, err = s.getForecastStatus(uuid)
+	if err != nil {
+		return err
 	}
+	if event.State == tasks.StateSuccess {
+		if event.Result, err = s.getForecastResult(uuid); err != nil {
+			return err
+		}
+	} else if record, err := s.store.GetForecast(ctx, uuid); err == nil {
+		event.Error = record.Error
+	}
+	return send(event)
+}
 
-	encodedUsage, err := resource.EncodeForecastUsage(forecastUsages)
+// getUUID returns the uuid of the latest forecast of the vm with the options.
+// The latest forecast with the same options in the results store is used when
+// the task is no longer cached.
+func (s *vmService) getUUID(name string, options resource.ForecastOptions) (string, error) {
+	options, err := s.forecasters.Resolve(options)
 	if err != nil {
 		return "", err
 	}
-
-	return encodedUsage, nil
+	uuid, err := s.worker.GetUUID(forecastName(name, options))
+	if err == nil {
+		return uuid, nil
+	}
+	record, storeErr := s.store.GetLatestForecast(context.Background(), models.ObjectTypeVm, "", name, options.Key())
+	if storeErr != nil {
+		return "", err
+	}
+	return record.TaskUUID, nil
 }
 
-func (s *vmService) GetForecastStatus(name string) (string, error) {
-	uuid, err := s.worker.GetUUID(uniqueName(name))
+func (s *vmService) GetForecastStatus(name string, options resource.ForecastOptions) (string, error) {
+	uuid, err := s.getUUID(name, options)
 	if err != nil {
 		return "", err
 	}
@@ -158,29 +410,47 @@ func (s *vmService) GetForecastStatus(name string) (string, error) {
 }
 
 func (s *vmService) GetForecastStatusByID(uuid string) (string, error) {
-	status, err := s.worker.GetTaskStatus(uuid)
-	if err != nil {
-		return "", err
-	}
-	return status, nil
+	return s.getForecastStatus(uuid)
 }
 
 func (s *vmService) getForecastStatus(uuid string) (string, error) {
 	status, err := s.worker.GetTaskStatus(uuid)
 	if err != nil {
-		return "", err
+		// result backend expired, use the recorded status
+		record, storeErr := s.store.GetForecast(context.Background(), uuid)
+		if storeErr != nil {
+			return "", err
+		}
+		return record.Status, nil
 	}
 	return status, nil
 }
 
-func (s *vmService) GetForecastResult(name string) (map[string]*resource.ForecastUsage, error) {
-	uuid, err := s.worker.GetUUID(uniqueName(name))
+func (s *vmService) GetForecastResult(name string, options resource.ForecastOptions) (map[string]*resource.ForecastUsage, error) {
+	uuid, err := s.getUUID(name, options)
 	if err != nil {
 		return nil, err
 	}
 	return s.getForecastResult(uuid)
 }
 
+func (s *vmService) GetForecastHistory(name string, limit int) ([]*resource.ForecastRun, error) {
+	records, err := s.store.ListForecasts(context.Background(), models.ObjectTypeVm, "", name, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*resource.ForecastRun, 0, len(records))
+	for _, record := range records {
+		run, err := resource.NewForecastRun(record)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
 func (s *vmService) GetForecastResultByID(uuid string) (map[string]*resource.ForecastUsage, error) {
 	return s.getForecastResult(uuid)
 }
@@ -192,7 +462,16 @@ func (s *vmService) getForecastResult(uuid string) (map[string]*resource.Forecas
 	}
 
 	if err != nil {
-		return nil, err
+		// result backend expired, use the recorded result
+		record, storeErr := s.store.GetForecast(context.Background(), uuid)
+		if storeErr != nil {
+			return nil, err
+		}
+		run, err := resource.NewForecastRun(record)
+		if err != nil {
+			return nil, err
+		}
+		return run.UsageMap(), nil
 	}
 
 	usages := make(map[string]*resource.ForecastUsage)
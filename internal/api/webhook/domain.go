@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/worker"
+)
+
+const (
+	EventForecastSucceeded = "forecast.succeeded"
+	EventForecastFailed    = "forecast.failed"
+	EventUnderallocated    = "container.underallocated"
+)
+
+var Events = []string{
+	EventForecastSucceeded,
+	EventForecastFailed,
+	EventUnderallocated,
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*models.WebhookDelivery, error)
+}
+
+// Notifier sends an event to the subscribers of the event.
+type Notifier interface {
+	Notify(event string, data interface{})
+}
+
+type WebhookService interface {
+	Notifier
+	NotifyTask(event *worker.Event)
+	CreateSubscription(request *SubscriptionRequest) (*Subscription, error)
+	ListSubscriptions() ([]*Subscription, error)
+	DeleteSubscription(id int64) error
+	ListDeliveries(id int64, limit int) ([]*Delivery, error)
+}
+
+type SubscriptionRequest struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret"`
+	// event filter, every event if empty
+	Events []string `json:"events,omitempty"`
+}
+
+type Subscription struct {
+	*models.WebhookSubscription
+	Events []string `json:"events"`
+}
+
+func NewSubscription(record *models.WebhookSubscription) *Subscription {
+	subscription := &Subscription{
+		WebhookSubscription: record,
+		Events:              []string{},
+	}
+	if record.Events != "" {
+		subscription.Events = strings.Split(record.Events, ",")
+	}
+	return subscription
+}
+
+// Accepts reports whether the event passes the event filter.
+func (s *Subscription) Accepts(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type Delivery struct {
+	*models.WebhookDelivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Payload is the body of a delivery. ID is the delivery id, which is also
+// sent in the X-Webhook-Delivery header.
+type Payload struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+type nopNotifier struct{}
+
+// NewNopNotifier returns a notifier which drops the events, for a server
+// without webhooks.
+func NewNopNotifier() Notifier {
+	return nopNotifier{}
+}
+
+func (nopNotifier) Notify(event string, data interface{}) {}
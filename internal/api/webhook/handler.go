@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	ws     WebhookService
+	logger *zap.Logger
+}
+
+func WebhookRouter(route fiber.Router, ws WebhookService, logger *zap.Logger) {
+	handler := &WebhookHandler{
+		ws:     ws,
+		logger: logger,
+	}
+
+	route.Post("/webhooks", handler.createSubscription)
+	route.Get("/webhooks", handler.listSubscriptions)
+	route.Delete("/webhooks/:id", handler.deleteSubscription)
+	route.Get("/webhooks/:id/deliveries", handler.listDeliveries)
+}
+
+// @Summary webhook 구독 등록
+// @Description 이벤트 발생 시 url로 서명된 json payload를 POST 한다. events를 지정하지 않으면 모든 이벤트를 전송한다.
+// (forecast.succeeded, forecast.failed, container.underallocated)
+// @Accept  json
+// @Produce json
+// @Param request body SubscriptionRequest true "url, secret and event filter"
+// @Success 201 {object} Subscription
+// @Failure 400 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) createSubscription(c *fiber.Ctx) error {
+	request := &SubscriptionRequest{}
+	if err := c.BodyParser(request); err != nil {
+		h.logger.Debug("body parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	subscription, err := h.ws.CreateSubscription(request)
+	if err != nil {
+		h.logger.Debug("failed to create webhook", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+// @Summary 등록된 webhook 구독 목록 제공
+// @Accept  json
+// @Produce json
+// @Success 200 {object} []Subscription
+// @Failure 500 {object} nil
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) listSubscriptions(c *fiber.Ctx) error {
+	subscriptions, err := h.ws.ListSubscriptions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(subscriptions)
+}
+
+// @Summary webhook 구독 삭제
+// @Accept  json
+// @Produce json
+// @Param id path int true "the id of webhook"
+// @Success 204 {object} nil
+// @Failure 400 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) deleteSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	if err := h.ws.DeleteSubscription(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary webhook 전송 기록 제공
+// @Description 재시도를 포함한 모든 전송 시도를 최신 순으로 제공한다.
+// @Accept  json
+// @Produce json
+// @Param id    path  int true  "the id of webhook"
+// @Param limit query int false "the maximum number of deliveries (default 50)"
+// @Success 200 {object} []Delivery
+// @Failure 400 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) listDeliveries(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	limit := c.Query("limit")
+	n := 0
+	if limit != "" {
+		if n, err = strconv.Atoi(limit); err != nil {
+			h.logger.Debug("query parser error", zap.Error(err))
+			return c.Status(fiber.StatusBadRequest).JSON(err)
+		}
+	}
+
+	deliveries, err := h.ws.ListDeliveries(id, n)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(deliveries)
+}
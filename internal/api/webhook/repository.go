@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/models"
+)
+
+const defaultLimit = 50
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+var _ WebhookRepository = (*webhookRepository)(nil)
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).
+		Create(subscription).
+		Error
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Take(&subscription).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, commonerrors.NotFoundErr("webhook", strconv.FormatInt(id, 10))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	err := r.db.WithContext(ctx).
+		Order("id").
+		Find(&subscriptions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).
+		Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return commonerrors.NotFoundErr("webhook", strconv.FormatInt(id, 10))
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).
+		Create(delivery).
+		Error
+}
+
+// ListDeliveries returns the delivery attempts of the subscription, most
+// recent first.
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*models.WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	var deliveries []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/store"
+	"rightsizing-api-server/internal/worker"
+)
+
+// Options of the delivery. A failed delivery is retried with exponential
+// backoff starting from Backoff up to MaxBackoff.
+type Options struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		Timeout:     10 * time.Second,
+	}
+}
+
+type webhookService struct {
+	client     *http.Client
+	options    Options
+	store      *store.Store
+	repository WebhookRepository
+	logger     *zap.Logger
+}
+
+var _ WebhookService = (*webhookService)(nil)
+
+func NewWebhookService(options Options, store *store.Store, r WebhookRepository, logger *zap.Logger) WebhookService {
+	return &webhookService{
+		client: &http.Client{
+			Timeout: options.Timeout,
+		},
+		options:    options,
+		store:      store,
+		repository: r,
+		logger:     logger,
+	}
+}
+
+func (s *webhookService) CreateSubscription(request *SubscriptionRequest) (*Subscription, error) {
+	target, err := url.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url %q", request.URL)
+	}
+	for _, event := range request.Events {
+		if !isEvent(event) {
+			return nil, fmt.Errorf("unknown webhook event %q", event)
+		}
+	}
+
+	record := &models.WebhookSubscription{
+		URL:       request.URL,
+		Secret:    request.Secret,
+		Events:    strings.Join(request.Events, ","),
+		CreatedAt: time.Now(),
+	}
+	if err := s.repository.CreateSubscription(context.Background(), record); err != nil {
+		return nil, err
+	}
+	return NewSubscription(record), nil
+}
+
+func isEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *webhookService) ListSubscriptions() ([]*Subscription, error) {
+	records, err := s.repository.ListSubscriptions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*Subscription, 0, len(records))
+	for _, record := range records {
+		subscriptions = append(subscriptions, NewSubscription(record))
+	}
+	return subscriptions, nil
+}
+
+func (s *webhookService) DeleteSubscription(id int64) error {
+	return s.repository.DeleteSubscription(context.Background(), id)
+}
+
+func (s *webhookService) ListDeliveries(id int64, limit int) ([]*Delivery, error) {
+	if _, err := s.repository.GetSubscription(context.Background(), id); err != nil {
+		return nil, err
+	}
+
+	records, err := s.repository.ListDeliveries(context.Background(), id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*Delivery, 0, len(records))
+	for _, record := range records {
+		deliveries = append(deliveries, &Delivery{
+			WebhookDelivery: record,
+			Payload:         json.RawMessage(record.Payload),
+		})
+	}
+	return deliveries, nil
+}
+
+// NotifyTask notifies the end of a forecast task. It is registered as a
+// listener of the task events of the worker.
+func (s *webhookService) NotifyTask(event *worker.Event) {
+	var name string
+	switch event.State {
+	case tasks.StateSuccess:
+		name = EventForecastSucceeded
+	case tasks.StateFailure:
+		name = EventForecastFailed
+	default:
+		return
+	}
+
+	// only forecasts are recorded in the results store
+	record, err := s.store.GetForecast(context.Background(), event.UUID)
+	if err != nil {
+		return
+	}
+	if record.Error == "" {
+		record.Error = event.Error
+	}
+	s.Notify(name, record)
+}
+
+// Notify sends the event to every subscriber of the event in the background.
+func (s *webhookService) Notify(event string, data interface{}) {
+	subscriptions, err := s.ListSubscriptions()
+	if err != nil {
+		s.logger.Error("failed to get webhook subscriptions", zap.String("event", event), zap.Error(err))
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(event) {
+			continue
+		}
+
+		payload := &Payload{
+			ID:    newDeliveryID(),
+			Event: event,
+			Time:  time.Now(),
+			Data:  data,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			s.logger.Error("failed to encode webhook payload", zap.String("event", event), zap.Error(err))
+			return
+		}
+		go s.deliver(subscription.WebhookSubscription, payload, body)
+	}
+}
+
+// deliver posts the payload until it succeeds or the attempts run out.
+// Every attempt is recorded in the delivery log.
+func (s *webhookService) deliver(subscription *models.WebhookSubscription, payload *Payload, body []byte) {
+	backoff := s.options.Backoff
+	for attempt := 1; ; attempt++ {
+		statusCode, err := s.post(subscription, payload, body)
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			DeliveryID:     payload.ID,
+			Event:          payload.Event,
+			Attempt:        attempt,
+			StatusCode:     statusCode,
+			Succeeded:      err == nil,
+			Payload:        string(body),
+			CreatedAt:      time.Now(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if err := s.repository.CreateDelivery(context.Background(), delivery); err != nil {
+			s.logger.Error("failed to save webhook delivery", zap.Error(err))
+		}
+
+		if err == nil {
+			return
+		}
+		if attempt >= s.options.MaxAttempts || !retryable(statusCode) {
+			s.logger.Error("failed to deliver webhook",
+				zap.Int64("subscription", subscription.ID),
+				zap.String("delivery", payload.ID),
+				zap.Int("attempt", attempt),
+				zap.Error(err))
+			return
+		}
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > s.options.MaxBackoff {
+			backoff = s.options.MaxBackoff
+		}
+	}
+}
+
+func (s *webhookService) post(subscription *models.WebhookSubscription, payload *Payload, body []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, payload.Event)
+	request.Header.Set(HeaderDelivery, payload.ID)
+	request.Header.Set(HeaderSignature, Sign(subscription.Secret, body))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, errors.New(response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// retryable reports whether a delivery which failed with the status code may
+// succeed later. Requests rejected by the receiver are not retried.
+func retryable(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode == 0 || statusCode >= 500
+}
+
+func newDeliveryID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/models"
+)
+
+// fakeRepository keeps the delivery log in memory.
+type fakeRepository struct {
+	mu         sync.Mutex
+	deliveries []*models.WebhookDelivery
+}
+
+var _ WebhookRepository = (*fakeRepository)(nil)
+
+func (r *fakeRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return nil
+}
+
+func (r *fakeRepository) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *fakeRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func (r *fakeRepository) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveries, nil
+}
+
+// receiver records the requests and responds with the status codes in order,
+// the last one once they run out.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	times    []time.Time
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	attempt := len(rc.times)
+	rc.times = append(rc.times, time.Now())
+	rc.headers = append(rc.headers, r.Header.Clone())
+	rc.bodies = append(rc.bodies, body)
+
+	status := rc.statuses[len(rc.statuses)-1]
+	if attempt < len(rc.statuses) {
+		status = rc.statuses[attempt]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestService(options Options) (*webhookService, *fakeRepository) {
+	repository := &fakeRepository{}
+	s := NewWebhookService(options, nil, repository, zap.NewNop()).(*webhookService)
+	return s, repository
+}
+
+// deliverTo delivers an event to the receiver as Notify does, but waits for
+// the delivery to finish.
+func deliverTo(t *testing.T, s *webhookService, server *httptest.Server, secret string) *Payload {
+	t.Helper()
+
+	subscription := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: secret}
+	payload := &Payload{
+		ID:    newDeliveryID(),
+		Event: EventUnderallocated,
+		Time:  time.Now(),
+		Data:  map[string]string{"pod": "nginx"},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.deliver(subscription, payload, body)
+	return payload
+}
+
+func checkDeliveries(t *testing.T, repository *fakeRepository, payload *Payload, statusCode, attempts int, succeeded bool) {
+	t.Helper()
+
+	deliveries, _ := repository.ListDeliveries(context.Background(), 1, 0)
+	if len(deliveries) != attempts {
+		t.Fatalf("got %d delivery log rows, want one per attempt (%d)", len(deliveries), attempts)
+	}
+	for i, delivery := range deliveries {
+		if delivery.Attempt != i+1 {
+			t.Errorf("row %d: got attempt %d, want %d", i, delivery.Attempt, i+1)
+		}
+		if delivery.SubscriptionID != 1 || delivery.DeliveryID != payload.ID || delivery.Event != payload.Event {
+			t.Errorf("row %d: got %+v, want the delivery %s of the subscription 1", i, delivery, payload.ID)
+		}
+		last := i == len(deliveries)-1
+		if delivery.Succeeded != (succeeded && last) {
+			t.Errorf("row %d: got succeeded %v", i, delivery.Succeeded)
+		}
+		if last && delivery.StatusCode != statusCode {
+			t.Errorf("row %d: got status code %d, want %d", i, delivery.StatusCode, statusCode)
+		}
+	}
+}
+
+func TestDeliverSignature(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusNoContent}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s, repository := newTestService(DefaultOptions())
+	payload := deliverTo(t, s, server, "secret")
+
+	if len(rc.bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(rc.bodies))
+	}
+	header, body := rc.headers[0], rc.bodies[0]
+	if got, want := header.Get(HeaderSignature), Sign("secret", body); got != want {
+		t.Errorf("got signature %q, want %q", got, want)
+	}
+	if !Verify("secret", body, header.Get(HeaderSignature)) {
+		t.Error("signature is not verified with the secret")
+	}
+	if Verify("other", body, header.Get(HeaderSignature)) {
+		t.Error("signature is verified with another secret")
+	}
+	if got := header.Get(HeaderDelivery); got != payload.ID {
+		t.Errorf("got delivery header %q, want %q", got, payload.ID)
+	}
+	if got := header.Get(HeaderEvent); got != EventUnderallocated {
+		t.Errorf("got event header %q, want %q", got, EventUnderallocated)
+	}
+	checkDeliveries(t, repository, payload, http.StatusNoContent, 1, true)
+}
+
+func TestDeliverRetriesServerErrors(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	options := Options{
+		MaxAttempts: 4,
+		Backoff:     20 * time.Millisecond,
+		MaxBackoff:  40 * time.Millisecond,
+		Timeout:     time.Second,
+	}
+	s, repository := newTestService(options)
+	payload := deliverTo(t, s, server, "secret")
+
+	if len(rc.times) != options.MaxAttempts {
+		t.Fatalf("got %d requests, want %d", len(rc.times), options.MaxAttempts)
+	}
+	// exponential backoff capped at MaxBackoff
+	backoffs := []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, backoff := range backoffs {
+		if gap := rc.times[i+1].Sub(rc.times[i]); gap < backoff {
+			t.Errorf("attempt %d: retried after %v, want at least %v", i+2, gap, backoff)
+		}
+	}
+	checkDeliveries(t, repository, payload, http.StatusServiceUnavailable, options.MaxAttempts, false)
+}
+
+func TestDeliverRetriesUntilSuccess(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusBadGateway, http.StatusInternalServerError, http.StatusOK}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	options := Options{
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		Timeout:     time.Second,
+	}
+	s, repository := newTestService(options)
+	payload := deliverTo(t, s, server, "secret")
+
+	if len(rc.times) != 3 {
+		t.Fatalf("got %d requests, want 3", len(rc.times))
+	}
+	checkDeliveries(t, repository, payload, http.StatusOK, 3, true)
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	options := Options{
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		Timeout:     time.Second,
+	}
+	s, repository := newTestService(options)
+	payload := deliverTo(t, s, server, "secret")
+
+	if len(rc.times) != 1 {
+		t.Fatalf("got %d requests, want 1", len(rc.times))
+	}
+	checkDeliveries(t, repository, payload, http.StatusBadRequest, 1, false)
+}
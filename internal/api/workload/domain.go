@@ -0,0 +1,200 @@
+package workload
+
+import (
+	"context"
+	"sort"
+
+	"rightsizing-api-server/internal/api/common/query"
+	"rightsizing-api-server/internal/api/common/resource"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+	"rightsizing-api-server/internal/api/pod"
+	"rightsizing-api-server/internal/models"
+	"rightsizing-api-server/internal/pricing"
+)
+
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindJob         = "Job"
+	KindReplicaSet  = "ReplicaSet"
+	// pod without owner
+	KindPod = "Pod"
+)
+
+type WorkloadRepository interface {
+	GetOwners(ctx context.Context, namespace, startTime, endTime string) (map[string]Owner, error)
+}
+
+// Datasource reads the owners of the pods and of the replica sets from a
+// metrics backend, the latest ones seen between startTime and endTime.
+type Datasource interface {
+	QueryOwners(ctx context.Context, namespace, startTime, endTime string) ([]models.PodOwner, []models.ReplicaSetOwner, error)
+}
+
+type WorkloadService interface {
+	GetAllWorkload(query query.Query) ([]*Workload, error)
+	GetWorkload(query query.Query, kind string) (*Workload, error)
+}
+
+type Owner struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type Workload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	// node pool used for pricing, if configured and the same for every pod
+	NodePool string `json:"node_pool,omitempty"`
+	// pods which belonged to the workload during the query range
+	Pods []string `json:"pods,omitempty"`
+	// Container information merged by container name
+	Containers []*Container `json:"containers,omitempty"`
+	// total usage information of a single replica
+	Usages map[string]*resource.ResourceUsageInfo `json:"usage,omitempty"`
+}
+
+type Container struct {
+	Name string `json:"container_name"`
+	// Resource usage list merged across all pods
+	Usage map[string]*resource.ResourceUsageInfo `json:"usages,omitempty"`
+	// timestamp of the latest sample per resource, to pick the current quota
+	latest map[string]int64
+}
+
+func NewWorkload(namespace string, owner Owner) *Workload {
+	return &Workload{
+		Namespace:  namespace,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		Pods:       make([]string, 0),
+		Containers: make([]*Container, 0),
+		Usages:     pod.NewUsages(),
+	}
+}
+
+func (w *Workload) UniqueName() string {
+	return uniqueName(w.Namespace, w.Kind, w.Name)
+}
+
+func (w *Workload) addPod(name string) {
+	for _, pod := range w.Pods {
+		if pod == name {
+			return
+		}
+	}
+	w.Pods = append(w.Pods, name)
+}
+
+func (w *Workload) getContainer(name string) *Container {
+	for _, container := range w.Containers {
+		if container.Name == name {
+			return container
+		}
+	}
+	container := &Container{
+		Name:   name,
+		Usage:  make(map[string]*resource.ResourceUsageInfo),
+		latest: make(map[string]int64),
+	}
+	w.Containers = append(w.Containers, container)
+	return container
+}
+
+// AddContainer merges the usage history of a pod container into the
+// container of the workload with the same name. The quota and the current
+// usage are taken from the most recent pod.
+func (w *Workload) AddContainer(c *pod.Container) {
+	w.addPod(c.Pod)
+	container := w.getContainer(c.Name)
+
+	for name, usage := range c.Usage {
+		merged, exist := container.Usage[name]
+		if !exist {
+			merged = &resource.ResourceUsageInfo{ResourceName: name, Unit: usage.Unit}
+			container.Usage[name] = merged
+			container.latest[name] = -1
+		}
+		merged.Usage = append(merged.Usage, usage.Usage...)
+
+		var latest int64
+		if len(usage.Usage) > 0 {
+			latest = usage.Usage[len(usage.Usage)-1].Time
+		}
+		if latest >= container.latest[name] {
+			container.latest[name] = latest
+			merged.Request = usage.Request
+			merged.Limit = usage.Limit
+			merged.CurrentUsage = usage.CurrentUsage
+		}
+	}
+}
+
+// Finalize sorts the merged history and sums the usage of the containers.
+func (w *Workload) Finalize() {
+	sort.Strings(w.Pods)
+	sort.Slice(w.Containers, func(i, j int) bool {
+		return w.Containers[i].Name < w.Containers[j].Name
+	})
+
+	for _, container := range w.Containers {
+		for name, usage := range container.Usage {
+			sort.SliceStable(usage.Usage, func(i, j int) bool {
+				return usage.Usage[i].Time < usage.Usage[j].Time
+			})
+			if _, exist := w.Usages[name]; !exist {
+				w.Usages[name] = &resource.ResourceUsageInfo{ResourceName: name, Unit: usage.Unit}
+			}
+			w.Usages[name].Request += usage.Request
+			w.Usages[name].Limit += usage.Limit
+			w.Usages[name].CurrentUsage += usage.CurrentUsage
+		}
+	}
+}
+
+// Rollup returns the usage of the containers and the workload total.
+func (w *Workload) Rollup() resource.Rollup {
+	rollup := resource.Rollup{Total: w.Usages}
+	for _, container := range w.Containers {
+		rollup.Containers = append(rollup.Containers, container.Usage)
+	}
+	return rollup
+}
+
+// Rightsizing recommends the usages of the containers of all the workloads
+// together, so that a batch recommender needs few calls for many workloads.
+func Rightsizing(ctx context.Context, recommender rightsizing.Recommender, estimator rightsizing.Estimator, workloads ...*Workload) error {
+	rollups := make([]resource.Rollup, len(workloads))
+	for i, w := range workloads {
+		rollups[i] = w.Rollup()
+	}
+	return rightsizing.RightsizingRollups(ctx, recommender, estimator, rollups...)
+}
+
+// Recommend fills the request/limit recommendation of every container and
+// the workload total. It should be called after Rightsizing.
+func (w *Workload) Recommend() {
+	w.Rollup().Recommend()
+}
+
+// ApplyCost sets the cost of every container and the workload total.
+// It should be called after Recommend.
+func (w *Workload) ApplyCost(p *pricing.Pricing) {
+	p.ApplyRollup(p.GetRate(w.Namespace, w.NodePool), w.Rollup())
+}
+
+// Summarize rolls up the requested, used and recommended resources of the
+// containers per resource.
+func (w *Workload) Summarize() map[string]*resource.ResourceSummary {
+	return w.Rollup().Summarize()
+}
+
+func uniqueName(namespace, kind, name string) string {
+	return "workload:" + namespace + "/" + kind + "/" + name
+}
+
+func uniquePodName(namespace, pod string) string {
+	return namespace + "_" + pod
+}
@@ -0,0 +1,90 @@
+package workload
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/api/common/query"
+	_ "rightsizing-api-server/internal/api/common/resource"
+)
+
+type WorkloadHandler struct {
+	ws     WorkloadService
+	logger *zap.Logger
+}
+
+func WorkloadRouter(route fiber.Router, ws WorkloadService, logger *zap.Logger) {
+	handler := &WorkloadHandler{
+		ws:     ws,
+		logger: logger,
+	}
+
+	route.Get("/workloads", handler.getAllWorkload)
+	route.Get("/workloads/:namespace/:kind/:name", handler.getWorkload)
+}
+
+// @Summary workload 단위의 리소스 정보 및 추천값 제공
+// @Description Deployment, StatefulSet, DaemonSet, Job 등 owner 기준으로 과거 pod들의 사용량을 합쳐서 container 이름별로 추천값을 제공한다.
+// @Accept  json
+// @Produce json
+// @Param namespace   query string false "the namespace of workloads"
+// @Param start       query string false "start time"
+// @Param end         query string false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Param step        query string false "interval between usage datapoints (e.g. 1h, default the finest)"
+// @Param resolution  query string false "interval between usage datapoints, the same as step"
+// @Param max_points  query int    false "the maximum number of usage datapoints of a series"
+// @Success 200 {object} Workload list
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/workloads [get]
+func (h *WorkloadHandler) getAllWorkload(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	workloads, err := h.ws.GetAllWorkload(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	return c.Status(fiber.StatusOK).JSON(workloads)
+}
+
+// @Summary 특정 workload의 리소스 정보 및 추천값 제공
+// @Accept  json
+// @Produce json
+// @Param namespace   path  string true  "the namespace of workload"
+// @Param kind        path  string true  "the kind of workload (Deployment, StatefulSet, DaemonSet, Job)"
+// @Param name        path  string true  "the name of workload"
+// @Param start       query string false "start time"
+// @Param end         query string false "end time"
+// @Param recommender query string false "recommendation strategy (grpc, percentile, max, histogram)"
+// @Param step        query string false "interval between usage datapoints (e.g. 1h, default the finest)"
+// @Param resolution  query string false "interval between usage datapoints, the same as step"
+// @Param max_points  query int    false "the maximum number of usage datapoints of a series"
+// @Success 200 {object} Workload
+// @Failure 400 {object} nil
+// @Failure 404 {object} nil
+// @Failure 500 {object} nil
+// @Router /api/v1/workloads/{namespace}/{kind}/{name} [get]
+func (h *WorkloadHandler) getWorkload(c *fiber.Ctx) error {
+	query, err := query.ParseAndValidate(c)
+	if err != nil {
+		h.logger.Debug("query parser error", zap.Error(err))
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+	query.Namespace = c.Params("namespace")
+	query.Name = c.Params("name")
+
+	workload, err := h.ws.GetWorkload(query, c.Params("kind"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+	if workload == nil {
+		return c.Status(fiber.StatusNotFound).JSON(nil)
+	}
+	return c.Status(fiber.StatusOK).JSON(workload)
+}
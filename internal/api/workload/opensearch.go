@@ -0,0 +1,76 @@
+package workload
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// openSearchDatasource reads the metric documents of kube-state-metrics from
+// OpenSearch.
+type openSearchDatasource struct {
+	client *datasource.OpenSearchClient
+}
+
+var _ Datasource = (*openSearchDatasource)(nil)
+
+func (d *openSearchDatasource) QueryOwners(ctx context.Context, namespace, startTime, endTime string) ([]models.PodOwner, []models.ReplicaSetOwner, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	match := make(map[string]string)
+	if namespace != "" {
+		match["namespace"] = namespace
+	}
+	var pods, replicaSets []datasource.Series
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		pods, err = d.client.Latest(gctx, datasource.OpenSearchQuery{
+			Metric:  osPodOwnerMetric,
+			GroupBy: []string{"namespace", "pod", "owner_kind", "owner_name"},
+			Match:   match,
+			Start:   start,
+			End:     end,
+		})
+		return err
+	})
+	g.Go(func() (err error) {
+		replicaSets, err = d.client.Latest(gctx, datasource.OpenSearchQuery{
+			Metric:  osReplicaSetOwnerMetric,
+			GroupBy: []string{"namespace", "replicaset", "owner_kind", "owner_name"},
+			Match:   match,
+			Start:   start,
+			End:     end,
+		})
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	podOwners := make([]models.PodOwner, 0, len(pods))
+	for _, s := range pods {
+		podOwners = append(podOwners, models.PodOwner{
+			Namespace: s.Labels["namespace"],
+			Pod:       s.Labels["pod"],
+			OwnerKind: s.Labels["owner_kind"],
+			OwnerName: s.Labels["owner_name"],
+		})
+	}
+	replicaSetOwners := make([]models.ReplicaSetOwner, 0, len(replicaSets))
+	for _, s := range replicaSets {
+		replicaSetOwners = append(replicaSetOwners, models.ReplicaSetOwner{
+			Namespace:  s.Labels["namespace"],
+			ReplicaSet: s.Labels["replicaset"],
+			OwnerKind:  s.Labels["owner_kind"],
+			OwnerName:  s.Labels["owner_name"],
+		})
+	}
+	return podOwners, replicaSetOwners, nil
+}
@@ -0,0 +1,69 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+// prometheusDatasource reads the metrics of kube-state-metrics with PromQL,
+// e.g. from Prometheus or Thanos Query.
+type prometheusDatasource struct {
+	client *datasource.PrometheusClient
+}
+
+var _ Datasource = (*prometheusDatasource)(nil)
+
+func (d *prometheusDatasource) QueryOwners(ctx context.Context, namespace, startTime, endTime string) ([]models.PodOwner, []models.ReplicaSetOwner, error) {
+	start, end, err := datasource.ParseRange(startTime, endTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labels := make(map[string]string)
+	if namespace != "" {
+		labels["namespace"] = namespace
+	}
+	var (
+		pods, replicaSets []datasource.Series
+		filter            = datasource.LabelFilter(labels)
+		window            = datasource.Duration(end.Sub(start))
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		pods, err = d.client.Query(gctx, fmt.Sprintf(promPodOwnerQuery, filter, window), end)
+		return err
+	})
+	g.Go(func() (err error) {
+		replicaSets, err = d.client.Query(gctx, fmt.Sprintf(promReplicaSetOwnerQuery, filter, window), end)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	podOwners := make([]models.PodOwner, 0, len(pods))
+	for _, s := range pods {
+		podOwners = append(podOwners, models.PodOwner{
+			Namespace: s.Labels["namespace"],
+			Pod:       s.Labels["pod"],
+			OwnerKind: s.Labels["owner_kind"],
+			OwnerName: s.Labels["owner_name"],
+		})
+	}
+	replicaSetOwners := make([]models.ReplicaSetOwner, 0, len(replicaSets))
+	for _, s := range replicaSets {
+		replicaSetOwners = append(replicaSetOwners, models.ReplicaSetOwner{
+			Namespace:  s.Labels["namespace"],
+			ReplicaSet: s.Labels["replicaset"],
+			OwnerKind:  s.Labels["owner_kind"],
+			OwnerName:  s.Labels["owner_name"],
+		})
+	}
+	return podOwners, replicaSetOwners, nil
+}
@@ -0,0 +1,48 @@
+package workload
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+
+	"rightsizing-api-server/internal/models"
+)
+
+// promscaleDatasource reads the Promscale schema of TimescaleDB.
+type promscaleDatasource struct {
+	db *gorm.DB
+}
+
+var _ Datasource = (*promscaleDatasource)(nil)
+
+func (d *promscaleDatasource) QueryOwners(ctx context.Context, namespace, startTime, endTime string) ([]models.PodOwner, []models.ReplicaSetOwner, error) {
+	var (
+		podOwners        []models.PodOwner
+		replicaSetOwners []models.ReplicaSetOwner
+		podQuery         = podOwnerQuery
+		replicaSetQuery  = replicaSetOwnerQuery
+		args             = []interface{}{startTime, endTime}
+	)
+
+	if namespace != "" {
+		podQuery += namespaceOwnerQuery
+		replicaSetQuery += namespaceOwnerQuery
+		args = append(args, namespace)
+	}
+	podQuery += podOwnerOrder
+	replicaSetQuery += replicaSetOwnerOrder
+
+	ctxDB := d.db.WithContext(ctx)
+	g, _ := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return ctxDB.Raw(podQuery, args...).Find(&podOwners).Error
+	})
+	g.Go(func() error {
+		return ctxDB.Raw(replicaSetQuery, args...).Find(&replicaSetOwners).Error
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return podOwners, replicaSetOwners, nil
+}
@@ -0,0 +1,34 @@
+package workload
+
+const (
+	podOwnerQuery = `SELECT DISTINCT ON (namespace_id, pod_id) 
+val(namespace_id) namespace, 
+val(pod_id) pod, 
+val(owner_kind_id) owner_kind, 
+val(owner_name_id) owner_name 
+FROM prom_metric.kube_pod_owner 
+WHERE time >= ? AND time <= ? AND value != 'NaN' `
+	replicaSetOwnerQuery = `SELECT DISTINCT ON (namespace_id, replicaset_id) 
+val(namespace_id) namespace, 
+val(replicaset_id) replicaset, 
+val(owner_kind_id) owner_kind, 
+val(owner_name_id) owner_name 
+FROM prom_metric.kube_replicaset_owner 
+WHERE time >= ? AND time <= ? AND value != 'NaN' `
+	namespaceOwnerQuery  = `AND val(namespace_id) = ? `
+	podOwnerOrder        = `ORDER BY namespace_id, pod_id, time DESC`
+	replicaSetOwnerOrder = `ORDER BY namespace_id, replicaset_id, time DESC`
+)
+
+// PromQL of the Prometheus datasource. The first verb is the label filter,
+// the second the window.
+const (
+	promPodOwnerQuery        = `max by (namespace, pod, owner_kind, owner_name) (last_over_time(kube_pod_owner{pod!=""%[1]s}[%[2]s]))`
+	promReplicaSetOwnerQuery = `max by (namespace, replicaset, owner_kind, owner_name) (last_over_time(kube_replicaset_owner{replicaset!=""%[1]s}[%[2]s]))`
+)
+
+// metrics of the OpenSearch datasource
+const (
+	osPodOwnerMetric        = "kube_pod_owner"
+	osReplicaSetOwnerMetric = "kube_replicaset_owner"
+)
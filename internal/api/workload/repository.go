@@ -0,0 +1,74 @@
+package workload
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"rightsizing-api-server/internal/datasource"
+	"rightsizing-api-server/internal/models"
+)
+
+type workloadRepository struct {
+	source Datasource
+}
+
+var _ WorkloadRepository = (*workloadRepository)(nil)
+
+// NewWorkloadRepository reads the metrics from TimescaleDB with the Promscale schema.
+func NewWorkloadRepository(db *gorm.DB) WorkloadRepository {
+	return &workloadRepository{
+		source: &promscaleDatasource{db: db},
+	}
+}
+
+// NewPrometheusWorkloadRepository reads the metrics from the Prometheus HTTP API.
+func NewPrometheusWorkloadRepository(client *datasource.PrometheusClient) WorkloadRepository {
+	return &workloadRepository{
+		source: &prometheusDatasource{client: client},
+	}
+}
+
+// NewOpenSearchWorkloadRepository reads the metric documents from OpenSearch.
+func NewOpenSearchWorkloadRepository(client *datasource.OpenSearchClient) WorkloadRepository {
+	return &workloadRepository{
+		source: &openSearchDatasource{client: client},
+	}
+}
+
+// GetOwners returns the top-level owner of every pod seen between startTime and
+// endTime, keyed by namespace and pod name. Pods created by a ReplicaSet are
+// resolved to the Deployment which owns the ReplicaSet.
+func (r *workloadRepository) GetOwners(ctx context.Context, namespace, startTime, endTime string) (map[string]Owner, error) {
+	podOwners, replicaSetOwners, err := r.source.QueryOwners(ctx, namespace, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSets := make(map[string]Owner, len(replicaSetOwners))
+	for _, rs := range replicaSetOwners {
+		replicaSets[uniquePodName(rs.Namespace, rs.ReplicaSet)] = Owner{
+			Kind: rs.OwnerKind,
+			Name: rs.OwnerName,
+		}
+	}
+
+	owners := make(map[string]Owner, len(podOwners))
+	for _, podOwner := range podOwners {
+		owners[uniquePodName(podOwner.Namespace, podOwner.Pod)] = resolveOwner(podOwner, replicaSets)
+	}
+	return owners, nil
+}
+
+func resolveOwner(podOwner models.PodOwner, replicaSets map[string]Owner) Owner {
+	switch podOwner.OwnerKind {
+	case "", "<none>":
+		return Owner{Kind: KindPod, Name: podOwner.Pod}
+	case KindReplicaSet:
+		rs, exist := replicaSets[uniquePodName(podOwner.Namespace, podOwner.OwnerName)]
+		if exist && rs.Kind == KindDeployment {
+			return rs
+		}
+	}
+	return Owner{Kind: podOwner.OwnerKind, Name: podOwner.OwnerName}
+}
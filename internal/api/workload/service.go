@@ -0,0 +1,178 @@
+package workload
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/api/common/query"
+	"rightsizing-api-server/internal/api/common/rightsizing"
+	"rightsizing-api-server/internal/api/pod"
+	"rightsizing-api-server/internal/pricing"
+)
+
+type workloadService struct {
+	recommenders  *rightsizing.Registry
+	pricing       *pricing.Pricing
+	podRepository pod.PodRepository
+	repository    WorkloadRepository
+	logger        *zap.Logger
+}
+
+var _ WorkloadService = (*workloadService)(nil)
+
+func NewWorkloadService(
+	recommenders *rightsizing.Registry,
+	pricing *pricing.Pricing,
+	podRepository pod.PodRepository,
+	r WorkloadRepository,
+	logger *zap.Logger) WorkloadService {
+	return &workloadService{
+		recommenders:  recommenders,
+		pricing:       pricing,
+		podRepository: podRepository,
+		repository:    r,
+		logger:        logger,
+	}
+}
+
+func (s *workloadService) GetAllWorkload(query query.Query) ([]*Workload, error) {
+	s.logger.Debug("rightsizing workload",
+		zap.String("id", query.ID),
+		zap.String("namespace", query.Namespace),
+		zap.String("recommender", query.Recommender),
+		zap.Time("start_time", query.StartTime),
+		zap.Time("end_time", query.EndTime))
+
+	workloads, err := s.rightsizing(query.Context(), query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workloads) == 0 {
+		return nil, commonerrors.NotFoundErr("workload", "all")
+	}
+	return workloads, nil
+}
+
+func (s *workloadService) GetWorkload(query query.Query, kind string) (*Workload, error) {
+	s.logger.Debug("rightsizing workload",
+		zap.String("id", query.ID),
+		zap.String("namespace", query.Namespace),
+		zap.String("kind", kind),
+		zap.String("workload", query.Name),
+		zap.String("recommender", query.Recommender),
+		zap.Time("start_time", query.StartTime),
+		zap.Time("end_time", query.EndTime))
+
+	workloads, err := s.rightsizing(query.Context(), query, func(owner Owner) bool {
+		return strings.EqualFold(owner.Kind, kind) && owner.Name == query.Name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workloads) == 0 {
+		return nil, commonerrors.NotFoundErr(strings.ToLower(kind), query.Name)
+	}
+	return workloads[0], nil
+}
+
+// applyCost recommends the workloads and sets their cost. The node pool of a
+// workload is the node pool of its pods if they are all in the same one.
+func (s *workloadService) applyCost(ctx context.Context, query query.Query, workloads []*Workload) error {
+	var nodePools map[string]string
+	if s.pricing.UseNodePools() {
+		var err error
+		nodePools, err = s.podRepository.GetNodePools(ctx, query.Namespace, s.pricing.NodePoolLabel,
+			query.StartTime.Format("2006-01-02T15:04:05"),
+			query.EndTime.Format("2006-01-02T15:04:05"))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, workload := range workloads {
+		workload.Recommend()
+		for i, name := range workload.Pods {
+			nodePool := pod.NodePoolOf(nodePools, workload.Namespace, name)
+			if i > 0 && nodePool != workload.NodePool {
+				workload.NodePool = ""
+				break
+			}
+			workload.NodePool = nodePool
+		}
+		workload.ApplyCost(s.pricing)
+	}
+	return nil
+}
+
+// rightsizing groups the containers of every pod in the namespace (or cluster)
+// by their owner and recommends per container name. Only the workloads of the
+// owners which match are recommended, all of them if match is nil.
+func (s *workloadService) rightsizing(ctx context.Context, query query.Query, match func(Owner) bool) ([]*Workload, error) {
+	var (
+		namespace = query.Namespace
+		startTime = query.StartTime.Format("2006-01-02T15:04:05")
+		endTime   = query.EndTime.Format("2006-01-02T15:04:05")
+	)
+
+	recommender, err := s.recommenders.Get(query.Recommender)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := s.podRepository.Query(ctx, namespace, "", startTime, endTime, query.Resolution)
+	if err != nil {
+		s.logger.Error("failed to get pod from database", zap.Error(err))
+		return nil, err
+	}
+
+	owners, err := s.repository.GetOwners(ctx, namespace, startTime, endTime)
+	if err != nil {
+		s.logger.Error("failed to get owner from database", zap.Error(err))
+		return nil, err
+	}
+
+	workloadMap := make(map[string]*Workload)
+	for _, container := range containers {
+		owner, exist := owners[uniquePodName(container.Namespace, container.Pod)]
+		if !exist {
+			owner = Owner{Kind: KindPod, Name: container.Pod}
+		}
+		if match != nil && !match(owner) {
+			continue
+		}
+		name := uniqueName(container.Namespace, owner.Kind, owner.Name)
+		if _, exist := workloadMap[name]; !exist {
+			workloadMap[name] = NewWorkload(container.Namespace, owner)
+		}
+		workloadMap[name].AddContainer(container)
+	}
+
+	workloads := make([]*Workload, 0, len(workloadMap))
+	for _, workload := range workloadMap {
+		workload.Finalize()
+		workloads = append(workloads, workload)
+	}
+	if err := Rightsizing(ctx, recommender, s.recommenders, workloads...); err != nil {
+		return nil, err
+	}
+	if err := s.applyCost(ctx, query, workloads); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Namespace != workloads[j].Namespace {
+			return workloads[i].Namespace < workloads[j].Namespace
+		}
+		if workloads[i].Kind != workloads[j].Kind {
+			return workloads[i].Kind < workloads[j].Kind
+		}
+		return workloads[i].Name < workloads[j].Name
+	})
+	return workloads, nil
+}
@@ -0,0 +1,361 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// modes of the continuous aggregate manager on startup
+const (
+	AggregatesNone = "none"
+	// report the drift of the continuous aggregates
+	AggregatesCheck = "check"
+	// create or recreate the drifted continuous aggregates and their policies
+	AggregatesSync = "sync"
+)
+
+// AggregateModes lists the modes of the continuous aggregate manager.
+var AggregateModes = []string{
+	AggregatesNone,
+	AggregatesCheck,
+	AggregatesSync,
+}
+
+// reasons of a drift
+const (
+	// the view does not exist
+	DriftMissing = "missing"
+	// the view exists but was not created by the manager, e.g. by
+	// install/timescaledb.sql, so its definition is unknown
+	DriftUnmanaged = "unmanaged"
+	// the view was created with another definition, e.g. another percentile
+	DriftDefinition = "definition"
+	// the refresh policy is missing or has other offsets or interval
+	DriftPolicy = "policy"
+	// the view was created by the manager but no metric uses it anymore
+	DriftStale = "stale"
+)
+
+const aggregateTable = "continuous_aggregate"
+
+// Resolution is a bucket width of the continuous aggregates. A bucket is
+// refreshed between StartOffset and EndOffset ago every ScheduleInterval.
+type Resolution struct {
+	// suffix of the view name
+	Name             string
+	Bucket           time.Duration
+	StartOffset      time.Duration
+	EndOffset        time.Duration
+	ScheduleInterval time.Duration
+}
+
+// Resolutions are the resolutions of the continuous aggregates of a metric
+// from the finest. The refresh window holds two buckets at least.
+var Resolutions = []Resolution{
+	{
+		Name:             "10min",
+		Bucket:           10 * time.Minute,
+		StartOffset:      time.Hour,
+		EndOffset:        10 * time.Minute,
+		ScheduleInterval: 10 * time.Minute,
+	},
+	{
+		Name:             "1h",
+		Bucket:           time.Hour,
+		StartOffset:      3 * time.Hour,
+		EndOffset:        time.Hour,
+		ScheduleInterval: time.Hour,
+	},
+	{
+		Name:             "1d",
+		Bucket:           24 * time.Hour,
+		StartOffset:      3 * 24 * time.Hour,
+		EndOffset:        24 * time.Hour,
+		ScheduleInterval: 24 * time.Hour,
+	},
+}
+
+// AggregateName returns the view of the resolution of the aggregate table of
+// the metric catalogue, which is the finest resolution. The resolution
+// suffix of the table is replaced, e.g. :container_cpu_usage:1h.
+func AggregateName(table string, resolution Resolution) string {
+	finest := Resolutions[0].Name
+	if resolution.Name == finest {
+		return table
+	}
+	if strings.HasSuffix(table, finest) {
+		return strings.TrimSuffix(table, finest) + resolution.Name
+	}
+	return table + ":" + resolution.Name
+}
+
+const (
+	toolkitExtensionQuery = `CREATE EXTENSION IF NOT EXISTS timescaledb_toolkit`
+	createAggregateQuery  = `CREATE MATERIALIZED VIEW IF NOT EXISTS %s
+WITH (timescaledb.continuous) AS
+%s`
+	dropAggregateQuery = `DROP MATERIALIZED VIEW IF EXISTS %s`
+	aggregateQuery     = `SELECT time_bucket(INTERVAL '%s', time) AS bucket,
+series_id,
+approx_percentile(%g, percentile_agg(value)) AS value
+FROM %s
+GROUP BY bucket, series_id`
+	addPolicyQuery    = `SELECT add_continuous_aggregate_policy(?::regclass, start_offset => ?::interval, end_offset => ?::interval, schedule_interval => ?::interval)`
+	removePolicyQuery = `SELECT remove_continuous_aggregate_policy(?::regclass, if_exists => true)`
+	// views of the current schema, where the repositories find them
+	aggregateViewQuery = `SELECT view_name FROM timescaledb_information.continuous_aggregates WHERE view_schema = current_schema()`
+	// offsets and interval in seconds
+	aggregatePolicyQuery = `SELECT ca.view_name,
+COALESCE(EXTRACT(EPOCH FROM (j.config->>'start_offset')::interval), 0)::bigint AS start_offset,
+COALESCE(EXTRACT(EPOCH FROM (j.config->>'end_offset')::interval), 0)::bigint AS end_offset,
+EXTRACT(EPOCH FROM j.schedule_interval)::bigint AS schedule_interval
+FROM timescaledb_information.jobs j
+JOIN timescaledb_information.continuous_aggregates ca
+ON j.hypertable_schema = ca.materialization_hypertable_schema AND j.hypertable_name = ca.materialization_hypertable_name
+WHERE j.proc_name = 'policy_refresh_continuous_aggregate' AND ca.view_schema = current_schema()`
+)
+
+// Aggregate is a continuous aggregate of the samples of a metric, the
+// percentile of the samples of every bucket.
+type Aggregate struct {
+	View string
+	// Promscale hypertable of the samples
+	Source string
+	// percentile (0~100) of the samples of a bucket
+	Percentile float64
+	Resolution Resolution
+}
+
+// NewAggregates returns the aggregates of every resolution of a metric of
+// the catalogue. The samples are in the prom_data table of the series table.
+func NewAggregates(idTable, aggregateTable string, percentile float64) []Aggregate {
+	metric := idTable[strings.LastIndex(idTable, ".")+1:]
+	aggregates := make([]Aggregate, 0, len(Resolutions))
+	for _, resolution := range Resolutions {
+		aggregates = append(aggregates, Aggregate{
+			View:       AggregateName(aggregateTable, resolution),
+			Source:     "prom_data." + quoteIdentifier(metric),
+			Percentile: percentile,
+			Resolution: resolution,
+		})
+	}
+	return aggregates
+}
+
+// Definition returns the query of the view.
+func (a Aggregate) Definition() string {
+	return fmt.Sprintf(aggregateQuery, interval(a.Resolution.Bucket), a.Percentile/100, a.Source)
+}
+
+func (a Aggregate) policy() aggregatePolicy {
+	return aggregatePolicy{
+		ViewName:         a.View,
+		StartOffset:      int64(a.Resolution.StartOffset / time.Second),
+		EndOffset:        int64(a.Resolution.EndOffset / time.Second),
+		ScheduleInterval: int64(a.Resolution.ScheduleInterval / time.Second),
+	}
+}
+
+// Drift is a difference of a continuous aggregate from its expected
+// definition.
+type Drift struct {
+	View   string `json:"view"`
+	Reason string `json:"reason"`
+}
+
+type aggregateRecord struct {
+	ViewName   string    `gorm:"column:view_name;primaryKey"`
+	Definition string    `gorm:"column:definition"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+func (aggregateRecord) TableName() string {
+	return aggregateTable
+}
+
+type aggregatePolicy struct {
+	ViewName         string `gorm:"column:view_name"`
+	StartOffset      int64  `gorm:"column:start_offset"`
+	EndOffset        int64  `gorm:"column:end_offset"`
+	ScheduleInterval int64  `gorm:"column:schedule_interval"`
+}
+
+// AggregateManager keeps the continuous aggregates of the metrics in use and
+// their refresh policies. The definitions of the views it created are
+// recorded, as TimescaleDB keeps only the rewritten query.
+type AggregateManager struct {
+	db         *gorm.DB
+	aggregates []Aggregate
+}
+
+func NewAggregateManager(db *gorm.DB, aggregates []Aggregate) *AggregateManager {
+	return &AggregateManager{
+		db:         db,
+		aggregates: aggregates,
+	}
+}
+
+// Drift reports the differences of the continuous aggregates from the
+// expected ones. The policy of a missing view is not reported.
+func (m *AggregateManager) Drift(ctx context.Context) ([]Drift, error) {
+	db := m.db.WithContext(ctx)
+
+	var views []string
+	if err := db.Raw(aggregateViewQuery).Scan(&views).Error; err != nil {
+		return nil, err
+	}
+	var policies []aggregatePolicy
+	if err := db.Raw(aggregatePolicyQuery).Scan(&policies).Error; err != nil {
+		return nil, err
+	}
+	var records []aggregateRecord
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return drift(m.aggregates, views, policies, records), nil
+}
+
+// drift compares the aggregates with the existing views, their policies and
+// the records of the views the manager created.
+func drift(aggregates []Aggregate, views []string, policies []aggregatePolicy, records []aggregateRecord) []Drift {
+	existing := make(map[string]bool, len(views))
+	for _, view := range views {
+		existing[view] = true
+	}
+	policyMap := make(map[string]aggregatePolicy, len(policies))
+	for _, policy := range policies {
+		policyMap[policy.ViewName] = policy
+	}
+	definitions := make(map[string]string, len(records))
+	for _, record := range records {
+		definitions[record.ViewName] = record.Definition
+	}
+
+	var drifts []Drift
+	expected := make(map[string]bool, len(aggregates))
+	for _, aggregate := range aggregates {
+		expected[aggregate.View] = true
+		if !existing[aggregate.View] {
+			drifts = append(drifts, Drift{View: aggregate.View, Reason: DriftMissing})
+			continue
+		}
+		if definition, exist := definitions[aggregate.View]; !exist {
+			drifts = append(drifts, Drift{View: aggregate.View, Reason: DriftUnmanaged})
+		} else if definition != aggregate.Definition() {
+			drifts = append(drifts, Drift{View: aggregate.View, Reason: DriftDefinition})
+		}
+		if policy, exist := policyMap[aggregate.View]; !exist || policy != aggregate.policy() {
+			drifts = append(drifts, Drift{View: aggregate.View, Reason: DriftPolicy})
+		}
+	}
+	for _, record := range records {
+		if !expected[record.ViewName] {
+			drifts = append(drifts, Drift{View: record.ViewName, Reason: DriftStale})
+		}
+	}
+	return drifts
+}
+
+// Sync creates the missing views, recreates the views of another definition
+// and replaces the drifted policies. It returns the drift it resolved.
+// Unmanaged views are adopted as they are, as recreating a view drops the
+// buckets of the samples past the retention of Promscale. Stale views are
+// only reported.
+func (m *AggregateManager) Sync(ctx context.Context) ([]Drift, error) {
+	drifts, err := m.Drift(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates := make(map[string]Aggregate, len(m.aggregates))
+	for _, aggregate := range m.aggregates {
+		aggregates[aggregate.View] = aggregate
+	}
+
+	db := m.db.WithContext(ctx)
+	for _, drift := range drifts {
+		if drift.Reason == DriftMissing || drift.Reason == DriftDefinition {
+			if err := db.Exec(toolkitExtensionQuery).Error; err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	// continuous aggregates can not be created in a transaction
+	for _, drift := range drifts {
+		aggregate, exist := aggregates[drift.View]
+		if !exist {
+			continue
+		}
+		switch drift.Reason {
+		case DriftDefinition:
+			if err := db.Exec(fmt.Sprintf(dropAggregateQuery, quoteIdentifier(aggregate.View))).Error; err != nil {
+				return nil, err
+			}
+			fallthrough
+		case DriftMissing:
+			if err := db.Exec(fmt.Sprintf(createAggregateQuery, quoteIdentifier(aggregate.View), aggregate.Definition())).Error; err != nil {
+				return nil, err
+			}
+			if err := m.record(ctx, aggregate); err != nil {
+				return nil, err
+			}
+			if err := m.replacePolicy(ctx, aggregate); err != nil {
+				return nil, err
+			}
+		case DriftUnmanaged:
+			if err := m.record(ctx, aggregate); err != nil {
+				return nil, err
+			}
+		case DriftPolicy:
+			if err := m.replacePolicy(ctx, aggregate); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return drifts, nil
+}
+
+func (m *AggregateManager) record(ctx context.Context, aggregate Aggregate) error {
+	return m.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).
+		Create(&aggregateRecord{
+			ViewName:   aggregate.View,
+			Definition: aggregate.Definition(),
+			UpdatedAt:  time.Now(),
+		}).
+		Error
+}
+
+func (m *AggregateManager) replacePolicy(ctx context.Context, aggregate Aggregate) error {
+	var (
+		db         = m.db.WithContext(ctx)
+		view       = quoteIdentifier(aggregate.View)
+		resolution = aggregate.Resolution
+	)
+	if err := db.Exec(removePolicyQuery, view).Error; err != nil {
+		return err
+	}
+	return db.Exec(addPolicyQuery, view,
+		interval(resolution.StartOffset),
+		interval(resolution.EndOffset),
+		interval(resolution.ScheduleInterval)).
+		Error
+}
+
+// quoteIdentifier quotes the view and table names, which hold colons.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d seconds", int64(d/time.Second))
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregateName(t *testing.T) {
+	cases := []struct {
+		table      string
+		resolution Resolution
+		want       string
+	}{
+		// the table of the catalogue is the finest resolution
+		{":container_cpu_usage:10min", Resolutions[0], ":container_cpu_usage:10min"},
+		{":container_cpu_usage:10min", Resolutions[1], ":container_cpu_usage:1h"},
+		{":container_cpu_usage:10min", Resolutions[2], ":container_cpu_usage:1d"},
+		// a table without the resolution suffix
+		{"vm_cpu_usage", Resolutions[0], "vm_cpu_usage"},
+		{"vm_cpu_usage", Resolutions[1], "vm_cpu_usage:1h"},
+	}
+	for _, c := range cases {
+		if got := AggregateName(c.table, c.resolution); got != c.want {
+			t.Errorf("AggregateName(%q, %s) = %q, want %q", c.table, c.resolution.Name, got, c.want)
+		}
+	}
+}
+
+func TestNewAggregates(t *testing.T) {
+	aggregates := NewAggregates("prom_metric.container_cpu_usage_seconds_total", ":container_cpu_usage:10min", 90)
+	if len(aggregates) != len(Resolutions) {
+		t.Fatalf("got %d aggregates, want one of every resolution", len(aggregates))
+	}
+	for idx, aggregate := range aggregates {
+		if want := AggregateName(":container_cpu_usage:10min", Resolutions[idx]); aggregate.View != want {
+			t.Errorf("got view %q, want %q", aggregate.View, want)
+		}
+		if want := `prom_data."container_cpu_usage_seconds_total"`; aggregate.Source != want {
+			t.Errorf("got source %q, want %q", aggregate.Source, want)
+		}
+	}
+
+	want := `SELECT time_bucket(INTERVAL '3600 seconds', time) AS bucket,
+series_id,
+approx_percentile(0.9, percentile_agg(value)) AS value
+FROM prom_data."container_cpu_usage_seconds_total"
+GROUP BY bucket, series_id`
+	if got := aggregates[1].Definition(); got != want {
+		t.Errorf("got definition %s, want %s", got, want)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	aggregates := NewAggregates("prom_metric.container_cpu_usage_seconds_total", ":container_cpu_usage:10min", 90)
+	fine, hour, day := aggregates[0], aggregates[1], aggregates[2]
+
+	// the policy of the hour view has another interval
+	hourPolicy := hour.policy()
+	hourPolicy.ScheduleInterval = 60
+
+	drifts := drift(aggregates,
+		[]string{fine.View, hour.View, ":memory:1h"},
+		[]aggregatePolicy{fine.policy(), hourPolicy},
+		[]aggregateRecord{
+			{ViewName: hour.View, Definition: NewAggregates("prom_metric.container_cpu_usage_seconds_total", ":container_cpu_usage:10min", 95)[1].Definition()},
+			{ViewName: ":memory:1h", Definition: "SELECT 1"},
+		},
+	)
+	want := []Drift{
+		// created by install/timescaledb.sql
+		{View: fine.View, Reason: DriftUnmanaged},
+		// created with another percentile
+		{View: hour.View, Reason: DriftDefinition},
+		{View: hour.View, Reason: DriftPolicy},
+		// the policy of a missing view is not reported
+		{View: day.View, Reason: DriftMissing},
+		{View: ":memory:1h", Reason: DriftStale},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("got drifts %+v, want %+v", drifts, want)
+	}
+}
+
+func TestDriftUpToDate(t *testing.T) {
+	aggregates := NewAggregates("prom_metric.container_cpu_usage_seconds_total", ":container_cpu_usage:10min", 90)
+
+	var (
+		views    []string
+		policies []aggregatePolicy
+		records  []aggregateRecord
+	)
+	for _, aggregate := range aggregates {
+		views = append(views, aggregate.View)
+		policies = append(policies, aggregate.policy())
+		records = append(records, aggregateRecord{ViewName: aggregate.View, Definition: aggregate.Definition()})
+	}
+	if drifts := drift(aggregates, views, policies, records); len(drifts) != 0 {
+		t.Errorf("got drifts %+v, want none", drifts)
+	}
+}
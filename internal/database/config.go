@@ -18,3 +18,10 @@ func NewConfig() (*envConfig, error) {
 	}
 	return dbConfig, nil
 }
+
+// Configured reports whether the database host is set, which is optional for
+// the datasources other than Promscale.
+func Configured() bool {
+	config, err := NewConfig()
+	return err == nil && config.Host != ""
+}
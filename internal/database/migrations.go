@@ -0,0 +1,110 @@
+package database
+
+// migrations must only be appended, applied migrations are never run again.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create forecast and recommendation results",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS forecast_result (
+task_uuid TEXT PRIMARY KEY,
+object_type TEXT NOT NULL,
+namespace TEXT NOT NULL DEFAULT '',
+name TEXT NOT NULL,
+model TEXT NOT NULL,
+start_time TIMESTAMPTZ NOT NULL,
+end_time TIMESTAMPTZ NOT NULL,
+parameters JSONB NOT NULL DEFAULT '{}',
+status TEXT NOT NULL,
+result JSONB,
+error TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+finished_at TIMESTAMPTZ)`,
+			`CREATE INDEX IF NOT EXISTS forecast_result_object_idx ON forecast_result (object_type, namespace, name, created_at DESC)`,
+			`CREATE TABLE IF NOT EXISTS recommendation_result (
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+object_type TEXT NOT NULL,
+namespace TEXT NOT NULL DEFAULT '',
+name TEXT NOT NULL,
+container TEXT NOT NULL DEFAULT '',
+resource TEXT NOT NULL,
+recommender TEXT NOT NULL,
+start_time TIMESTAMPTZ NOT NULL,
+end_time TIMESTAMPTZ NOT NULL,
+samples INTEGER NOT NULL,
+optimized_usage DOUBLE PRECISION NOT NULL,
+request DOUBLE PRECISION NOT NULL,
+"limit" DOUBLE PRECISION NOT NULL,
+current_request DOUBLE PRECISION NOT NULL,
+current_limit DOUBLE PRECISION NOT NULL)`,
+			`CREATE INDEX IF NOT EXISTS recommendation_result_object_idx ON recommendation_result (object_type, namespace, name, created_at DESC)`,
+		},
+	}, {
+		Version: 2,
+		Name:    "convert recommendation results to hypertable",
+		Statements: []string{
+			`SELECT create_hypertable('recommendation_result', 'created_at', if_not_exists => TRUE, migrate_data => TRUE)`,
+			`CREATE INDEX IF NOT EXISTS recommendation_result_container_idx ON recommendation_result (object_type, namespace, name, container, resource, created_at DESC)`,
+		},
+	}, {
+		Version: 3,
+		Name:    "add batch id to forecast results",
+		Statements: []string{
+			`ALTER TABLE forecast_result ADD COLUMN IF NOT EXISTS batch_id TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX IF NOT EXISTS forecast_result_batch_idx ON forecast_result (batch_id) WHERE batch_id != ''`,
+		},
+	}, {
+		Version: 4,
+		Name:    "create webhook subscriptions and deliveries",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS webhook_subscription (
+id BIGSERIAL PRIMARY KEY,
+url TEXT NOT NULL,
+secret TEXT NOT NULL DEFAULT '',
+events TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL)`,
+			`CREATE TABLE IF NOT EXISTS webhook_delivery (
+id BIGSERIAL PRIMARY KEY,
+subscription_id BIGINT NOT NULL REFERENCES webhook_subscription (id) ON DELETE CASCADE,
+delivery_id TEXT NOT NULL,
+event TEXT NOT NULL,
+attempt INTEGER NOT NULL,
+status_code INTEGER NOT NULL DEFAULT 0,
+error TEXT NOT NULL DEFAULT '',
+succeeded BOOLEAN NOT NULL,
+payload JSONB NOT NULL,
+created_at TIMESTAMPTZ NOT NULL)`,
+			`CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON webhook_delivery (subscription_id, created_at DESC)`,
+		},
+	}, {
+		Version: 5,
+		Name:    "create container statuses",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS container_status (
+object_type TEXT NOT NULL,
+namespace TEXT NOT NULL DEFAULT '',
+name TEXT NOT NULL,
+container TEXT NOT NULL DEFAULT '',
+resource TEXT NOT NULL,
+status TEXT NOT NULL,
+updated_at TIMESTAMPTZ NOT NULL,
+PRIMARY KEY (object_type, namespace, name, container, resource))`,
+		},
+	}, {
+		Version: 6,
+		Name:    "add options key to forecast results",
+		Statements: []string{
+			`ALTER TABLE forecast_result ADD COLUMN IF NOT EXISTS options_key TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX IF NOT EXISTS forecast_result_options_idx ON forecast_result (object_type, namespace, name, options_key, created_at DESC)`,
+		},
+	}, {
+		Version: 7,
+		Name:    "create managed continuous aggregates",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS continuous_aggregate (
+view_name TEXT PRIMARY KEY,
+definition TEXT NOT NULL,
+updated_at TIMESTAMPTZ NOT NULL)`,
+		},
+	},
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// the existing views are looked up again after this, to use the views
+// created by the aggregate manager meanwhile
+const aggregateViewsTTL = 10 * time.Minute
+
+// AggregateSelector picks the continuous aggregate which a usage query reads
+// from. The coarser views which do not exist are skipped, as they are only
+// created by the aggregate manager.
+type AggregateSelector struct {
+	db      *gorm.DB
+	lock    sync.Mutex
+	views   map[string]bool
+	updated time.Time
+}
+
+func NewAggregateSelector(db *gorm.DB) *AggregateSelector {
+	return &AggregateSelector{
+		db: db,
+	}
+}
+
+// Select returns the view of the coarsest resolution of the aggregate table
+// whose bucket is at most step, and its bucket. The aggregate table itself,
+// the finest resolution, is returned if no coarser view fits.
+func (s *AggregateSelector) Select(ctx context.Context, table string, step time.Duration) (string, time.Duration, error) {
+	view, bucket := table, Resolutions[0].Bucket
+	for _, resolution := range Resolutions[1:] {
+		if resolution.Bucket > step {
+			break
+		}
+		name := AggregateName(table, resolution)
+		exist, err := s.exists(ctx, name)
+		if err != nil {
+			return "", 0, err
+		}
+		if exist {
+			view, bucket = name, resolution.Bucket
+		}
+	}
+	return view, bucket, nil
+}
+
+func (s *AggregateSelector) exists(ctx context.Context, view string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.views == nil || time.Since(s.updated) > aggregateViewsTTL {
+		var views []string
+		if err := s.db.WithContext(ctx).Raw(aggregateViewQuery).Scan(&views).Error; err != nil {
+			return false, err
+		}
+		s.views = make(map[string]bool, len(views))
+		for _, name := range views {
+			s.views[name] = true
+		}
+		s.updated = time.Now()
+	}
+	return s.views[view], nil
+}
+
+// Downsample groups the buckets of a continuous aggregate into the buckets of
+// step, the percentile (0~100) of the values of the buckets. step is rounded
+// up to a multiple of bucket, the bucket of the aggregate, so that every
+// output bucket holds whole buckets of the same count.
+func Downsample(step, bucket time.Duration, percentile float64) func(tx *gorm.DB) *gorm.DB {
+	if bucket > 0 {
+		step = (step + bucket - 1) / bucket * bucket
+	}
+	return func(tx *gorm.DB) *gorm.DB {
+		// the output bucket is grouped by its position, as the column of the
+		// same name takes precedence in GROUP BY
+		return tx.Select("series_id, time_bucket(?::interval, bucket) AS bucket, approx_percentile(?, percentile_agg(value)) AS value",
+			interval(step), percentile/100).
+			Group("series_id, 2")
+	}
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestSelector returns a selector of the views, which are not looked up
+// until they expire.
+func newTestSelector(views ...string) *AggregateSelector {
+	s := NewAggregateSelector(nil)
+	s.views = make(map[string]bool, len(views))
+	for _, view := range views {
+		s.views[view] = true
+	}
+	s.updated = time.Now()
+	return s
+}
+
+func TestSelect(t *testing.T) {
+	const table = ":container_cpu_usage:10min"
+	cases := []struct {
+		name       string
+		views      []string
+		step       time.Duration
+		wantView   string
+		wantBucket time.Duration
+	}{
+		{"step below the coarser buckets", []string{":container_cpu_usage:1h", ":container_cpu_usage:1d"}, 30 * time.Minute, table, 10 * time.Minute},
+		{"step of the bucket", []string{":container_cpu_usage:1h", ":container_cpu_usage:1d"}, time.Hour, ":container_cpu_usage:1h", time.Hour},
+		{"coarsest fitting view", []string{":container_cpu_usage:1h", ":container_cpu_usage:1d"}, 7 * 24 * time.Hour, ":container_cpu_usage:1d", 24 * time.Hour},
+		{"missing coarsest view", []string{":container_cpu_usage:1h"}, 7 * 24 * time.Hour, ":container_cpu_usage:1h", time.Hour},
+		{"missing finer view", []string{":container_cpu_usage:1d"}, 7 * 24 * time.Hour, ":container_cpu_usage:1d", 24 * time.Hour},
+		{"missing finer view below the coarser bucket", []string{":container_cpu_usage:1d"}, 2 * time.Hour, table, 10 * time.Minute},
+		{"no view", nil, 7 * 24 * time.Hour, table, 10 * time.Minute},
+	}
+	for _, c := range cases {
+		view, bucket, err := newTestSelector(c.views...).Select(context.Background(), table, c.step)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if view != c.wantView || bucket != c.wantBucket {
+			t.Errorf("%s: got %q of %s, want %q of %s", c.name, view, bucket, c.wantView, c.wantBucket)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		step, bucket time.Duration
+		want         string
+	}{
+		// rounded up to whole buckets
+		{25 * time.Minute, 10 * time.Minute, "1800 seconds"},
+		{time.Hour, 10 * time.Minute, "3600 seconds"},
+		{90 * time.Minute, time.Hour, "7200 seconds"},
+		{time.Minute, 24 * time.Hour, "86400 seconds"},
+		// the bucket of the aggregate is unknown
+		{25 * time.Minute, 0, "1500 seconds"},
+	}
+	for _, c := range cases {
+		var rows []map[string]interface{}
+		stmt := db.Table("aggregate").Scopes(Downsample(c.step, c.bucket, 95)).Find(&rows).Statement
+		if want := []interface{}{c.want, 0.95}; !reflect.DeepEqual(stmt.Vars, want) {
+			t.Errorf("Downsample(%s, %s): got vars %v, want %v", c.step, c.bucket, stmt.Vars, want)
+		}
+	}
+}
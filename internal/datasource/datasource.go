@@ -0,0 +1,73 @@
+package datasource
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metrics backends which the usage and quota are read from
+const (
+	// TimescaleDB with the Promscale schema and continuous aggregates
+	Promscale = "promscale"
+	// Prometheus HTTP API, e.g. Prometheus or Thanos Query
+	Prometheus = "prometheus"
+	// metric documents of OpenSearch or Elasticsearch
+	OpenSearch = "opensearch"
+)
+
+// Names lists the supported metrics backends.
+var Names = []string{
+	Promscale,
+	Prometheus,
+	OpenSearch,
+}
+
+// TimeLayout is the layout of the query range passed to the repositories.
+const TimeLayout = "2006-01-02T15:04:05"
+
+// ParseTime parses a time of the query range in the local time zone, where it
+// was formatted.
+func ParseTime(value string) (time.Time, error) {
+	return time.ParseInLocation(TimeLayout, value, time.Local)
+}
+
+// ParseRange parses the start and the end of the query range.
+func ParseRange(startTime, endTime string) (time.Time, time.Time, error) {
+	start, err := ParseTime(startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := ParseTime(endTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// LabelFilter returns the label matchers of labels to append to a selector
+// which has matchers already, e.g. `,namespace="default"`.
+func LabelFilter(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filter strings.Builder
+	for _, key := range keys {
+		filter.WriteString(fmt.Sprintf(",%s=%s", key, strconv.Quote(labels[key])))
+	}
+	return filter.String()
+}
+
+// Duration formats d as a PromQL duration in seconds, at least one second.
+func Duration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
@@ -0,0 +1,428 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+
+	"rightsizing-api-server/internal/models"
+)
+
+const (
+	// default percentile of the samples in a histogram bucket, the same as
+	// the continuous aggregates
+	histogramPercentile = 90
+	// buckets of a composite aggregation page
+	compositeSize = 1000
+)
+
+// OpenSearchOptions configures the OpenSearch (or Elasticsearch) client. The
+// documents hold samples of the Prometheus metrics like the prometheus module
+// of Metricbeat writes them, the labels and the values in fields named by the
+// label and the metric. The label fields must be keywords.
+type OpenSearchOptions struct {
+	// base URL of the REST API, e.g. https://opensearch:9200
+	URL string
+	// index or index pattern of the documents
+	Index          string
+	TimestampField string
+	// prefix of the label fields, e.g. prometheus.labels.namespace
+	LabelField string
+	// prefix of the value fields, e.g. prometheus.metrics.container_memory_working_set_bytes
+	MetricField string
+	// size of the histogram buckets, the same as the continuous aggregates
+	Interval time.Duration
+	// deadline of a search
+	Timeout time.Duration
+	// skip the verification of the server certificate
+	InsecureSkipVerify bool
+	// basic auth credentials, read from the environment
+	Username string `env:"OPENSEARCH_USERNAME"`
+	Password string `env:"OPENSEARCH_PASSWORD,unset"`
+}
+
+func DefaultOpenSearchOptions() OpenSearchOptions {
+	return OpenSearchOptions{
+		URL:            "http://localhost:9200",
+		Index:          "metricbeat-*",
+		TimestampField: "@timestamp",
+		LabelField:     "prometheus.labels.",
+		MetricField:    "prometheus.metrics.",
+		Interval:       10 * time.Minute,
+		Timeout:        time.Minute,
+	}
+}
+
+func (o OpenSearchOptions) Validate() error {
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("opensearch url %s must be http or https", o.URL)
+	}
+	if o.Index == "" || o.TimestampField == "" {
+		return errors.New("opensearch index and timestamp field must be present")
+	}
+	if o.Interval <= 0 || o.Timeout <= 0 {
+		return errors.New("opensearch interval and timeout must be positive")
+	}
+	return nil
+}
+
+// OpenSearchQuery selects the samples of a metric between Start and End.
+type OpenSearchQuery struct {
+	Metric string
+	// labels which the samples are grouped into series by. Samples without
+	// one of the labels are left out.
+	GroupBy []string
+	// label values the samples must have
+	Match map[string]string
+	// label values the samples must not have
+	Exclude map[string][]string
+	// percentile (0~100) of the samples of a histogram interval, 90 if 0
+	Percentile float64
+	// interval of the histogram, the configured one if shorter
+	Interval time.Duration
+	Start    time.Time
+	End      time.Time
+}
+
+// OpenSearchClient searches the metric documents of an OpenSearch index.
+type OpenSearchClient struct {
+	url     string
+	client  *http.Client
+	options OpenSearchOptions
+}
+
+// NewOpenSearchClient reads the credentials from the environment.
+func NewOpenSearchClient(options OpenSearchOptions) (*OpenSearchClient, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if err := env.Parse(&options); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &OpenSearchClient{
+		url: strings.TrimSuffix(options.URL, "/"),
+		client: &http.Client{
+			Timeout:   options.Timeout,
+			Transport: transport,
+		},
+		options: options,
+	}, nil
+}
+
+// Histogram returns the series of the query with a point per interval, the
+// percentile of the samples in the interval. Intervals without samples
+// have no point.
+func (c *OpenSearchClient) Histogram(ctx context.Context, query OpenSearchQuery) ([]Series, error) {
+	const bucket = "@bucket"
+
+	interval := c.options.Interval
+	if query.Interval > interval {
+		interval = query.Interval
+	}
+	sources := c.sources(query.GroupBy)
+	sources = append(sources, map[string]interface{}{
+		bucket: map[string]interface{}{
+			"date_histogram": map[string]interface{}{
+				"field":          c.options.TimestampField,
+				"fixed_interval": Duration(interval),
+			},
+		},
+	})
+	percentile := query.Percentile
+	if percentile == 0 {
+		percentile = histogramPercentile
+	}
+	aggs := map[string]interface{}{
+		"value": map[string]interface{}{
+			"percentiles": map[string]interface{}{
+				"field":    c.metricField(query.Metric),
+				"percents": []float64{percentile},
+				"keyed":    false,
+			},
+		},
+	}
+
+	var (
+		series []Series
+		last   string
+	)
+	err := c.composite(ctx, query, sources, aggs, func(b compositeBucket) error {
+		var percentiles struct {
+			Values []struct {
+				Value *float64 `json:"value"`
+			} `json:"values"`
+		}
+		if err := json.Unmarshal(b.Aggs["value"], &percentiles); err != nil {
+			return err
+		}
+		if len(percentiles.Values) == 0 || percentiles.Values[0].Value == nil {
+			return nil
+		}
+		timestamp, ok := b.Key[bucket].(float64)
+		if !ok {
+			return fmt.Errorf("invalid histogram bucket %v", b.Key[bucket])
+		}
+
+		// the buckets of a series are adjacent as the buckets are sorted by
+		// the labels first
+		labels := bucketLabels(b.Key, query.GroupBy)
+		if key := seriesKey(labels, query.GroupBy); len(series) == 0 || key != last {
+			series = append(series, Series{Labels: labels})
+			last = key
+		}
+		s := &series[len(series)-1]
+		s.Points = append(s.Points, models.TimeSeriesDatapoint{
+			Time:  time.Unix(0, int64(timestamp)*int64(time.Millisecond)),
+			Value: *percentiles.Values[0].Value,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Latest returns the series of the query with a single point, the latest
+// sample of the series.
+func (c *OpenSearchClient) Latest(ctx context.Context, query OpenSearchQuery) ([]Series, error) {
+	field := c.metricField(query.Metric)
+	aggs := map[string]interface{}{
+		"latest": map[string]interface{}{
+			"top_hits": map[string]interface{}{
+				"size":            1,
+				"_source":         false,
+				"docvalue_fields": []string{field},
+				"sort": []interface{}{
+					map[string]interface{}{
+						c.options.TimestampField: map[string]interface{}{"order": "desc"},
+					},
+				},
+			},
+		},
+	}
+
+	var series []Series
+	err := c.composite(ctx, query, c.sources(query.GroupBy), aggs, func(b compositeBucket) error {
+		var latest struct {
+			Hits struct {
+				Hits []struct {
+					Fields map[string][]float64 `json:"fields"`
+					Sort   []float64            `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := json.Unmarshal(b.Aggs["latest"], &latest); err != nil {
+			return err
+		}
+		if len(latest.Hits.Hits) == 0 {
+			return nil
+		}
+		hit := latest.Hits.Hits[0]
+		if len(hit.Fields[field]) == 0 || len(hit.Sort) == 0 {
+			return nil
+		}
+
+		series = append(series, Series{
+			Labels: bucketLabels(b.Key, query.GroupBy),
+			Points: []models.TimeSeriesDatapoint{{
+				Time:  time.Unix(0, int64(hit.Sort[0])*int64(time.Millisecond)),
+				Value: hit.Fields[field][0],
+			}},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Interval returns the size of the histogram buckets.
+func (c *OpenSearchClient) Interval() time.Duration {
+	return c.options.Interval
+}
+
+type compositeBucket struct {
+	Key  map[string]interface{}
+	Aggs map[string]json.RawMessage
+}
+
+// composite pages through the buckets of a composite aggregation of sources
+// with the sub aggregations aggs over the documents of the query.
+func (c *OpenSearchClient) composite(ctx context.Context, query OpenSearchQuery, sources []interface{}, aggs map[string]interface{}, handle func(compositeBucket) error) error {
+	var after map[string]interface{}
+	for {
+		composite := map[string]interface{}{
+			"size":    compositeSize,
+			"sources": sources,
+		}
+		if after != nil {
+			composite["after"] = after
+		}
+		body := map[string]interface{}{
+			"size":  0,
+			"query": c.filter(query),
+			"aggs": map[string]interface{}{
+				"series": map[string]interface{}{
+					"composite": composite,
+					"aggs":      aggs,
+				},
+			},
+		}
+
+		var response struct {
+			Aggregations struct {
+				Series struct {
+					AfterKey map[string]interface{}       `json:"after_key"`
+					Buckets  []map[string]json.RawMessage `json:"buckets"`
+				} `json:"series"`
+			} `json:"aggregations"`
+		}
+		if err := c.search(ctx, body, &response); err != nil {
+			return err
+		}
+
+		buckets := response.Aggregations.Series.Buckets
+		for _, raw := range buckets {
+			b := compositeBucket{Aggs: raw}
+			if err := json.Unmarshal(raw["key"], &b.Key); err != nil {
+				return err
+			}
+			if err := handle(b); err != nil {
+				return err
+			}
+		}
+		after = response.Aggregations.Series.AfterKey
+		if len(buckets) < compositeSize || after == nil {
+			return nil
+		}
+	}
+}
+
+func (c *OpenSearchClient) search(ctx context.Context, body interface{}, response interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s/_search", c.url, url.PathEscape(c.options.Index)), bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if c.options.Username != "" {
+		request.SetBasicAuth(c.options.Username, c.options.Password)
+	}
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error.Reason == "" {
+			return fmt.Errorf("opensearch search: %s", resp.Status)
+		}
+		return fmt.Errorf("opensearch search: %s: %s", failure.Error.Type, failure.Error.Reason)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// filter selects the samples of the metric in the time range with the labels.
+func (c *OpenSearchClient) filter(q OpenSearchQuery) map[string]interface{} {
+	filters := []interface{}{
+		map[string]interface{}{
+			"exists": map[string]interface{}{"field": c.metricField(q.Metric)},
+		},
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				c.options.TimestampField: map[string]interface{}{
+					"gte":    q.Start.UnixNano() / int64(time.Millisecond),
+					"lte":    q.End.UnixNano() / int64(time.Millisecond),
+					"format": "epoch_millis",
+				},
+			},
+		},
+	}
+	for label, value := range q.Match {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{c.labelField(label): value},
+		})
+	}
+
+	query := map[string]interface{}{
+		"filter": filters,
+	}
+	if len(q.Exclude) > 0 {
+		excludes := make([]interface{}, 0, len(q.Exclude))
+		for label, values := range q.Exclude {
+			excludes = append(excludes, map[string]interface{}{
+				"terms": map[string]interface{}{c.labelField(label): values},
+			})
+		}
+		query["must_not"] = excludes
+	}
+	return map[string]interface{}{"bool": query}
+}
+
+func (c *OpenSearchClient) sources(labels []string) []interface{} {
+	sources := make([]interface{}, 0, len(labels)+1)
+	for _, label := range labels {
+		sources = append(sources, map[string]interface{}{
+			label: map[string]interface{}{
+				"terms": map[string]interface{}{"field": c.labelField(label)},
+			},
+		})
+	}
+	return sources
+}
+
+func (c *OpenSearchClient) labelField(label string) string {
+	return c.options.LabelField + label
+}
+
+func (c *OpenSearchClient) metricField(metric string) string {
+	return c.options.MetricField + metric
+}
+
+func bucketLabels(key map[string]interface{}, labels []string) map[string]string {
+	values := make(map[string]string, len(labels))
+	for _, label := range labels {
+		values[label] = fmt.Sprint(key[label])
+	}
+	return values
+}
+
+func seriesKey(labels map[string]string, names []string) string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = labels[name]
+	}
+	return strings.Join(values, "\x00")
+}
@@ -0,0 +1,313 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rightsizing-api-server/internal/models"
+)
+
+// fakeOpenSearch records the search requests and responds with the pages in
+// order, the last one once they run out.
+type fakeOpenSearch struct {
+	t     *testing.T
+	mu    sync.Mutex
+	pages []string
+	// decoded request bodies and basic auth credentials
+	requests []map[string]interface{}
+	auth     []string
+}
+
+func (f *fakeOpenSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/metricbeat-*/_search" {
+		f.t.Errorf("got %s %s, want POST /metricbeat-*/_search", r.Method, r.URL.Path)
+	}
+	buf, _ := ioutil.ReadAll(r.Body)
+	var body map[string]interface{}
+	if err := json.Unmarshal(buf, &body); err != nil {
+		f.t.Error(err)
+	}
+	user, password, _ := r.BasicAuth()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	page := f.pages[len(f.pages)-1]
+	if len(f.requests) < len(f.pages) {
+		page = f.pages[len(f.requests)]
+	}
+	f.requests = append(f.requests, body)
+	f.auth = append(f.auth, user+":"+password)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(page))
+}
+
+func newTestOpenSearch(t *testing.T, pages ...string) (*OpenSearchClient, *fakeOpenSearch, func()) {
+	t.Helper()
+
+	fake := &fakeOpenSearch{t: t, pages: pages}
+	server := httptest.NewServer(fake)
+
+	options := DefaultOpenSearchOptions()
+	options.URL = server.URL + "/"
+	client, err := NewOpenSearchClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, fake, server.Close
+}
+
+// compositePage returns a search response of the composite aggregation with
+// the buckets, and the after key unless it is nil.
+func compositePage(afterKey map[string]interface{}, buckets ...string) string {
+	after := ""
+	if afterKey != nil {
+		buf, _ := json.Marshal(afterKey)
+		after = fmt.Sprintf(`"after_key": %s,`, buf)
+	}
+	return fmt.Sprintf(`{"aggregations": {"series": {%s "buckets": [%s]}}}`, after, strings.Join(buckets, ","))
+}
+
+func histogramBucket(pod string, timestamp int64, value string) string {
+	return fmt.Sprintf(`{"key": {"namespace": "default", "pod": %q, "@bucket": %d},
+		"doc_count": 10, "value": {"values": [{"key": 90.0, "value": %s}]}}`, pod, timestamp, value)
+}
+
+// compositeRequest returns the composite aggregation of a search request.
+func compositeRequest(t *testing.T, body map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	series, _ := body["aggs"].(map[string]interface{})["series"].(map[string]interface{})
+	composite, ok := series["composite"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no composite aggregation in %v", body)
+	}
+	return composite
+}
+
+// The pages of the composite aggregation are followed with the after key, and
+// the buckets of a series which continue on the next page are kept together.
+func TestHistogramPagination(t *testing.T) {
+	var (
+		start = time.Unix(1700000000, 0)
+		first []string
+	)
+	// a full page ends with the first bucket of redis
+	for i := 0; i < compositeSize-1; i++ {
+		first = append(first, histogramBucket("nginx", (start.Unix()+int64(i)*600)*1000, "0.5"))
+	}
+	first = append(first, histogramBucket("redis", start.Unix()*1000, "1"))
+	afterKey := map[string]interface{}{"namespace": "default", "pod": "redis", "@bucket": float64(start.Unix() * 1000)}
+
+	client, fake, stop := newTestOpenSearch(t,
+		compositePage(afterKey, first...),
+		compositePage(nil,
+			histogramBucket("redis", (start.Unix()+600)*1000, "2"),
+			// a bucket without samples has no percentile
+			histogramBucket("redis", (start.Unix()+1200)*1000, "null"),
+		),
+	)
+	defer stop()
+
+	series, err := client.Histogram(context.Background(), OpenSearchQuery{
+		Metric:  "container_memory_working_set_bytes",
+		GroupBy: []string{"namespace", "pod"},
+		Match:   map[string]string{"namespace": "default"},
+		Start:   start,
+		End:     start.Add(7 * 24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(fake.requests))
+	}
+	if _, exist := compositeRequest(t, fake.requests[0])["after"]; exist {
+		t.Error("the first page has an after key")
+	}
+	if after := compositeRequest(t, fake.requests[1])["after"]; !reflect.DeepEqual(after, afterKey) {
+		t.Errorf("got after %v, want the after key %v", after, afterKey)
+	}
+
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want nginx and redis", len(series))
+	}
+	nginx, redis := series[0], series[1]
+	if want := map[string]string{"namespace": "default", "pod": "nginx"}; !reflect.DeepEqual(nginx.Labels, want) {
+		t.Errorf("got labels %v, want %v", nginx.Labels, want)
+	}
+	if len(nginx.Points) != compositeSize-1 {
+		t.Errorf("got %d points of nginx, want %d", len(nginx.Points), compositeSize-1)
+	}
+	wantRedis := []models.TimeSeriesDatapoint{
+		{Time: start, Value: 1},
+		{Time: start.Add(10 * time.Minute), Value: 2},
+	}
+	if !reflect.DeepEqual(redis.Points, wantRedis) {
+		t.Errorf("got redis %+v, want %+v", redis.Points, wantRedis)
+	}
+}
+
+func TestHistogramRequest(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	client, fake, stop := newTestOpenSearch(t, compositePage(nil))
+	defer stop()
+
+	_, err := client.Histogram(context.Background(), OpenSearchQuery{
+		Metric:     "container_memory_working_set_bytes",
+		GroupBy:    []string{"namespace", "pod", "container"},
+		Match:      map[string]string{"namespace": "default"},
+		Exclude:    map[string][]string{"container": {"", "POD"}},
+		Percentile: 95,
+		Interval:   time.Hour,
+		Start:      start,
+		End:        start.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want map[string]interface{}
+	err = json.Unmarshal([]byte(`{
+		"size": 0,
+		"query": {"bool": {
+			"filter": [
+				{"exists": {"field": "prometheus.metrics.container_memory_working_set_bytes"}},
+				{"range": {"@timestamp": {"gte": 1700000000000, "lte": 1700003600000, "format": "epoch_millis"}}},
+				{"term": {"prometheus.labels.namespace": "default"}}
+			],
+			"must_not": [
+				{"terms": {"prometheus.labels.container": ["", "POD"]}}
+			]
+		}},
+		"aggs": {"series": {
+			"composite": {
+				"size": 1000,
+				"sources": [
+					{"namespace": {"terms": {"field": "prometheus.labels.namespace"}}},
+					{"pod": {"terms": {"field": "prometheus.labels.pod"}}},
+					{"container": {"terms": {"field": "prometheus.labels.container"}}},
+					{"@bucket": {"date_histogram": {"field": "@timestamp", "fixed_interval": "3600s"}}}
+				]
+			},
+			"aggs": {"value": {"percentiles": {
+				"field": "prometheus.metrics.container_memory_working_set_bytes",
+				"percents": [95],
+				"keyed": false
+			}}}
+		}}
+	}`), &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fake.requests[0], want) {
+		got, _ := json.Marshal(fake.requests[0])
+		t.Errorf("got request %s", got)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	field := "prometheus.metrics.kube_pod_container_resource_requests"
+	client, _, stop := newTestOpenSearch(t, compositePage(nil,
+		fmt.Sprintf(`{"key": {"pod": "nginx", "resource": "cpu"}, "doc_count": 3, "latest": {"hits": {"hits": [
+			{"_id": "1", "sort": [1700000000000], "fields": {%q: [0.5]}}
+		]}}}`, field),
+		// documents without the value are left out
+		`{"key": {"pod": "nginx", "resource": "memory"}, "doc_count": 1, "latest": {"hits": {"hits": [
+			{"_id": "2", "sort": [1700000000000], "fields": {}}
+		]}}}`,
+		`{"key": {"pod": "redis", "resource": "cpu"}, "doc_count": 0, "latest": {"hits": {"hits": []}}}`,
+	))
+	defer stop()
+
+	series, err := client.Latest(context.Background(), OpenSearchQuery{
+		Metric:  "kube_pod_container_resource_requests",
+		GroupBy: []string{"pod", "resource"},
+		Start:   time.Unix(1699999700, 0),
+		End:     time.Unix(1700000000, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Series{
+		{
+			Labels: map[string]string{"pod": "nginx", "resource": "cpu"},
+			Points: []models.TimeSeriesDatapoint{{Time: time.Unix(1700000000, 0), Value: 0.5}},
+		},
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("got %+v, want %+v", series, want)
+	}
+}
+
+func TestOpenSearchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"type": "search_phase_execution_exception", "reason": "all shards failed"}, "status": 400}`))
+	}))
+	defer server.Close()
+
+	options := DefaultOpenSearchOptions()
+	options.URL = server.URL
+	client, err := NewOpenSearchClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Latest(context.Background(), OpenSearchQuery{Metric: "up"})
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{"search_phase_execution_exception", "all shards failed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+// The credentials are read from the environment and the password is unset
+// once read.
+func TestOpenSearchCredentials(t *testing.T) {
+	t.Setenv("OPENSEARCH_USERNAME", "rightsizing")
+	t.Setenv("OPENSEARCH_PASSWORD", "secret")
+
+	client, fake, stop := newTestOpenSearch(t, compositePage(nil))
+	defer stop()
+
+	if _, exist := os.LookupEnv("OPENSEARCH_PASSWORD"); exist {
+		t.Error("the password is left in the environment")
+	}
+	if _, err := client.Latest(context.Background(), OpenSearchQuery{Metric: "up"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.auth[0]; got != "rightsizing:secret" {
+		t.Errorf("got basic auth %q, want rightsizing:secret", got)
+	}
+}
+
+func TestOpenSearchWithoutCredentials(t *testing.T) {
+	t.Setenv("OPENSEARCH_USERNAME", "")
+	t.Setenv("OPENSEARCH_PASSWORD", "")
+
+	client, fake, stop := newTestOpenSearch(t, compositePage(nil))
+	defer stop()
+
+	if _, err := client.Latest(context.Background(), OpenSearchQuery{Metric: "up"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.auth[0]; got != ":" {
+		t.Errorf("got basic auth %q, want none", got)
+	}
+}
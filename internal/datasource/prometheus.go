@@ -0,0 +1,207 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"rightsizing-api-server/internal/models"
+)
+
+// maxPoints is the most points of a series Prometheus returns for a range
+// query, which rejects the queries of a finer step.
+const maxPoints = 11000
+
+// PrometheusOptions configures the Prometheus HTTP API client.
+type PrometheusOptions struct {
+	// base URL of the HTTP API, e.g. http://prometheus:9090
+	URL string
+	// resolution of the range queries, the same as the continuous aggregates
+	Step time.Duration
+	// deadline of a query
+	Timeout time.Duration
+}
+
+func DefaultPrometheusOptions() PrometheusOptions {
+	return PrometheusOptions{
+		URL:     "http://localhost:9090",
+		Step:    10 * time.Minute,
+		Timeout: time.Minute,
+	}
+}
+
+func (o PrometheusOptions) Validate() error {
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("prometheus url %s must be http or https", o.URL)
+	}
+	if o.Step <= 0 || o.Timeout <= 0 {
+		return errors.New("prometheus step and timeout must be positive")
+	}
+	return nil
+}
+
+// Series is a time series of a query result. The NaN samples are dropped,
+// like the NaN rows are filtered out of TimescaleDB.
+type Series struct {
+	Labels map[string]string
+	Points []models.TimeSeriesDatapoint
+}
+
+// PrometheusClient queries the Prometheus HTTP API.
+type PrometheusClient struct {
+	url     string
+	client  *http.Client
+	options PrometheusOptions
+}
+
+func NewPrometheusClient(options PrometheusOptions) (*PrometheusClient, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	return &PrometheusClient{
+		url: strings.TrimSuffix(options.URL, "/"),
+		client: &http.Client{
+			Timeout: options.Timeout,
+		},
+		options: options,
+	}, nil
+}
+
+// RangeStep returns the step of a range query from start to end, at least the
+// configured step and coarse enough to keep to maxPoints.
+func (c *PrometheusClient) RangeStep(start, end time.Time, step time.Duration) time.Duration {
+	if step < c.options.Step {
+		step = c.options.Step
+	}
+	// rounded up to the seconds of the step parameter
+	min := (end.Sub(start) + maxPoints - 1) / maxPoints
+	if min = (min + time.Second - 1).Truncate(time.Second); step < min {
+		step = min
+	}
+	return step
+}
+
+// QueryRange evaluates query from start to end every step, see RangeStep.
+func (c *PrometheusClient) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	step = c.RangeStep(start, end, step)
+	form := url.Values{
+		"query": {query},
+		"start": {formatTime(start)},
+		"end":   {formatTime(end)},
+		"step":  {Duration(step)},
+	}
+	return c.do(ctx, "/api/v1/query_range", form)
+}
+
+// Query evaluates query at t. Every series has a single point.
+func (c *PrometheusClient) Query(ctx context.Context, query string, t time.Time) ([]Series, error) {
+	form := url.Values{
+		"query": {query},
+		"time":  {formatTime(t)},
+	}
+	return c.do(ctx, "/api/v1/query", form)
+}
+
+// response of the HTTP API, see https://prometheus.io/docs/prometheus/latest/querying/api/
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			// matrix
+			Values [][]interface{} `json:"values"`
+			// vector
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (c *PrometheusClient) do(ctx context.Context, path string, form url.Values) ([]Series, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var body prometheusResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("prometheus %s: %s", path, response.Status)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("prometheus %s: %s: %s", path, body.ErrorType, body.Error)
+	}
+
+	series := make([]Series, 0, len(body.Data.Result))
+	for _, result := range body.Data.Result {
+		values := result.Values
+		if body.Data.ResultType == "vector" {
+			values = [][]interface{}{result.Value}
+		}
+
+		s := Series{
+			Labels: result.Metric,
+			Points: make([]models.TimeSeriesDatapoint, 0, len(values)),
+		}
+		for _, value := range values {
+			point, err := parseSample(value)
+			if err != nil {
+				return nil, fmt.Errorf("prometheus %s: %w", path, err)
+			}
+			if math.IsNaN(point.Value) {
+				continue
+			}
+			s.Points = append(s.Points, point)
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+// parseSample parses a [timestamp, "value"] pair.
+func parseSample(sample []interface{}) (models.TimeSeriesDatapoint, error) {
+	if len(sample) != 2 {
+		return models.TimeSeriesDatapoint{}, fmt.Errorf("invalid sample %v", sample)
+	}
+	timestamp, ok := sample[0].(float64)
+	if !ok {
+		return models.TimeSeriesDatapoint{}, fmt.Errorf("invalid sample timestamp %v", sample[0])
+	}
+	text, ok := sample[1].(string)
+	if !ok {
+		return models.TimeSeriesDatapoint{}, fmt.Errorf("invalid sample value %v", sample[1])
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return models.TimeSeriesDatapoint{}, err
+	}
+
+	seconds, fraction := math.Modf(timestamp)
+	return models.TimeSeriesDatapoint{
+		Time:  time.Unix(int64(seconds), int64(fraction*1e9)),
+		Value: value,
+	}, nil
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
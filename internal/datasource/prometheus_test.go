@@ -0,0 +1,189 @@
+package datasource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rightsizing-api-server/internal/models"
+)
+
+// newTestClient returns a client of a fake Prometheus which checks the form
+// of the request and responds with body.
+func newTestClient(t *testing.T, path string, form map[string]string, status int, body string) (*PrometheusClient, func()) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("got path %s, want %s", r.URL.Path, path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		for key, value := range form {
+			if got := r.PostForm.Get(key); got != value {
+				t.Errorf("got %s %q, want %q", key, got, value)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	options := DefaultPrometheusOptions()
+	options.URL = server.URL + "/"
+	client, err := NewPrometheusClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server.Close
+}
+
+func TestQueryRangeMatrix(t *testing.T) {
+	var (
+		start = time.Unix(1700000000, 0)
+		end   = start.Add(time.Hour)
+	)
+	client, stop := newTestClient(t, "/api/v1/query_range", map[string]string{
+		"query": `up{job="node"}`,
+		"start": "1700000000",
+		"end":   "1700003600",
+		"step":  "1800s",
+	}, http.StatusOK, `{
+		"status": "success",
+		"data": {
+			"resultType": "matrix",
+			"result": [
+				{
+					"metric": {"namespace": "default", "pod": "nginx"},
+					"values": [[1700000000, "1"], [1700001800.5, "NaN"], [1700003600, "2.5"]]
+				},
+				{
+					"metric": {"namespace": "default", "pod": "redis"},
+					"values": []
+				}
+			]
+		}
+	}`)
+	defer stop()
+
+	series, err := client.QueryRange(context.Background(), `up{job="node"}`, start, end, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Series{
+		{
+			Labels: map[string]string{"namespace": "default", "pod": "nginx"},
+			// the NaN sample is dropped
+			Points: []models.TimeSeriesDatapoint{
+				{Time: time.Unix(1700000000, 0), Value: 1},
+				{Time: time.Unix(1700003600, 0), Value: 2.5},
+			},
+		},
+		{
+			Labels: map[string]string{"namespace": "default", "pod": "redis"},
+			Points: []models.TimeSeriesDatapoint{},
+		},
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("got %+v, want %+v", series, want)
+	}
+}
+
+func TestQueryVector(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	client, stop := newTestClient(t, "/api/v1/query", map[string]string{
+		"query": "kube_pod_info",
+		"time":  "1700000000",
+	}, http.StatusOK, `{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [
+				{"metric": {"pod": "nginx", "node": "node-1"}, "value": [1700000000.25, "1"]}
+			]
+		}
+	}`)
+	defer stop()
+
+	series, err := client.Query(context.Background(), "kube_pod_info", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Series{
+		{
+			Labels: map[string]string{"pod": "nginx", "node": "node-1"},
+			Points: []models.TimeSeriesDatapoint{
+				{Time: time.Unix(1700000000, 250000000), Value: 1},
+			},
+		},
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("got %+v, want %+v", series, want)
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	client, stop := newTestClient(t, "/api/v1/query", nil, http.StatusBadRequest, `{
+		"status": "error",
+		"errorType": "bad_data",
+		"error": "parse error at char 5: unclosed left parenthesis"
+	}`)
+	defer stop()
+
+	_, err := client.Query(context.Background(), "sum((", time.Now())
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{"bad_data", "unclosed left parenthesis"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestQueryErrorNotJSON(t *testing.T) {
+	client, stop := newTestClient(t, "/api/v1/query", nil, http.StatusBadGateway, "bad gateway")
+	defer stop()
+
+	_, err := client.Query(context.Background(), "up", time.Now())
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("got error %v, want the status", err)
+	}
+}
+
+func TestRangeStep(t *testing.T) {
+	client, err := NewPrometheusClient(DefaultPrometheusOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		window time.Duration
+		step   time.Duration
+		want   time.Duration
+	}{
+		// at least the configured step
+		{window: 24 * time.Hour, step: 0, want: 10 * time.Minute},
+		{window: 24 * time.Hour, step: time.Minute, want: 10 * time.Minute},
+		{window: 24 * time.Hour, step: time.Hour, want: time.Hour},
+		// 90 days at 10m would be 12,960 points
+		{window: 90 * 24 * time.Hour, step: 0, want: 707 * time.Second},
+	}
+	for _, test := range tests {
+		step := client.RangeStep(start, start.Add(test.window), test.step)
+		if step != test.want {
+			t.Errorf("window %v step %v: got %v, want %v", test.window, test.step, step, test.want)
+		}
+		if points := test.window / step; points > maxPoints {
+			t.Errorf("window %v step %v: %d points exceed the limit", test.window, test.step, points)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package grpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned instead of calling the analysis server while it
+// is considered down.
+var ErrCircuitOpen = errors.New("analysis server is unavailable, circuit breaker is open")
+
+// IsUnavailable reports whether err means that the analysis server could not
+// be reached or did not answer in time, as opposed to a failure of the request.
+// The deadline of a call is that of the call itself, the deadline of the
+// caller is not matched.
+func IsUnavailable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// breaker opens after threshold consecutive unavailable failures and rejects
+// the calls for the cooldown. Then one call at a time is let through to probe
+// the server (half open), whose success closes the breaker again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	// a call is probing the server while the breaker is half open
+	probing bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow returns ErrCircuitOpen if the call must not be made. A call which is
+// allowed must be followed by done.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if time.Since(b.openedAt) < b.cooldown || b.probing {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// done records the result of an allowed call.
+func (b *breaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.record(err)
+}
+
+// release ends an allowed call without a result, as the caller gave up on it.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// probed records the result of a health check. The checks are made besides
+// the calls, so the call probing the half open breaker is left alone.
+func (b *breaker) probed(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.record(err)
+}
+
+func (b *breaker) record(err error) {
+	if err != nil && IsUnavailable(err) {
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openedAt = time.Now()
+		}
+		return
+	}
+	b.failures = 0
+}
+
+// isOpen reports whether the calls are rejected.
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
@@ -2,8 +2,12 @@ package grpc
 
 import (
 	"context"
+	"io"
+	"sort"
+	"time"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"rightsizing-api-server/internal/api/common/resource"
 	pb "rightsizing-api-server/proto"
@@ -12,16 +16,53 @@ import (
 type Client struct {
 	forecastClient    pb.ForecastClient
 	rightsizingClient pb.RightsizingClient
+	healthClient      healthpb.HealthClient
+	breaker           *breaker
+	options           Options
 }
 
-func NewClient(conn *grpc.ClientConn) *Client {
+func NewClient(conn *grpc.ClientConn, options Options) *Client {
 	return &Client{
 		forecastClient:    pb.NewForecastClient(conn),
 		rightsizingClient: pb.NewRightsizingClient(conn),
+		healthClient:      healthpb.NewHealthClient(conn),
+		breaker:           newBreaker(options.BreakerThreshold, options.BreakerCooldown),
+		options:           options,
 	}
 }
 
-func (c *Client) Forecast(ctx context.Context, data resource.TimeseriesData) (*pb.ForecastResponse, error) {
+// Dial connects to the analysis server with the options.
+func Dial(target string, options Options) (*grpc.ClientConn, error) {
+	dialOptions, err := options.DialOptions()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Dial(target, dialOptions...)
+}
+
+// invoke makes the call with the deadline unless the circuit breaker is open.
+// The call counts for the breaker unless ctx is done, as the deadline or the
+// cancellation of the caller says nothing about the server.
+func (c *Client) invoke(ctx context.Context, timeout time.Duration, call func(ctx context.Context) error) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := call(callCtx)
+	if err != nil && ctx.Err() != nil {
+		c.breaker.release()
+		return err
+	}
+	c.breaker.done(err)
+	return err
+}
+
+// Forecast forecasts the usage with the options. Unset options are left to
+// the defaults of the analysis server. Usage larger than the message size
+// limit is streamed in chunks.
+func (c *Client) Forecast(ctx context.Context, data resource.TimeseriesData, options resource.ForecastOptions) (*pb.ForecastResponse, error) {
 	datapoints := make([]*pb.TimeSeriesDatapoint, len(data))
 	for i, point := range data {
 		datapoints[i] = &pb.TimeSeriesDatapoint{
@@ -31,28 +72,191 @@ func (c *Client) Forecast(ctx context.Context, data resource.TimeseriesData) (*p
 	}
 
 	request := &pb.ForecastRequest{
-		Data: datapoints,
+		Data:          datapoints,
+		Horizon:       int64(options.Horizon / time.Second),
+		Step:          int64(options.Step / time.Second),
+		IntervalWidth: options.IntervalWidth,
+		Seasonality:   options.Seasonality,
+	}
+	if !options.Origin.IsZero() {
+		request.Origin = options.Origin.Unix()
 	}
-	response, err := c.forecastClient.Forecast(ctx, request)
+
+	var response *pb.ForecastResponse
+	err := c.invoke(ctx, c.options.ForecastTimeout, func(ctx context.Context) (err error) {
+		if request.Size() > c.options.MaxSendMsgSize {
+			response, err = c.forecastStream(ctx, request)
+			return err
+		}
+		response, err = c.forecastClient.Forecast(ctx, request)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return response, nil
 }
 
+// forecastStream sends the request in chunks of its data, the first one
+// with the options.
+func (c *Client) forecastStream(ctx context.Context, request *pb.ForecastRequest) (*pb.ForecastResponse, error) {
+	stream, err := c.forecastClient.ForecastStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		data  = request.Data
+		size  = c.chunkSize(len(data), request.Size())
+		chunk = request
+	)
+	for start := 0; start < len(data); start += size {
+		end := start + size
+		if end > len(data) {
+			end = len(data)
+		}
+		if start > 0 {
+			chunk = &pb.ForecastRequest{}
+		}
+		chunk.Data = data[start:end]
+		if err := stream.Send(chunk); err != nil {
+			// the server closed the stream, its error is returned by CloseAndRecv
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
 func (c *Client) Rightsizing(ctx context.Context, data resource.TimeseriesData) (*pb.RightsizingResponse, error) {
+	request := rightsizingRequest("", data)
+
+	var response *pb.RightsizingResponse
+	err := c.invoke(ctx, c.options.Timeout, func(ctx context.Context) (err error) {
+		if request.Size() > c.options.MaxSendMsgSize {
+			response, err = c.rightsizingStream(ctx, request)
+			return err
+		}
+		response, err = c.rightsizingClient.Rightsizing(ctx, request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// BatchRightsizing recommends the series by id with as few calls as the
+// message size limit allows. A series larger than the limit is streamed alone.
+// The responses are keyed by id, a response with a message failed.
+func (c *Client) BatchRightsizing(ctx context.Context, series map[string]resource.TimeseriesData) (map[string]*pb.RightsizingResponse, error) {
+	ids := make([]string, 0, len(series))
+	for id := range series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var (
+		responses = make(map[string]*pb.RightsizingResponse, len(series))
+		batch     = &pb.BatchRightsizingRequest{}
+	)
+	send := func() error {
+		if len(batch.Requests) == 0 {
+			return nil
+		}
+		var response *pb.BatchRightsizingResponse
+		err := c.invoke(ctx, c.options.Timeout, func(ctx context.Context) (err error) {
+			response, err = c.rightsizingClient.BatchRightsizing(ctx, batch)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range response.Responses {
+			responses[r.Id] = r
+		}
+		batch = &pb.BatchRightsizingRequest{}
+		return nil
+	}
+
+	for _, id := range ids {
+		request := rightsizingRequest(id, series[id])
+		if request.Size()+batchOverhead > c.options.MaxSendMsgSize {
+			var response *pb.RightsizingResponse
+			err := c.invoke(ctx, c.options.Timeout, func(ctx context.Context) (err error) {
+				response, err = c.rightsizingStream(ctx, request)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			response.Id = id
+			responses[id] = response
+			continue
+		}
+		if batch.Size()+request.Size()+batchOverhead > c.options.MaxSendMsgSize {
+			if err := send(); err != nil {
+				return nil, err
+			}
+		}
+		batch.Requests = append(batch.Requests, request)
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+func rightsizingRequest(id string, data resource.TimeseriesData) *pb.RightsizingRequest {
 	datapoints := make([]float64, len(data))
 	for i, point := range data {
 		datapoints[i] = point.Value
 	}
-
-	request := &pb.RightsizingRequest{
+	return &pb.RightsizingRequest{
+		Id:   id,
 		Data: datapoints,
 	}
+}
 
-	response, err := c.rightsizingClient.Rightsizing(ctx, request)
+func (c *Client) rightsizingStream(ctx context.Context, request *pb.RightsizingRequest) (*pb.RightsizingResponse, error) {
+	stream, err := c.rightsizingClient.RightsizingStream(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return response, nil
+
+	var (
+		data  = request.Data
+		size  = c.chunkSize(len(data), request.Size())
+		chunk = request
+	)
+	for start := 0; start < len(data); start += size {
+		end := start + size
+		if end > len(data) {
+			end = len(data)
+		}
+		if start > 0 {
+			chunk = &pb.RightsizingRequest{}
+		}
+		chunk.Data = data[start:end]
+		if err := stream.Send(chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// chunkSize returns the number of datapoints per chunk of a request of n
+// datapoints and size bytes, so that a chunk takes at most half of the
+// message size limit.
+func (c *Client) chunkSize(n, size int) int {
+	chunk := n * c.options.MaxSendMsgSize / size / 2
+	if chunk < 1 {
+		return 1
+	}
+	return chunk
 }
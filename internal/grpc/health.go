@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Check checks the health of the analysis server with the grpc health checking
+// protocol. A server without the health service is healthy if it answers.
+func (c *Client) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	resp, err := c.healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return status.Errorf(codes.Unavailable, "analysis server is %s", resp.Status)
+	}
+	return nil
+}
+
+// Probe checks the health of the analysis server every interval until ctx is
+// done. The checks count for the circuit breaker like the calls, so that it
+// opens while the server is down and closes as soon as the server is back,
+// even when there are no calls.
+func (c *Client) Probe(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		wasOpen := c.breaker.isOpen()
+		err := c.Check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.breaker.probed(err)
+		switch isOpen := c.breaker.isOpen(); {
+		case isOpen && !wasOpen:
+			logger.Warn("analysis server is unavailable", zap.Error(err))
+		case !isOpen && wasOpen:
+			logger.Info("analysis server is available again")
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	// default message size limit of grpc servers
+	defaultMaxMsgSize = 4 * 1024 * 1024
+	// bytes of the tag and the length of a request in a batch, at most
+	batchOverhead = 1 + 5
+)
+
+// retry policy of the analysis server calls, only UNAVAILABLE is retried as
+// the other failures would fail again
+const serviceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "rightsizing.Rightsizing"}, {"service": "rightsizing.Forecast"}],
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.2s",
+			"maxBackoff": "2s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// Options configures the connection to the analysis server.
+type Options struct {
+	// largest message sent in bytes. Larger requests are streamed in chunks,
+	// so it should not exceed the receive limit of the analysis server.
+	MaxSendMsgSize int
+	// largest message received in bytes
+	MaxRecvMsgSize int
+	// deadline of a rightsizing call
+	Timeout time.Duration
+	// deadline of a forecast call, which fits a model
+	ForecastTimeout time.Duration
+	// attempts of a call failing with UNAVAILABLE, including the first one
+	MaxAttempts int
+	// consecutive failures opening the circuit breaker
+	BreakerThreshold int
+	// time the circuit breaker stays open before a call may probe the server
+	BreakerCooldown time.Duration
+	// interval of the health checks, none if 0
+	HealthCheckInterval time.Duration
+	// TLS of the connection, insecure if disabled
+	TLS TLSOptions
+}
+
+// TLSOptions configures TLS, and mutual TLS if the client certificate is set.
+type TLSOptions struct {
+	Enabled bool
+	// CA certificate verifying the server, the system pool if empty
+	CAFile string
+	// client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// server name verified instead of the host, if set
+	ServerName string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		MaxSendMsgSize:      defaultMaxMsgSize,
+		MaxRecvMsgSize:      defaultMaxMsgSize,
+		Timeout:             30 * time.Second,
+		ForecastTimeout:     5 * time.Minute,
+		MaxAttempts:         3,
+		BreakerThreshold:    5,
+		BreakerCooldown:     30 * time.Second,
+		HealthCheckInterval: 10 * time.Second,
+	}
+}
+
+func (o Options) Validate() error {
+	if o.MaxSendMsgSize <= 0 || o.MaxRecvMsgSize <= 0 {
+		return errors.New("grpc max message sizes must be positive")
+	}
+	if o.Timeout <= 0 || o.ForecastTimeout <= 0 {
+		return errors.New("grpc timeouts must be positive")
+	}
+	// the limit of grpc, larger values are lowered silently
+	if o.MaxAttempts < 1 || o.MaxAttempts > 5 {
+		return errors.New("grpc max attempts must be between 1 and 5")
+	}
+	if o.BreakerThreshold < 1 || o.BreakerCooldown <= 0 {
+		return errors.New("grpc circuit breaker threshold and cooldown must be positive")
+	}
+	if o.HealthCheckInterval < 0 {
+		return errors.New("grpc health check interval must not be negative")
+	}
+	if !o.TLS.Enabled && (o.TLS.CAFile != "" || o.TLS.CertFile != "" || o.TLS.ServerName != "") {
+		return errors.New("grpc TLS options require TLS to be enabled")
+	}
+	if (o.TLS.CertFile == "") != (o.TLS.KeyFile == "") {
+		return errors.New("grpc client certificate/private key both must be present or neither must be present")
+	}
+	return nil
+}
+
+// DialOptions returns the dial options of the transport credentials, the
+// retry policy and the message size limits of every call.
+func (o Options) DialOptions() ([]grpc.DialOption, error) {
+	transport := grpc.WithInsecure()
+	if o.TLS.Enabled {
+		config, err := o.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(config))
+	}
+
+	return []grpc.DialOption{
+		transport,
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(serviceConfig, o.MaxAttempts)),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(o.MaxSendMsgSize),
+			grpc.MaxCallRecvMsgSize(o.MaxRecvMsgSize)),
+	}, nil
+}
+
+func (o TLSOptions) config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: o.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if o.CAFile != "" {
+		ca, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate in %s", o.CAFile)
+		}
+	}
+	if o.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
@@ -24,3 +24,14 @@ type ContainerQuota struct {
 	Resource string  `gorm:"column:resource" json:"resource"`
 	Value    float64 `gorm:"column:value" json:"value"`
 }
+
+type PodNodePool struct {
+	Namespace string `gorm:"column:namespace" json:"namespace"`
+	Pod       string `gorm:"column:pod"       json:"pod"`
+	NodePool  string `gorm:"column:node_pool" json:"node_pool"`
+}
+
+type PodName struct {
+	Namespace string `gorm:"column:namespace" json:"namespace"`
+	Pod       string `gorm:"column:pod"       json:"pod"`
+}
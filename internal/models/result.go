@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ObjectTypePod = "pod"
+	ObjectTypeVm  = "vm"
+)
+
+type ForecastResult struct {
+	TaskUUID   string    `gorm:"column:task_uuid;primaryKey" json:"uuid"`
+	ObjectType string    `gorm:"column:object_type"          json:"object_type"`
+	Namespace  string    `gorm:"column:namespace"            json:"namespace,omitempty"`
+	Name       string    `gorm:"column:name"                 json:"name"`
+	Model      string    `gorm:"column:model"                json:"model"`
+	StartTime  time.Time `gorm:"column:start_time"           json:"start_time"`
+	EndTime    time.Time `gorm:"column:end_time"             json:"end_time"`
+	// json encoded forecast parameters
+	Parameters string `gorm:"column:parameters;type:jsonb" json:"-"`
+	// resource.ForecastOptions.Key of the parameters
+	OptionsKey string `gorm:"column:options_key"           json:"-"`
+	Status     string `gorm:"column:status"                json:"status"`
+	// json encoded forecast usage, nil until the task finishes
+	Result     *string    `gorm:"column:result;type:jsonb" json:"-"`
+	Error      string     `gorm:"column:error"             json:"error,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at"        json:"created_at"`
+	FinishedAt *time.Time `gorm:"column:finished_at"       json:"finished_at,omitempty"`
+	// machinery group uuid of a batch forecast
+	BatchID string `gorm:"column:batch_id" json:"batch_id,omitempty"`
+}
+
+func (ForecastResult) TableName() string {
+	return "forecast_result"
+}
+
+type RecommendationResult struct {
+	CreatedAt      time.Time `gorm:"column:created_at"      json:"time"`
+	ObjectType     string    `gorm:"column:object_type"     json:"object_type"`
+	Namespace      string    `gorm:"column:namespace"       json:"namespace,omitempty"`
+	Name           string    `gorm:"column:name"            json:"name"`
+	Container      string    `gorm:"column:container"       json:"container,omitempty"`
+	Resource       string    `gorm:"column:resource"        json:"resource"`
+	Recommender    string    `gorm:"column:recommender"     json:"recommender"`
+	StartTime      time.Time `gorm:"column:start_time"      json:"start_time"`
+	EndTime        time.Time `gorm:"column:end_time"        json:"end_time"`
+	Samples        int       `gorm:"column:samples"         json:"samples"`
+	OptimizedUsage float64   `gorm:"column:optimized_usage" json:"optimized_usage"`
+	Request        float64   `gorm:"column:request"         json:"request"`
+	Limit          float64   `gorm:"column:limit"           json:"limit"`
+	CurrentRequest float64   `gorm:"column:current_request" json:"current_request"`
+	CurrentLimit   float64   `gorm:"column:current_limit"   json:"current_limit"`
+}
+
+func (RecommendationResult) TableName() string {
+	return "recommendation_result"
+}
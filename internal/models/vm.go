@@ -8,5 +8,5 @@ type VmID struct {
 type Vm struct {
 	VmID
 	Usage []TimeSeriesDatapoint `gorm:"foreignKey:ID" json:"usage"`
-	Limit []float64             `gorm:"foreignKey:ID" json:"usage"`
+	Limit []float64             `gorm:"foreignKey:ID" json:"limit"`
 }
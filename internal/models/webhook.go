@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID     int64  `gorm:"column:id;primaryKey" json:"id"`
+	URL    string `gorm:"column:url"           json:"url"`
+	Secret string `gorm:"column:secret"        json:"-"`
+	// comma separated event filter, empty for every event
+	Events    string    `gorm:"column:events"     json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscription"
+}
+
+// WebhookDelivery is an attempt to deliver an event. Retries of the same
+// event share the delivery id.
+type WebhookDelivery struct {
+	ID             int64  `gorm:"column:id;primaryKey"    json:"id"`
+	SubscriptionID int64  `gorm:"column:subscription_id" json:"subscription_id"`
+	DeliveryID     string `gorm:"column:delivery_id"     json:"delivery_id"`
+	Event          string `gorm:"column:event"           json:"event"`
+	Attempt        int    `gorm:"column:attempt"         json:"attempt"`
+	StatusCode     int    `gorm:"column:status_code"     json:"status_code,omitempty"`
+	Error          string `gorm:"column:error"           json:"error,omitempty"`
+	Succeeded      bool   `gorm:"column:succeeded"       json:"succeeded"`
+	// json encoded payload
+	Payload   string    `gorm:"column:payload;type:jsonb" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at"         json:"created_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
+
+// ContainerStatus is the last allocation status of a container resource.
+type ContainerStatus struct {
+	ObjectType string    `gorm:"column:object_type;primaryKey" json:"object_type"`
+	Namespace  string    `gorm:"column:namespace;primaryKey"   json:"namespace"`
+	Name       string    `gorm:"column:name;primaryKey"        json:"name"`
+	Container  string    `gorm:"column:container;primaryKey"   json:"container"`
+	Resource   string    `gorm:"column:resource;primaryKey"    json:"resource"`
+	Status     string    `gorm:"column:status"                 json:"status"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"             json:"updated_at"`
+}
+
+func (ContainerStatus) TableName() string {
+	return "container_status"
+}
+
+func (s ContainerStatus) Key() string {
+	return s.ObjectType + "/" + s.Namespace + "/" + s.Name + "/" + s.Container + "/" + s.Resource
+}
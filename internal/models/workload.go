@@ -0,0 +1,15 @@
+package models
+
+type PodOwner struct {
+	Namespace string `gorm:"column:namespace"  json:"namespace"`
+	Pod       string `gorm:"column:pod"        json:"pod"`
+	OwnerKind string `gorm:"column:owner_kind" json:"owner_kind"`
+	OwnerName string `gorm:"column:owner_name" json:"owner_name"`
+}
+
+type ReplicaSetOwner struct {
+	Namespace  string `gorm:"column:namespace"  json:"namespace"`
+	ReplicaSet string `gorm:"column:replicaset" json:"replicaset"`
+	OwnerKind  string `gorm:"column:owner_kind" json:"owner_kind"`
+	OwnerName  string `gorm:"column:owner_name" json:"owner_name"`
+}
@@ -0,0 +1,164 @@
+package pricing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+
+	"rightsizing-api-server/internal/api/common/resource"
+)
+
+const (
+	hoursPerMonth = 730
+	gibibyte      = 1 << 30
+
+	// on-demand price of a general purpose instance
+	defaultCurrency      = "USD"
+	defaultCPUHour       = 0.031611
+	defaultMemoryGiBHour = 0.004237
+)
+
+// label column names are used in queries as they are
+var labelPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Rate is the price of a vCPU and of a GiB of memory for an hour.
+// A zero value falls back to the default rate.
+type Rate struct {
+	CPUHour       float64 `yaml:"cpu_hour"`
+	MemoryGiBHour float64 `yaml:"memory_gib_hour"`
+	// price of the resources of the metric catalogue for an hour by name, per
+	// GiB of the resources in bytes and per unit of the others. It takes
+	// precedence over the price of the unit.
+	Resources map[string]float64 `yaml:"resources"`
+}
+
+// Pricing is loaded from the pricing file. Namespace overrides take
+// precedence over node pool overrides.
+type Pricing struct {
+	Currency string `yaml:"currency"`
+	Rate     `yaml:",inline"`
+	// label of kube_node_labels which holds the node pool name, e.g. label_agentpool
+	NodePoolLabel string          `yaml:"node_pool_label"`
+	NodePools     map[string]Rate `yaml:"node_pools"`
+	Namespaces    map[string]Rate `yaml:"namespaces"`
+}
+
+func Default() *Pricing {
+	return &Pricing{
+		Currency: defaultCurrency,
+		Rate: Rate{
+			CPUHour:       defaultCPUHour,
+			MemoryGiBHour: defaultMemoryGiBHour,
+		},
+	}
+}
+
+// Load reads the pricing file. The default pricing is used if path is empty.
+func Load(path string) (*Pricing, error) {
+	pricing := Default()
+	if path == "" {
+		return pricing, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(buf, pricing); err != nil {
+		return nil, err
+	}
+	if pricing.NodePoolLabel != "" && !labelPattern.MatchString(pricing.NodePoolLabel) {
+		return nil, fmt.Errorf("invalid node pool label %q", pricing.NodePoolLabel)
+	}
+	return pricing, nil
+}
+
+// UseNodePools reports whether node pool overrides need to be resolved.
+func (p *Pricing) UseNodePools() bool {
+	return p.NodePoolLabel != "" && len(p.NodePools) > 0
+}
+
+// GetRate returns the rate applied to objects in the namespace and node pool.
+func (p *Pricing) GetRate(namespace, nodePool string) Rate {
+	rate := p.Rate
+	if override, exist := p.NodePools[nodePool]; exist && nodePool != "" {
+		rate = rate.merge(override)
+	}
+	if override, exist := p.Namespaces[namespace]; exist && namespace != "" {
+		rate = rate.merge(override)
+	}
+	return rate
+}
+
+func (r Rate) merge(override Rate) Rate {
+	if override.CPUHour != 0 {
+		r.CPUHour = override.CPUHour
+	}
+	if override.MemoryGiBHour != 0 {
+		r.MemoryGiBHour = override.MemoryGiBHour
+	}
+	if len(override.Resources) > 0 {
+		resources := make(map[string]float64, len(r.Resources)+len(override.Resources))
+		for name, price := range r.Resources {
+			resources[name] = price
+		}
+		for name, price := range override.Resources {
+			resources[name] = price
+		}
+		r.Resources = resources
+	}
+	return r
+}
+
+// Monthly returns the monthly price of amount of the resource in the unit of
+// the metric catalogue. The resources in cores are priced at CPUHour and the
+// resources in bytes at MemoryGiBHour, unless the resource has its own price.
+// The resources of the other units without price are free.
+func (r Rate) Monthly(name, unit string, amount float64) float64 {
+	if unit == resource.UnitBytes {
+		amount /= gibibyte
+	}
+	if price, exist := r.Resources[name]; exist {
+		return amount * price * hoursPerMonth
+	}
+	switch unit {
+	case resource.UnitCores:
+		return amount * r.CPUHour * hoursPerMonth
+	case resource.UnitBytes:
+		return amount * r.MemoryGiBHour * hoursPerMonth
+	}
+	return 0
+}
+
+// Apply sets the cost of info. The recommended cost is the current cost if
+// nothing is recommended.
+func (p *Pricing) Apply(rate Rate, info *resource.ResourceUsageInfo) *resource.Cost {
+	current := rate.Monthly(info.ResourceName, info.Unit, info.Request)
+	recommended := current
+	if info.Recommendation != nil {
+		recommended = rate.Monthly(info.ResourceName, info.Unit, info.Recommendation.Request)
+	}
+
+	info.Cost = &resource.Cost{
+		Currency:    p.Currency,
+		Current:     current,
+		Recommended: recommended,
+		Savings:     current - recommended,
+	}
+	return info.Cost
+}
+
+// ApplyRollup sets the cost of every container of the rollup and the total.
+func (p *Pricing) ApplyRollup(rate Rate, rollup resource.Rollup) {
+	costs := make(map[string][]*resource.Cost)
+	for _, usages := range rollup.Containers {
+		for name, usage := range usages {
+			costs[name] = append(costs[name], p.Apply(rate, usage))
+		}
+	}
+	for name, usage := range rollup.Total {
+		usage.Cost = resource.SumCosts(costs[name]...)
+	}
+}
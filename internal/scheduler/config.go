@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultRightsizing = "0 * * * *"
+	defaultWindow      = "168h"
+)
+
+// Config is loaded from the schedule file. Specs are standard cron specs and
+// an empty spec disables the job.
+type Config struct {
+	// rightsizing of every pod and vm, recorded in the results store as the
+	// recommendation history
+	Rightsizing string `yaml:"rightsizing"`
+	// usage window analyzed by the scheduled jobs
+	Window   string         `yaml:"window"`
+	Forecast ForecastConfig `yaml:"forecast"`
+}
+
+// ForecastConfig is the forecast of every pod of the flagged namespaces.
+type ForecastConfig struct {
+	Spec       string   `yaml:"spec"`
+	Namespaces []string `yaml:"namespaces"`
+}
+
+func Default() *Config {
+	return &Config{
+		Rightsizing: defaultRightsizing,
+		Window:      defaultWindow,
+	}
+}
+
+// Load reads the schedule file. The default schedule is used if path is empty.
+func Load(path string) (*Config, error) {
+	config := Default()
+	if path == "" {
+		return config, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(buf, config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) Validate() error {
+	window, err := time.ParseDuration(c.Window)
+	if err != nil {
+		return err
+	}
+	if window <= 0 {
+		return errors.New("schedule window must be positive")
+	}
+	if c.Forecast.Spec != "" && len(c.Forecast.Namespaces) == 0 {
+		return errors.New("scheduled forecast needs at least one namespace")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"go.uber.org/zap"
+
+	"rightsizing-api-server/internal/api/pod"
+	"rightsizing-api-server/internal/api/vm"
+	"rightsizing-api-server/internal/worker"
+)
+
+// Register registers the scheduled jobs as periodic tasks of the machinery
+// server. The results are written to the results store by the tasks.
+func Register(w *worker.Worker, config *Config, logger *zap.Logger) error {
+	if config.Rightsizing != "" {
+		for _, name := range []string{pod.RightsizingTaskName, vm.RightsizingTaskName} {
+			task := &tasks.Signature{
+				Name: name,
+				Args: []tasks.Arg{
+					{
+						Type:  "string",
+						Value: config.Window,
+					},
+				},
+			}
+			if err := w.RegisterPeriodicTask(config.Rightsizing, name, task); err != nil {
+				return err
+			}
+			logger.Info("scheduled task", zap.String("task", name), zap.String("spec", config.Rightsizing))
+		}
+	}
+
+	if config.Forecast.Spec != "" {
+		for _, namespace := range config.Forecast.Namespaces {
+			task := &tasks.Signature{
+				Name: pod.NamespaceForecastTaskName,
+				Args: []tasks.Arg{
+					{
+						Type:  "string",
+						Value: namespace,
+					},
+					{
+						Type:  "string",
+						Value: config.Window,
+					},
+				},
+			}
+			// the lock of periodic tasks is per name
+			name := pod.NamespaceForecastTaskName + "_" + namespace
+			if err := w.RegisterPeriodicTask(config.Forecast.Spec, name, task); err != nil {
+				return err
+			}
+			logger.Info("scheduled task", zap.String("task", name), zap.String("spec", config.Forecast.Spec))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	commonerrors "rightsizing-api-server/internal/api/common/errors"
+	"rightsizing-api-server/internal/models"
+)
+
+const defaultLimit = 20
+
+// ErrDisabled is returned by the store of a server without the database.
+var ErrDisabled = commonerrors.UnavailableErr("results store", "the database is not configured")
+
+// Store keeps forecast and recommendation results in TimescaleDB so that they
+// survive restarts and the expiration of the machinery result backend. The
+// store of a nil db is disabled and fails with ErrDisabled.
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// session returns the db of the request, or ErrDisabled.
+func (s *Store) session(ctx context.Context) (*gorm.DB, error) {
+	if s.db == nil {
+		return nil, ErrDisabled
+	}
+	return s.db.WithContext(ctx), nil
+}
+
+// CreateForecast records a newly sent forecast task. Records of already
+// existing tasks are kept as they are.
+func (s *Store) CreateForecast(ctx context.Context, record *models.ForecastResult) error {
+	if record.Parameters == "" {
+		record.Parameters = "{}"
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	db, err := s.session(ctx)
+	if err != nil {
+		return err
+	}
+	return db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record).
+		Error
+}
+
+// FinishForecast records the result of a forecast task. result is the json
+// encoded forecast usage and is ignored if the task failed.
+func (s *Store) FinishForecast(ctx context.Context, uuid, status, result string, taskErr error) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"finished_at": time.Now(),
+	}
+	if taskErr != nil {
+		updates["error"] = taskErr.Error()
+	} else {
+		updates["result"] = result
+	}
+	db, err := s.session(ctx)
+	if err != nil {
+		return err
+	}
+	return db.
+		Model(&models.ForecastResult{}).
+		Where("task_uuid = ?", uuid).
+		Updates(updates).
+		Error
+}
+
+func (s *Store) GetForecast(ctx context.Context, uuid string) (*models.ForecastResult, error) {
+	var record models.ForecastResult
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.
+		Where("task_uuid = ?", uuid).
+		Take(&record).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, commonerrors.NotFoundErr("forecast", uuid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// GetLatestForecast returns the most recent forecast of the object with the
+// options of optionsKey, resource.ForecastOptions.Key.
+func (s *Store) GetLatestForecast(ctx context.Context, objectType, namespace, name, optionsKey string) (*models.ForecastResult, error) {
+	var record models.ForecastResult
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.
+		Where("object_type = ? AND namespace = ? AND name = ?", objectType, namespace, name).
+		Where("options_key = ?", optionsKey).
+		Order("created_at DESC").
+		Take(&record).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, commonerrors.NotFoundErr("forecast", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListForecasts returns the past forecasts of the object, most recent first.
+func (s *Store) ListForecasts(ctx context.Context, objectType, namespace, name string, limit int) ([]*models.ForecastResult, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	var records []*models.ForecastResult
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.
+		Where("object_type = ? AND namespace = ? AND name = ?", objectType, namespace, name).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ListForecastBatch returns the forecasts of the batch ordered by object.
+func (s *Store) ListForecastBatch(ctx context.Context, batchID string) ([]*models.ForecastResult, error) {
+	var records []*models.ForecastResult
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.
+		Where("batch_id = ?", batchID).
+		Order("namespace, name").
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, commonerrors.NotFoundErr("batch", batchID)
+	}
+	return records, nil
+}
+
+func (s *Store) SaveRecommendations(ctx context.Context, records []*models.RecommendationResult) error {
+	if len(records) == 0 {
+		return nil
+	}
+	db, err := s.session(ctx)
+	if err != nil {
+		return err
+	}
+	return db.
+		CreateInBatches(records, 100).
+		Error
+}
+
+// FinishForecastTask records the outcome of the running forecast task. The
+// task is found from the machinery signature in ctx. A failed task which will
+// be retried is recorded as RETRY.
+func (s *Store) FinishForecastTask(ctx context.Context, result interface{}, taskErr error) error {
+	sig := tasks.SignatureFromContext(ctx)
+	if sig == nil {
+		return nil
+	}
+
+	if taskErr != nil {
+		status := tasks.StateFailure
+		if sig.RetryCount > 0 {
+			status = tasks.StateRetry
+		}
+		return s.FinishForecast(ctx, sig.UUID, status, "", taskErr)
+	}
+
+	buf, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return s.FinishForecast(ctx, sig.UUID, tasks.StateSuccess, string(buf), nil)
+}
+
+// ListRecommendations returns the recommendations of the object recorded
+// between start and end in time order. recommender is optional.
+func (s *Store) ListRecommendations(ctx context.Context, objectType, namespace, name, recommender string, start, end time.Time) ([]*models.RecommendationResult, error) {
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db = db.
+		Where("object_type = ? AND namespace = ? AND name = ?", objectType, namespace, name).
+		Where("created_at >= ? AND created_at <= ?", start, end)
+	if recommender != "" {
+		db = db.Where("recommender = ?", recommender)
+	}
+
+	var records []*models.RecommendationResult
+	err = db.Order("created_at").
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// LatestRecommendations returns the most recent recommendation of every
+// container and resource of the objects recorded since since. namespace and
+// name are optional.
+func (s *Store) LatestRecommendations(ctx context.Context, objectType, namespace, name string, since time.Time) ([]*models.RecommendationResult, error) {
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db = db.
+		Select("DISTINCT ON (namespace, name, container, resource) *").
+		Where("object_type = ?", objectType).
+		Where("created_at >= ?", since)
+	if namespace != "" {
+		db = db.Where("namespace = ?", namespace)
+	}
+	if name != "" {
+		db = db.Where("name = ?", name)
+	}
+
+	var records []*models.RecommendationResult
+	err = db.Order("namespace, name, container, resource, created_at DESC").
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetContainerStatuses returns the last recorded statuses keyed by
+// models.ContainerStatus.Key. namespace is optional.
+func (s *Store) GetContainerStatuses(ctx context.Context, objectType, namespace string) (map[string]string, error) {
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db = db.Where("object_type = ?", objectType)
+	if namespace != "" {
+		db = db.Where("namespace = ?", namespace)
+	}
+
+	var records []*models.ContainerStatus
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string, len(records))
+	for _, record := range records {
+		statuses[record.Key()] = record.Status
+	}
+	return statuses, nil
+}
+
+func (s *Store) SaveContainerStatuses(ctx context.Context, records []*models.ContainerStatus) error {
+	if len(records) == 0 {
+		return nil
+	}
+	db, err := s.session(ctx)
+	if err != nil {
+		return err
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).
+		CreateInBatches(records, 100).
+		Error
+}
+
+// LatestForecasts returns the most recent successful forecast with the options
+// of optionsKey of every object created since since. namespace is optional.
+func (s *Store) LatestForecasts(ctx context.Context, objectType, namespace, optionsKey string, since time.Time) ([]*models.ForecastResult, error) {
+	db, err := s.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db = db.
+		Select("DISTINCT ON (namespace, name) *").
+		Where("object_type = ? AND status = ?", objectType, tasks.StateSuccess).
+		Where("options_key = ?", optionsKey).
+		Where("created_at >= ?", since)
+	if namespace != "" {
+		db = db.Where("namespace = ?", namespace)
+	}
+
+	var records []*models.ForecastResult
+	err = db.Order("namespace, name, created_at DESC").
+		Find(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+)
+
+const (
+	EventState    = "state"
+	EventProgress = "progress"
+	EventResult   = "result"
+	EventError    = "error"
+
+	// buffered events of a subscriber, newer events are dropped when it is full
+	eventBufferSize = 64
+)
+
+// Event is a state transition or the progress of a task. Progress is only
+// published for tasks processed by this server.
+type Event struct {
+	Type      string      `json:"type"`
+	UUID      string      `json:"uuid"`
+	Task      string      `json:"task,omitempty"`
+	State     string      `json:"state,omitempty"`
+	Container string      `json:"container,omitempty"`
+	Resource  string      `json:"resource,omitempty"`
+	Done      int         `json:"done,omitempty"`
+	Total     int         `json:"total,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Result    interface{} `json:"result,omitempty"`
+	Time      time.Time   `json:"time"`
+}
+
+// IsTerminal reports whether the task will not change its state anymore.
+func IsTerminal(state string) bool {
+	return state == tasks.StateSuccess || state == tasks.StateFailure
+}
+
+type eventHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan *Event]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subscribers: make(map[string]map[chan *Event]struct{}),
+	}
+}
+
+func (h *eventHub) subscribe(uuid string) (chan *Event, func()) {
+	ch := make(chan *Event, eventBufferSize)
+
+	h.mu.Lock()
+	if _, exist := h.subscribers[uuid]; !exist {
+		h.subscribers[uuid] = make(map[chan *Event]struct{})
+	}
+	h.subscribers[uuid][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers[uuid], ch)
+		if len(h.subscribers[uuid]) == 0 {
+			delete(h.subscribers, uuid)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *eventHub) publish(event *Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subscribers[event.UUID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Progress publishes the progress of the running task found from the
+// machinery signature in ctx.
+func (w *Worker) Progress(ctx context.Context, container, resource string, done, total int) {
+	sig := tasks.SignatureFromContext(ctx)
+	if sig == nil {
+		return
+	}
+	w.events.publish(&Event{
+		Type:      EventProgress,
+		UUID:      sig.UUID,
+		Task:      sig.Name,
+		State:     tasks.StateStarted,
+		Container: container,
+		Resource:  resource,
+		Done:      done,
+		Total:     total,
+		Time:      time.Now(),
+	})
+}
+
+// Watch sends the state transitions and the progress of the task until it
+// succeeds or fails. The state is also polled from the result backend so that
+// transitions on other servers are not missed.
+func (w *Worker) Watch(ctx context.Context, uuid string, interval time.Duration, send func(*Event) error) error {
+	events, unsubscribe := w.events.subscribe(uuid)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last string
+	sendState := func(state string) (bool, error) {
+		if state == last {
+			return IsTerminal(state), nil
+		}
+		last = state
+		err := send(&Event{
+			Type:  EventState,
+			UUID:  uuid,
+			State: state,
+			Time:  time.Now(),
+		})
+		return IsTerminal(state), err
+	}
+
+	for {
+		state, err := w.GetTaskStatus(uuid)
+		if err != nil {
+			return err
+		}
+		if done, err := sendState(state); done || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-events:
+			if event.Type == EventProgress {
+				if err := send(event); err != nil {
+					return err
+				}
+				continue
+			}
+			if done, err := sendState(event.State); done || err != nil {
+				return err
+			}
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/RichardKnop/machinery/v1"
@@ -31,7 +32,11 @@ type Worker struct {
 	cache  *cache.Cache
 	server *machinery.Server
 	worker *machinery.Worker
-	logger *zap.Logger
+	events *eventHub
+	// listeners of the state transitions of every task
+	mu        sync.RWMutex
+	listeners []func(*Event)
+	logger    *zap.Logger
 }
 
 func NewWorker(cache *cache.Cache, logger *zap.Logger, errCh chan<- error) (*Worker, error) {
@@ -51,6 +56,7 @@ func NewWorker(cache *cache.Cache, logger *zap.Logger, errCh chan<- error) (*Wor
 		cache:  cache,
 		server: server,
 		worker: worker,
+		events: newEventHub(),
 		logger: logger,
 	}
 
@@ -108,8 +114,18 @@ func (w *Worker) preHandler(sig *tasks.Signature) {
 		zap.String("task", sig.Name),
 		zap.Time("startAt", time.Now()),
 		zap.Int("retry", sig.RetryCount))
+
+	w.publish(&Event{
+		Type:  EventState,
+		UUID:  sig.UUID,
+		Task:  sig.Name,
+		State: tasks.StateStarted,
+		Time:  time.Now(),
+	})
 }
 
+// errorHandler is called without the signature, the failure of the task is
+// published by postHandler.
 func (w *Worker) errorHandler(err error) {
 	w.logger.Error("error task", zap.Error(err))
 }
@@ -119,6 +135,39 @@ func (w *Worker) postHandler(sig *tasks.Signature) {
 		zap.String("uuid", sig.UUID),
 		zap.String("task", sig.Name),
 		zap.Time("finishAt", time.Now()))
+
+	// the state is already updated to SUCCESS, FAILURE or RETRY
+	taskState, err := w.getTask(sig.UUID)
+	if err != nil {
+		w.logger.Error("failed to get task state", zap.String("uuid", sig.UUID), zap.Error(err))
+		return
+	}
+	w.publish(&Event{
+		Type:  EventState,
+		UUID:  sig.UUID,
+		Task:  sig.Name,
+		State: taskState.State,
+		Error: taskState.Error,
+		Time:  time.Now(),
+	})
+}
+
+func (w *Worker) publish(event *Event) {
+	w.events.publish(event)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, listener := range w.listeners {
+		go listener(event)
+	}
+}
+
+// Listen registers a listener of the state transitions of every task
+// processed by this server.
+func (w *Worker) Listen(listener func(*Event)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, listener)
 }
 
 func (w *Worker) SendTaskWithContext(ctx context.Context, task *tasks.Signature, name string) (*tasks.TaskState, error) {
@@ -144,6 +193,23 @@ func (w *Worker) SendTaskWithContext(ctx context.Context, task *tasks.Signature,
 	return taskState, nil
 }
 
+// SendGroupWithContext sends the tasks as a group with bounded concurrency.
+// names are the names of the tasks in order. Unlike SendTaskWithContext the
+// tasks are always sent and replace the cached task of the same name.
+func (w *Worker) SendGroupWithContext(ctx context.Context, group *tasks.Group, names []string, concurrency int) ([]*tasks.TaskState, error) {
+	results, err := w.server.SendGroupWithContext(ctx, group, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	taskStates := make([]*tasks.TaskState, len(results))
+	for i, result := range results {
+		taskStates[i] = result.GetState()
+		w.cache.Set(cachePrefix+names[i], taskStates[i].TaskUUID)
+	}
+	return taskStates, nil
+}
+
 func (w *Worker) GetUUID(name string) (string, error) {
 	uuid, exist := w.cache.Get(cachePrefix + name)
 	if !exist {
@@ -185,6 +251,12 @@ func (w *Worker) RegisterTasks(tasks map[string]interface{}) error {
 	return w.server.RegisterTasks(tasks)
 }
 
+// RegisterPeriodicTask sends the task on the cron spec. Only one server sends
+// the task at a time thanks to the lock of machinery.
+func (w *Worker) RegisterPeriodicTask(spec, name string, task *tasks.Signature) error {
+	return w.server.RegisterPeriodicTask(spec, name, task)
+}
+
 func (w *Worker) Stop(ctx context.Context) {
 	w.worker.Quit()
 }
@@ -272,9 +272,116 @@ func (*RightsizingResponse) XXX_MessageName() string {
 	return "rightsizing.RightsizingResponse"
 }
 
+type BatchRightsizingRequest struct {
+	Requests             []*RightsizingRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *BatchRightsizingRequest) Reset()         { *m = BatchRightsizingRequest{} }
+func (m *BatchRightsizingRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRightsizingRequest) ProtoMessage()    {}
+func (*BatchRightsizingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e2fc2910afaee383, []int{4}
+}
+func (m *BatchRightsizingRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchRightsizingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchRightsizingRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchRightsizingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRightsizingRequest.Merge(m, src)
+}
+func (m *BatchRightsizingRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchRightsizingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRightsizingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRightsizingRequest proto.InternalMessageInfo
+
+func (m *BatchRightsizingRequest) GetRequests() []*RightsizingRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+func (*BatchRightsizingRequest) XXX_MessageName() string {
+	return "rightsizing.BatchRightsizingRequest"
+}
+
+type BatchRightsizingResponse struct {
+	Responses            []*RightsizingResponse `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *BatchRightsizingResponse) Reset()         { *m = BatchRightsizingResponse{} }
+func (m *BatchRightsizingResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchRightsizingResponse) ProtoMessage()    {}
+func (*BatchRightsizingResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e2fc2910afaee383, []int{5}
+}
+func (m *BatchRightsizingResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchRightsizingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchRightsizingResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchRightsizingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRightsizingResponse.Merge(m, src)
+}
+func (m *BatchRightsizingResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchRightsizingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRightsizingResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRightsizingResponse proto.InternalMessageInfo
+
+func (m *BatchRightsizingResponse) GetResponses() []*RightsizingResponse {
+	if m != nil {
+		return m.Responses
+	}
+	return nil
+}
+
+func (*BatchRightsizingResponse) XXX_MessageName() string {
+	return "rightsizing.BatchRightsizingResponse"
+}
+
 type ForecastRequest struct {
 	Id                   string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	Data                 []*TimeSeriesDatapoint `protobuf:"bytes,2,rep,name=data,proto3" json:"data,omitempty"`
+	Horizon              int64                  `protobuf:"varint,3,opt,name=horizon,proto3" json:"horizon,omitempty"`
+	Step                 int64                  `protobuf:"varint,4,opt,name=step,proto3" json:"step,omitempty"`
+	IntervalWidth        float64                `protobuf:"fixed64,5,opt,name=interval_width,json=intervalWidth,proto3" json:"interval_width,omitempty"`
+	Seasonality          []string               `protobuf:"bytes,6,rep,name=seasonality,proto3" json:"seasonality,omitempty"`
+	Origin               int64                  `protobuf:"varint,7,opt,name=origin,proto3" json:"origin,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
 	XXX_unrecognized     []byte                 `json:"-"`
 	XXX_sizecache        int32                  `json:"-"`
@@ -284,7 +391,7 @@ func (m *ForecastRequest) Reset()         { *m = ForecastRequest{} }
 func (m *ForecastRequest) String() string { return proto.CompactTextString(m) }
 func (*ForecastRequest) ProtoMessage()    {}
 func (*ForecastRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e2fc2910afaee383, []int{4}
+	return fileDescriptor_e2fc2910afaee383, []int{6}
 }
 func (m *ForecastRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -327,6 +434,41 @@ func (m *ForecastRequest) GetData() []*TimeSeriesDatapoint {
 	return nil
 }
 
+func (m *ForecastRequest) GetHorizon() int64 {
+	if m != nil {
+		return m.Horizon
+	}
+	return 0
+}
+
+func (m *ForecastRequest) GetStep() int64 {
+	if m != nil {
+		return m.Step
+	}
+	return 0
+}
+
+func (m *ForecastRequest) GetIntervalWidth() float64 {
+	if m != nil {
+		return m.IntervalWidth
+	}
+	return 0
+}
+
+func (m *ForecastRequest) GetSeasonality() []string {
+	if m != nil {
+		return m.Seasonality
+	}
+	return nil
+}
+
+func (m *ForecastRequest) GetOrigin() int64 {
+	if m != nil {
+		return m.Origin
+	}
+	return 0
+}
+
 func (*ForecastRequest) XXX_MessageName() string {
 	return "rightsizing.ForecastRequest"
 }
@@ -344,7 +486,7 @@ func (m *ForecastResponse) Reset()         { *m = ForecastResponse{} }
 func (m *ForecastResponse) String() string { return proto.CompactTextString(m) }
 func (*ForecastResponse) ProtoMessage()    {}
 func (*ForecastResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e2fc2910afaee383, []int{5}
+	return fileDescriptor_e2fc2910afaee383, []int{7}
 }
 func (m *ForecastResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -410,7 +552,7 @@ func (m *ForecastResponse_Result) Reset()         { *m = ForecastResponse_Result
 func (m *ForecastResponse_Result) String() string { return proto.CompactTextString(m) }
 func (*ForecastResponse_Result) ProtoMessage()    {}
 func (*ForecastResponse_Result) Descriptor() ([]byte, []int) {
-	return fileDescriptor_e2fc2910afaee383, []int{5, 0}
+	return fileDescriptor_e2fc2910afaee383, []int{7, 0}
 }
 func (m *ForecastResponse_Result) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -461,6 +603,8 @@ func init() {
 	proto.RegisterType((*TimeSeriesDatapoint)(nil), "rightsizing.TimeSeriesDatapoint")
 	proto.RegisterType((*RightsizingRequest)(nil), "rightsizing.RightsizingRequest")
 	proto.RegisterType((*RightsizingResponse)(nil), "rightsizing.RightsizingResponse")
+	proto.RegisterType((*BatchRightsizingRequest)(nil), "rightsizing.BatchRightsizingRequest")
+	proto.RegisterType((*BatchRightsizingResponse)(nil), "rightsizing.BatchRightsizingResponse")
 	proto.RegisterType((*ForecastRequest)(nil), "rightsizing.ForecastRequest")
 	proto.RegisterType((*ForecastResponse)(nil), "rightsizing.ForecastResponse")
 	proto.RegisterType((*ForecastResponse_Result)(nil), "rightsizing.ForecastResponse.Result")
@@ -469,31 +613,42 @@ func init() {
 func init() { proto.RegisterFile("proto/rightsizing.proto", fileDescriptor_e2fc2910afaee383) }
 
 var fileDescriptor_e2fc2910afaee383 = []byte{
-	// 383 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0xcb, 0x4a, 0x33, 0x31,
-	0x14, 0x6e, 0xa6, 0xff, 0x5f, 0xed, 0x29, 0x54, 0x49, 0x8b, 0x0e, 0xa5, 0x8e, 0xc3, 0xa0, 0xd0,
-	0x55, 0x85, 0xea, 0xc2, 0x85, 0x0b, 0x11, 0x11, 0xba, 0x8d, 0x4a, 0xc1, 0x5d, 0xb4, 0x71, 0x0c,
-	0x74, 0x26, 0xe3, 0x24, 0x75, 0xe1, 0x93, 0xf8, 0x38, 0x2e, 0xbb, 0x12, 0x1f, 0x41, 0xda, 0x17,
-	0x91, 0x49, 0x5a, 0x9a, 0xb1, 0xad, 0xa8, 0xbb, 0x73, 0x0e, 0xdf, 0xf9, 0x2e, 0xb9, 0xc0, 0x76,
-	0x92, 0x0a, 0x25, 0x0e, 0x52, 0x1e, 0x3e, 0x28, 0xc9, 0x9f, 0x79, 0x1c, 0xb6, 0xf5, 0x04, 0x57,
-	0xac, 0x51, 0xa3, 0x1e, 0x8a, 0x50, 0x18, 0x64, 0x56, 0x19, 0x48, 0x70, 0x0f, 0xd5, 0x2b, 0x1e,
-	0xb1, 0x4b, 0x96, 0x72, 0x26, 0xcf, 0xa9, 0xa2, 0x18, 0xc3, 0xbf, 0x98, 0x46, 0xcc, 0x45, 0x3e,
-	0x6a, 0x95, 0x89, 0xae, 0xf1, 0x29, 0x40, 0x9f, 0x2a, 0x9a, 0x08, 0x1e, 0x2b, 0xe9, 0x3a, 0x7e,
-	0xb1, 0x55, 0xe9, 0xf8, 0x6d, 0x5b, 0x30, 0x4f, 0xa2, 0x81, 0xc4, 0xda, 0x09, 0xba, 0x50, 0x5b,
-	0x02, 0xc1, 0x4d, 0x28, 0x2b, 0x1e, 0x31, 0xa9, 0x68, 0x94, 0x68, 0xc5, 0x22, 0x99, 0x0f, 0x70,
-	0x1d, 0xfe, 0x3f, 0xd1, 0xc1, 0x90, 0xb9, 0x8e, 0x8f, 0x5a, 0x88, 0x98, 0x26, 0x38, 0x06, 0x4c,
-	0xe6, 0xca, 0x84, 0x3d, 0x0e, 0x99, 0x54, 0xb8, 0x0a, 0x0e, 0xef, 0x4f, 0x4d, 0x3b, 0xbc, 0x9f,
-	0xc5, 0xc8, 0xe4, 0xb5, 0x59, 0x44, 0x74, 0x1d, 0xf4, 0xa0, 0x96, 0xdb, 0x94, 0x89, 0x88, 0x25,
-	0x5b, 0x58, 0x75, 0x61, 0x2d, 0x62, 0x52, 0xd2, 0xd0, 0x08, 0x97, 0xc9, 0xac, 0xc5, 0x5b, 0x50,
-	0x4a, 0x99, 0x1c, 0x0e, 0x94, 0x5b, 0xd4, 0x8e, 0xa6, 0x5d, 0xd0, 0x83, 0x8d, 0x0b, 0x91, 0xb2,
-	0x3b, 0x2a, 0xd5, 0x2a, 0x3f, 0x47, 0x96, 0x9f, 0x9f, 0x1c, 0x9e, 0x71, 0xfc, 0x86, 0x60, 0x73,
-	0xce, 0xfc, 0x6b, 0xbf, 0x27, 0x96, 0xdf, 0x4c, 0x76, 0x2f, 0x27, 0xfb, 0x95, 0xb8, 0x4d, 0x34,
-	0x76, 0x96, 0xaa, 0x41, 0xa0, 0x64, 0x26, 0x4b, 0xdf, 0xc4, 0x9f, 0x02, 0x75, 0x28, 0x54, 0xac,
-	0x2b, 0xc0, 0x24, 0xdf, 0xee, 0xe6, 0x58, 0x16, 0x6f, 0xb9, 0xe1, 0xaf, 0x06, 0x98, 0x0c, 0x41,
-	0xa1, 0x73, 0x0d, 0xeb, 0xb3, 0x64, 0xb8, 0x6b, 0xd5, 0xcd, 0x15, 0xe1, 0x0d, 0xf3, 0xce, 0xb7,
-	0x47, 0x13, 0x14, 0xce, 0xf6, 0x47, 0x63, 0x0f, 0xbd, 0x8f, 0x3d, 0xf4, 0x31, 0xf6, 0xd0, 0xcb,
-	0xc4, 0x2b, 0xbc, 0x4e, 0x3c, 0x34, 0x9a, 0x78, 0xe8, 0xc6, 0xfe, 0x66, 0xb7, 0x25, 0xfd, 0xaf,
-	0x0e, 0x3f, 0x03, 0x00, 0x00, 0xff, 0xff, 0xa9, 0x8d, 0x86, 0x20, 0x95, 0x03, 0x00, 0x00,
+	// 555 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xee, 0x26, 0x6d, 0xda, 0x4c, 0x44, 0x28, 0xdb, 0x8a, 0xae, 0xa2, 0x62, 0x2c, 0x8b, 0x48,
+	0x3e, 0x05, 0x29, 0x70, 0x40, 0x02, 0x21, 0x54, 0x21, 0xa4, 0x1e, 0xd9, 0x22, 0x2a, 0xe5, 0x82,
+	0x96, 0x66, 0x71, 0x56, 0x8a, 0xbd, 0x66, 0x77, 0x53, 0x44, 0x9f, 0x84, 0x23, 0x47, 0x1e, 0x83,
+	0x63, 0x4f, 0x88, 0x47, 0x40, 0x89, 0xc4, 0x73, 0x20, 0xaf, 0x6d, 0xb2, 0x6e, 0x12, 0xc2, 0xdf,
+	0x6d, 0xe6, 0xf3, 0xcc, 0x37, 0xe3, 0xef, 0x1b, 0x1b, 0x0e, 0x52, 0x25, 0x8d, 0xbc, 0xab, 0x44,
+	0x34, 0x32, 0x5a, 0x5c, 0x88, 0x24, 0xea, 0x59, 0x04, 0xb7, 0x1c, 0xa8, 0xb3, 0x1f, 0xc9, 0x48,
+	0xe6, 0x95, 0x59, 0x94, 0x97, 0x04, 0x6f, 0xa0, 0xfd, 0x42, 0xc4, 0xfc, 0x84, 0x2b, 0xc1, 0xf5,
+	0x53, 0x66, 0x18, 0xc6, 0xb0, 0x99, 0xb0, 0x98, 0x13, 0xe4, 0xa3, 0xb0, 0x49, 0x6d, 0x8c, 0x9f,
+	0x00, 0x0c, 0x99, 0x61, 0xa9, 0x14, 0x89, 0xd1, 0xa4, 0xe6, 0xd7, 0xc3, 0x56, 0xdf, 0xef, 0xb9,
+	0x03, 0xab, 0x24, 0xb6, 0x90, 0x3a, 0x3d, 0xc1, 0x31, 0xec, 0x2d, 0x29, 0xc1, 0x87, 0xd0, 0x34,
+	0x22, 0xe6, 0xda, 0xb0, 0x38, 0xb5, 0x13, 0xeb, 0x74, 0x0e, 0xe0, 0x7d, 0xd8, 0x3a, 0x67, 0xe3,
+	0x09, 0x27, 0x35, 0x1f, 0x85, 0x88, 0xe6, 0x49, 0xf0, 0x00, 0x30, 0x9d, 0x4f, 0xa6, 0xfc, 0xed,
+	0x84, 0x6b, 0x83, 0xdb, 0x50, 0x13, 0xc3, 0x62, 0xe9, 0x9a, 0x18, 0x66, 0xaf, 0x91, 0x8d, 0xb7,
+	0xcb, 0x22, 0x6a, 0xe3, 0xe0, 0x14, 0xf6, 0x2a, 0x9d, 0x3a, 0x95, 0x89, 0xe6, 0x0b, 0xad, 0x04,
+	0xb6, 0x63, 0xae, 0x35, 0x8b, 0xf2, 0xc1, 0x4d, 0x5a, 0xa6, 0xf8, 0x26, 0x34, 0x14, 0xd7, 0x93,
+	0xb1, 0x21, 0x75, 0xbb, 0x51, 0x91, 0x05, 0x2f, 0xe1, 0xe0, 0x88, 0x99, 0xb3, 0xd1, 0x92, 0xbd,
+	0x1e, 0xc2, 0x8e, 0xca, 0x43, 0x4d, 0x90, 0x15, 0xee, 0x76, 0x45, 0xb8, 0xc5, 0x16, 0xfa, 0xb3,
+	0x21, 0x18, 0x00, 0x59, 0xe4, 0x2d, 0xb6, 0x7e, 0x0c, 0x4d, 0x55, 0xc4, 0x25, 0xb3, 0xbf, 0x9a,
+	0x39, 0x2f, 0xa4, 0xf3, 0x96, 0xe0, 0x3b, 0x82, 0xeb, 0xcf, 0xa4, 0xe2, 0x67, 0x4c, 0x9b, 0x55,
+	0x22, 0xde, 0x77, 0x44, 0xfc, 0x1d, 0xc7, 0x6d, 0x75, 0xa6, 0xdf, 0x48, 0x2a, 0x71, 0x21, 0x13,
+	0x2b, 0x53, 0x9d, 0x96, 0x69, 0x66, 0x8a, 0x36, 0x3c, 0x25, 0x9b, 0x16, 0xb6, 0x31, 0xee, 0x42,
+	0x5b, 0x24, 0x86, 0xab, 0x73, 0x36, 0x7e, 0xf5, 0x4e, 0x0c, 0xcd, 0x88, 0x6c, 0x59, 0x6d, 0xaf,
+	0x95, 0xe8, 0x69, 0x06, 0x62, 0x1f, 0x5a, 0x9a, 0x33, 0x2d, 0x13, 0x36, 0x16, 0xe6, 0x3d, 0x69,
+	0xf8, 0xf5, 0xb0, 0x49, 0x5d, 0x28, 0x33, 0x47, 0x2a, 0x11, 0x89, 0x84, 0x6c, 0x5b, 0xfa, 0x22,
+	0x0b, 0xbe, 0x20, 0xd8, 0x9d, 0xbf, 0xe8, 0x1f, 0x7b, 0xfe, 0xc8, 0xf1, 0x3c, 0x53, 0xe1, 0x4e,
+	0x45, 0x85, 0xab, 0xc4, 0x3d, 0x6a, 0x6b, 0xcb, 0xcb, 0xe8, 0x50, 0x68, 0xe4, 0xc8, 0xd2, 0xef,
+	0xea, 0xaf, 0xf4, 0xed, 0x7f, 0xac, 0x41, 0xcb, 0x31, 0x17, 0xd3, 0x6a, 0xba, 0xee, 0xbe, 0x3a,
+	0x6b, 0xcf, 0x24, 0xd8, 0xc0, 0x03, 0xb8, 0xe1, 0x3c, 0x38, 0x31, 0x8a, 0xb3, 0xf8, 0xbf, 0x30,
+	0x87, 0x08, 0x33, 0xd8, 0xbd, 0x7a, 0xd5, 0xb8, 0xaa, 0xea, 0x8a, 0x8f, 0xa9, 0xd3, 0x5d, 0x53,
+	0x55, 0x0e, 0xe9, 0x7f, 0x42, 0xb0, 0x53, 0x5a, 0x83, 0x8f, 0x9d, 0xf8, 0x70, 0x85, 0x7b, 0x39,
+	0xff, 0xad, 0x5f, 0x7a, 0x1b, 0x6c, 0xe0, 0xe7, 0xd0, 0x2e, 0xd1, 0x42, 0x93, 0x7f, 0x23, 0x0c,
+	0xd1, 0x51, 0xf7, 0x72, 0xea, 0xa1, 0xaf, 0x53, 0x0f, 0x7d, 0x9b, 0x7a, 0xe8, 0xc3, 0xcc, 0xdb,
+	0xf8, 0x3c, 0xf3, 0xd0, 0xe5, 0xcc, 0x43, 0x03, 0xf7, 0xf7, 0xfd, 0xba, 0x61, 0xff, 0xd7, 0xf7,
+	0x7e, 0x0c, 0x00, 0x9e, 0xe4, 0x05, 0xff, 0xed, 0x05, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -509,6 +664,8 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type RightsizingClient interface {
 	Rightsizing(ctx context.Context, in *RightsizingRequest, opts ...grpc.CallOption) (*RightsizingResponse, error)
+	RightsizingStream(ctx context.Context, opts ...grpc.CallOption) (Rightsizing_RightsizingStreamClient, error)
+	BatchRightsizing(ctx context.Context, in *BatchRightsizingRequest, opts ...grpc.CallOption) (*BatchRightsizingResponse, error)
 }
 
 type rightsizingClient struct {
@@ -528,9 +685,54 @@ func (c *rightsizingClient) Rightsizing(ctx context.Context, in *RightsizingRequ
 	return out, nil
 }
 
+func (c *rightsizingClient) RightsizingStream(ctx context.Context, opts ...grpc.CallOption) (Rightsizing_RightsizingStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Rightsizing_serviceDesc.Streams[0], "/rightsizing.Rightsizing/RightsizingStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rightsizingRightsizingStreamClient{stream}
+	return x, nil
+}
+
+type Rightsizing_RightsizingStreamClient interface {
+	Send(*RightsizingRequest) error
+	CloseAndRecv() (*RightsizingResponse, error)
+	grpc.ClientStream
+}
+
+type rightsizingRightsizingStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *rightsizingRightsizingStreamClient) Send(m *RightsizingRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *rightsizingRightsizingStreamClient) CloseAndRecv() (*RightsizingResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RightsizingResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *rightsizingClient) BatchRightsizing(ctx context.Context, in *BatchRightsizingRequest, opts ...grpc.CallOption) (*BatchRightsizingResponse, error) {
+	out := new(BatchRightsizingResponse)
+	err := c.cc.Invoke(ctx, "/rightsizing.Rightsizing/BatchRightsizing", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RightsizingServer is the server API for Rightsizing service.
 type RightsizingServer interface {
 	Rightsizing(context.Context, *RightsizingRequest) (*RightsizingResponse, error)
+	RightsizingStream(Rightsizing_RightsizingStreamServer) error
+	BatchRightsizing(context.Context, *BatchRightsizingRequest) (*BatchRightsizingResponse, error)
 }
 
 // UnimplementedRightsizingServer can be embedded to have forward compatible implementations.
@@ -540,6 +742,12 @@ type UnimplementedRightsizingServer struct {
 func (*UnimplementedRightsizingServer) Rightsizing(ctx context.Context, req *RightsizingRequest) (*RightsizingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Rightsizing not implemented")
 }
+func (*UnimplementedRightsizingServer) RightsizingStream(srv Rightsizing_RightsizingStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RightsizingStream not implemented")
+}
+func (*UnimplementedRightsizingServer) BatchRightsizing(ctx context.Context, req *BatchRightsizingRequest) (*BatchRightsizingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchRightsizing not implemented")
+}
 
 func RegisterRightsizingServer(s *grpc.Server, srv RightsizingServer) {
 	s.RegisterService(&_Rightsizing_serviceDesc, srv)
@@ -563,6 +771,50 @@ func _Rightsizing_Rightsizing_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Rightsizing_RightsizingStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RightsizingServer).RightsizingStream(&rightsizingRightsizingStreamServer{stream})
+}
+
+type Rightsizing_RightsizingStreamServer interface {
+	SendAndClose(*RightsizingResponse) error
+	Recv() (*RightsizingRequest, error)
+	grpc.ServerStream
+}
+
+type rightsizingRightsizingStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *rightsizingRightsizingStreamServer) SendAndClose(m *RightsizingResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *rightsizingRightsizingStreamServer) Recv() (*RightsizingRequest, error) {
+	m := new(RightsizingRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Rightsizing_BatchRightsizing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRightsizingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RightsizingServer).BatchRightsizing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rightsizing.Rightsizing/BatchRightsizing",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RightsizingServer).BatchRightsizing(ctx, req.(*BatchRightsizingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Rightsizing_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rightsizing.Rightsizing",
 	HandlerType: (*RightsizingServer)(nil),
@@ -571,8 +823,18 @@ var _Rightsizing_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Rightsizing",
 			Handler:    _Rightsizing_Rightsizing_Handler,
 		},
+		{
+			MethodName: "BatchRightsizing",
+			Handler:    _Rightsizing_BatchRightsizing_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RightsizingStream",
+			Handler:       _Rightsizing_RightsizingStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/rightsizing.proto",
 }
 
@@ -581,6 +843,7 @@ var _Rightsizing_serviceDesc = grpc.ServiceDesc{
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type ForecastClient interface {
 	Forecast(ctx context.Context, in *ForecastRequest, opts ...grpc.CallOption) (*ForecastResponse, error)
+	ForecastStream(ctx context.Context, opts ...grpc.CallOption) (Forecast_ForecastStreamClient, error)
 }
 
 type forecastClient struct {
@@ -600,9 +863,44 @@ func (c *forecastClient) Forecast(ctx context.Context, in *ForecastRequest, opts
 	return out, nil
 }
 
+func (c *forecastClient) ForecastStream(ctx context.Context, opts ...grpc.CallOption) (Forecast_ForecastStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Forecast_serviceDesc.Streams[0], "/rightsizing.Forecast/ForecastStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &forecastForecastStreamClient{stream}
+	return x, nil
+}
+
+type Forecast_ForecastStreamClient interface {
+	Send(*ForecastRequest) error
+	CloseAndRecv() (*ForecastResponse, error)
+	grpc.ClientStream
+}
+
+type forecastForecastStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *forecastForecastStreamClient) Send(m *ForecastRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *forecastForecastStreamClient) CloseAndRecv() (*ForecastResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ForecastResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ForecastServer is the server API for Forecast service.
 type ForecastServer interface {
 	Forecast(context.Context, *ForecastRequest) (*ForecastResponse, error)
+	ForecastStream(Forecast_ForecastStreamServer) error
 }
 
 // UnimplementedForecastServer can be embedded to have forward compatible implementations.
@@ -612,6 +910,9 @@ type UnimplementedForecastServer struct {
 func (*UnimplementedForecastServer) Forecast(ctx context.Context, req *ForecastRequest) (*ForecastResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Forecast not implemented")
 }
+func (*UnimplementedForecastServer) ForecastStream(srv Forecast_ForecastStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ForecastStream not implemented")
+}
 
 func RegisterForecastServer(s *grpc.Server, srv ForecastServer) {
 	s.RegisterService(&_Forecast_serviceDesc, srv)
@@ -635,6 +936,32 @@ func _Forecast_Forecast_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Forecast_ForecastStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ForecastServer).ForecastStream(&forecastForecastStreamServer{stream})
+}
+
+type Forecast_ForecastStreamServer interface {
+	SendAndClose(*ForecastResponse) error
+	Recv() (*ForecastRequest, error)
+	grpc.ServerStream
+}
+
+type forecastForecastStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *forecastForecastStreamServer) SendAndClose(m *ForecastResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *forecastForecastStreamServer) Recv() (*ForecastRequest, error) {
+	m := new(ForecastRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Forecast_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rightsizing.Forecast",
 	HandlerType: (*ForecastServer)(nil),
@@ -644,7 +971,13 @@ var _Forecast_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Forecast_Forecast_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ForecastStream",
+			Handler:       _Forecast_ForecastStream_Handler,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "proto/rightsizing.proto",
 }
 
@@ -825,6 +1158,88 @@ func (m *RightsizingResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *BatchRightsizingRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchRightsizingRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchRightsizingRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Requests) > 0 {
+		for iNdEx := len(m.Requests) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Requests[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRightsizing(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchRightsizingResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchRightsizingResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchRightsizingResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Responses) > 0 {
+		for iNdEx := len(m.Responses) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Responses[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRightsizing(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *ForecastRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -849,6 +1264,36 @@ func (m *ForecastRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Origin != 0 {
+		i = encodeVarintRightsizing(dAtA, i, uint64(m.Origin))
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.Seasonality) > 0 {
+		for iNdEx := len(m.Seasonality) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Seasonality[iNdEx])
+			copy(dAtA[i:], m.Seasonality[iNdEx])
+			i = encodeVarintRightsizing(dAtA, i, uint64(len(m.Seasonality[iNdEx])))
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.IntervalWidth != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.IntervalWidth))))
+		i--
+		dAtA[i] = 0x29
+	}
+	if m.Step != 0 {
+		i = encodeVarintRightsizing(dAtA, i, uint64(m.Step))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Horizon != 0 {
+		i = encodeVarintRightsizing(dAtA, i, uint64(m.Horizon))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Data) > 0 {
 		for iNdEx := len(m.Data) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1069,6 +1514,42 @@ func (m *RightsizingResponse) Size() (n int) {
 	return n
 }
 
+func (m *BatchRightsizingRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Requests) > 0 {
+		for _, e := range m.Requests {
+			l = e.Size()
+			n += 1 + l + sovRightsizing(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *BatchRightsizingResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Responses) > 0 {
+		for _, e := range m.Responses {
+			l = e.Size()
+			n += 1 + l + sovRightsizing(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *ForecastRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -1085,6 +1566,24 @@ func (m *ForecastRequest) Size() (n int) {
 			n += 1 + l + sovRightsizing(uint64(l))
 		}
 	}
+	if m.Horizon != 0 {
+		n += 1 + sovRightsizing(uint64(m.Horizon))
+	}
+	if m.Step != 0 {
+		n += 1 + sovRightsizing(uint64(m.Step))
+	}
+	if m.IntervalWidth != 0 {
+		n += 9
+	}
+	if len(m.Seasonality) > 0 {
+		for _, s := range m.Seasonality {
+			l = len(s)
+			n += 1 + l + sovRightsizing(uint64(l))
+		}
+	}
+	if m.Origin != 0 {
+		n += 1 + sovRightsizing(uint64(m.Origin))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1176,7 +1675,7 @@ func (m *TimeSeriesData) Unmarshal(dAtA []byte) error {
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PodName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1606,6 +2105,176 @@ func (m *RightsizingResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *BatchRightsizingRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRightsizing
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchRightsizingRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchRightsizingRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Requests", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Requests = append(m.Requests, &RightsizingRequest{})
+			if err := m.Requests[len(m.Requests)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRightsizing(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchRightsizingResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRightsizing
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchRightsizingResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchRightsizingResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Responses", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Responses = append(m.Responses, &RightsizingResponse{})
+			if err := m.Responses[len(m.Responses)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRightsizing(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *ForecastRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -1701,6 +2370,106 @@ func (m *ForecastRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Horizon", wireType)
+			}
+			m.Horizon = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Horizon |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Step", wireType)
+			}
+			m.Step = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Step |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IntervalWidth", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.IntervalWidth = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seasonality", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRightsizing
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Seasonality = append(m.Seasonality, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Origin", wireType)
+			}
+			m.Origin = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRightsizing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Origin |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRightsizing(dAtA[iNdEx:])
@@ -1903,7 +2672,7 @@ func (m *ForecastResponse_Result) Unmarshal(dAtA []byte) error {
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PodName", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1,5 +1,6 @@
 from datetime import datetime, timedelta
-from typing import List
+import math
+from typing import List, Optional
 
 from fbprophet import Prophet
 import pandas as pd
@@ -8,26 +9,45 @@ import numpy as np
 
 _MARGIN = 0.2
 
+# defaults of the forecast options, used when the request leaves them unset
+_HORIZON = 6 * 60 * 60
+_STEP = 5 * 60
+_INTERVAL_WIDTH = 0.1
+_SEASONALITIES = ('daily', 'weekly', 'yearly')
+
 
 def percentile(values: List[float], quantile: int) -> float:
     q = np.percentile(values, quantile)
     return q * (1 + _MARGIN)
 
 
-def forecasting(df, freq=None) -> pd.DataFrame:
-    m = Prophet(interval_width=0.1)
+def forecasting(df, horizon: int = 0, step: int = 0, interval_width: float = 0,
+                seasonality: Optional[List[str]] = None, origin: int = 0) -> pd.DataFrame:
+    """Forecasts the usage from origin (unix time, now if 0) until horizon
+    seconds later.
+
+    step is the interval between the forecast datapoints in seconds. Only the
+    given seasonalities are fitted, prophet detects them if none is given.
+    """
+    horizon = timedelta(seconds=horizon or _HORIZON)
+    step = timedelta(seconds=step or _STEP)
+
+    seasonalities = {}
+    if seasonality:
+        seasonalities = {f'{name}_seasonality': name in seasonality for name in _SEASONALITIES}
+    m = Prophet(interval_width=interval_width or _INTERVAL_WIDTH, **seasonalities)
     m.fit(df)
 
-    if freq:
-        future = m.make_future_dataframe(periods=1440, freq=freq)
-    else:
-        future = m.make_future_dataframe(periods=1440, freq="5min")
+    now = datetime.fromtimestamp(origin) if origin else datetime.now()
+    end_time = now + horizon
+
+    # the usage may end before now, forecast from its last datapoint
+    last = pd.to_datetime(df['ds']).max()
+    periods = max(math.ceil((end_time - last) / step), 1)
+    future = m.make_future_dataframe(periods=periods, freq=f"{int(step.total_seconds())}s")
     forecast = m.predict(future)
     forecast['ds'] = forecast['ds'].astype(str)
 
-    now = datetime.now()
-    end_time = now + timedelta(hours=6)
-
     result = forecast[['ds', 'yhat', 'yhat_upper', 'yhat_lower']].query(
         f"ds >= '{now:%Y-%m-%d %H:%M}' and ds <= '{end_time:%Y-%m-%d %H:%M}'")
     return result
@@ -20,7 +20,7 @@ DESCRIPTOR = _descriptor.FileDescriptor(
   syntax='proto3',
   serialized_options=b'Z\013rightsizing\310\342\036\001\320\342\036\001\340\342\036\001\300\343\036\001\310\343\036\001',
   create_key=_descriptor._internal_create_key,
-  serialized_pb=b'\n\x11rightsizing.proto\x12\x0brightsizing\x1a\x14gogoproto/gogo.proto\"T\n\x0eTimeSeriesData\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x34\n\ndatapoints\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint\"7\n\x13TimeSeriesDatapoint\x12\x11\n\ttimestamp\x18\x01 \x01(\x03\x12\r\n\x05value\x18\x02 \x01(\x01\".\n\x12RightsizingRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0c\n\x04\x64\x61ta\x18\x02 \x03(\x01\"B\n\x13RightsizingResponse\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0f\n\x07message\x18\x02 \x01(\t\x12\x0e\n\x06result\x18\x03 \x01(\x01\"M\n\x0f\x46orecastRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12.\n\x04\x64\x61ta\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint\"\xad\x01\n\x10\x46orecastResponse\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0f\n\x07message\x18\x02 \x01(\t\x12\x34\n\x06result\x18\x03 \x03(\x0b\x32$.rightsizing.ForecastResponse.Result\x1a\x46\n\x06Result\x12\x0c\n\x04name\x18\x01 \x01(\t\x12.\n\x04\x64\x61ta\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint2a\n\x0bRightsizing\x12R\n\x0bRightsizing\x12\x1f.rightsizing.RightsizingRequest\x1a .rightsizing.RightsizingResponse\"\x00\x32U\n\x08\x46orecast\x12I\n\x08\x46orecast\x12\x1c.rightsizing.ForecastRequest\x1a\x1d.rightsizing.ForecastResponse\"\x00\x42!Z\x0brightsizing\xc8\xe2\x1e\x01\xd0\xe2\x1e\x01\xe0\xe2\x1e\x01\xc0\xe3\x1e\x01\xc8\xe3\x1e\x01\x62\x06proto3'
+  serialized_pb=b'\n\x11rightsizing.proto\x12\x0brightsizing\x1a\x14gogoproto/gogo.proto\"T\n\x0eTimeSeriesData\x12\x0c\n\x04name\x18\x01 \x01(\t\x12\x34\n\ndatapoints\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint\"7\n\x13TimeSeriesDatapoint\x12\x11\n\ttimestamp\x18\x01 \x01(\x03\x12\r\n\x05value\x18\x02 \x01(\x01\".\n\x12RightsizingRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0c\n\x04\x64\x61ta\x18\x02 \x03(\x01\"B\n\x13RightsizingResponse\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0f\n\x07message\x18\x02 \x01(\t\x12\x0e\n\x06result\x18\x03 \x01(\x01\"L\n\x17\x42\x61tchRightsizingRequest\x12\x31\n\x08requests\x18\x01 \x03(\x0b\x32\x1f.rightsizing.RightsizingRequest\"O\n\x18\x42\x61tchRightsizingResponse\x12\x33\n\tresponses\x18\x01 \x03(\x0b\x32 .rightsizing.RightsizingResponse\"\xa9\x01\n\x0f\x46orecastRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12.\n\x04\x64\x61ta\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint\x12\x0f\n\x07horizon\x18\x03 \x01(\x03\x12\x0c\n\x04step\x18\x04 \x01(\x03\x12\x16\n\x0einterval_width\x18\x05 \x01(\x01\x12\x13\n\x0bseasonality\x18\x06 \x03(\t\x12\x0e\n\x06origin\x18\x07 \x01(\x03\"\xad\x01\n\x10\x46orecastResponse\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0f\n\x07message\x18\x02 \x01(\t\x12\x34\n\x06result\x18\x03 \x03(\x0b\x32$.rightsizing.ForecastResponse.Result\x1a\x46\n\x06Result\x12\x0c\n\x04name\x18\x01 \x01(\t\x12.\n\x04\x64\x61ta\x18\x02 \x03(\x0b\x32 .rightsizing.TimeSeriesDatapoint2\xa0\x02\n\x0bRightsizing\x12R\n\x0bRightsizing\x12\x1f.rightsizing.RightsizingRequest\x1a .rightsizing.RightsizingResponse\"\x00\x12Z\n\x11RightsizingStream\x12\x1f.rightsizing.RightsizingRequest\x1a .rightsizing.RightsizingResponse\"\x00(\x01\x12\x61\n\x10\x42\x61tchRightsizing\x12$.rightsizing.BatchRightsizingRequest\x1a%.rightsizing.BatchRightsizingResponse\"\x00\x32\xa8\x01\n\x08\x46orecast\x12I\n\x08\x46orecast\x12\x1c.rightsizing.ForecastRequest\x1a\x1d.rightsizing.ForecastResponse\"\x00\x12Q\n\x0e\x46orecastStream\x12\x1c.rightsizing.ForecastRequest\x1a\x1d.rightsizing.ForecastResponse\"\x00(\x01\x42!Z\x0brightsizing\xc8\xe2\x1e\x01\xd0\xe2\x1e\x01\xe0\xe2\x1e\x01\xc0\xe3\x1e\x01\xc8\xe3\x1e\x01\x62\x06proto3'
   ,
   dependencies=[gogoproto_dot_gogo__pb2.DESCRIPTOR,])
 
@@ -190,6 +190,70 @@ _RIGHTSIZINGRESPONSE = _descriptor.Descriptor(
 )
 
 
+_BATCHRIGHTSIZINGREQUEST = _descriptor.Descriptor(
+  name='BatchRightsizingRequest',
+  full_name='rightsizing.BatchRightsizingRequest',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='requests', full_name='rightsizing.BatchRightsizingRequest.requests', index=0,
+      number=1, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=315,
+  serialized_end=391,
+)
+
+
+_BATCHRIGHTSIZINGRESPONSE = _descriptor.Descriptor(
+  name='BatchRightsizingResponse',
+  full_name='rightsizing.BatchRightsizingResponse',
+  filename=None,
+  file=DESCRIPTOR,
+  containing_type=None,
+  create_key=_descriptor._internal_create_key,
+  fields=[
+    _descriptor.FieldDescriptor(
+      name='responses', full_name='rightsizing.BatchRightsizingResponse.responses', index=0,
+      number=1, type=11, cpp_type=10, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+  ],
+  extensions=[
+  ],
+  nested_types=[],
+  enum_types=[
+  ],
+  serialized_options=None,
+  is_extendable=False,
+  syntax='proto3',
+  extension_ranges=[],
+  oneofs=[
+  ],
+  serialized_start=393,
+  serialized_end=472,
+)
+
+
 _FORECASTREQUEST = _descriptor.Descriptor(
   name='ForecastRequest',
   full_name='rightsizing.ForecastRequest',
@@ -212,6 +276,41 @@ _FORECASTREQUEST = _descriptor.Descriptor(
       message_type=None, enum_type=None, containing_type=None,
       is_extension=False, extension_scope=None,
       serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='horizon', full_name='rightsizing.ForecastRequest.horizon', index=2,
+      number=3, type=3, cpp_type=2, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='step', full_name='rightsizing.ForecastRequest.step', index=3,
+      number=4, type=3, cpp_type=2, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='interval_width', full_name='rightsizing.ForecastRequest.interval_width', index=4,
+      number=5, type=1, cpp_type=5, label=1,
+      has_default_value=False, default_value=float(0),
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='seasonality', full_name='rightsizing.ForecastRequest.seasonality', index=5,
+      number=6, type=9, cpp_type=9, label=3,
+      has_default_value=False, default_value=[],
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
+    _descriptor.FieldDescriptor(
+      name='origin', full_name='rightsizing.ForecastRequest.origin', index=6,
+      number=7, type=3, cpp_type=2, label=1,
+      has_default_value=False, default_value=0,
+      message_type=None, enum_type=None, containing_type=None,
+      is_extension=False, extension_scope=None,
+      serialized_options=None, file=DESCRIPTOR,  create_key=_descriptor._internal_create_key),
   ],
   extensions=[
   ],
@@ -224,8 +323,8 @@ _FORECASTREQUEST = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=315,
-  serialized_end=392,
+  serialized_start=475,
+  serialized_end=644,
 )
 
 
@@ -263,8 +362,8 @@ _FORECASTRESPONSE_RESULT = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=498,
-  serialized_end=568,
+  serialized_start=750,
+  serialized_end=820,
 )
 
 _FORECASTRESPONSE = _descriptor.Descriptor(
@@ -308,11 +407,13 @@ _FORECASTRESPONSE = _descriptor.Descriptor(
   extension_ranges=[],
   oneofs=[
   ],
-  serialized_start=395,
-  serialized_end=568,
+  serialized_start=647,
+  serialized_end=820,
 )
 
 _TIMESERIESDATA.fields_by_name['datapoints'].message_type = _TIMESERIESDATAPOINT
+_BATCHRIGHTSIZINGREQUEST.fields_by_name['requests'].message_type = _RIGHTSIZINGREQUEST
+_BATCHRIGHTSIZINGRESPONSE.fields_by_name['responses'].message_type = _RIGHTSIZINGRESPONSE
 _FORECASTREQUEST.fields_by_name['data'].message_type = _TIMESERIESDATAPOINT
 _FORECASTRESPONSE_RESULT.fields_by_name['data'].message_type = _TIMESERIESDATAPOINT
 _FORECASTRESPONSE_RESULT.containing_type = _FORECASTRESPONSE
@@ -321,6 +422,8 @@ DESCRIPTOR.message_types_by_name['TimeSeriesData'] = _TIMESERIESDATA
 DESCRIPTOR.message_types_by_name['TimeSeriesDatapoint'] = _TIMESERIESDATAPOINT
 DESCRIPTOR.message_types_by_name['RightsizingRequest'] = _RIGHTSIZINGREQUEST
 DESCRIPTOR.message_types_by_name['RightsizingResponse'] = _RIGHTSIZINGRESPONSE
+DESCRIPTOR.message_types_by_name['BatchRightsizingRequest'] = _BATCHRIGHTSIZINGREQUEST
+DESCRIPTOR.message_types_by_name['BatchRightsizingResponse'] = _BATCHRIGHTSIZINGRESPONSE
 DESCRIPTOR.message_types_by_name['ForecastRequest'] = _FORECASTREQUEST
 DESCRIPTOR.message_types_by_name['ForecastResponse'] = _FORECASTRESPONSE
 _sym_db.RegisterFileDescriptor(DESCRIPTOR)
@@ -353,6 +456,20 @@ RightsizingResponse = _reflection.GeneratedProtocolMessageType('RightsizingRespo
   })
 _sym_db.RegisterMessage(RightsizingResponse)
 
+BatchRightsizingRequest = _reflection.GeneratedProtocolMessageType('BatchRightsizingRequest', (_message.Message,), {
+  'DESCRIPTOR' : _BATCHRIGHTSIZINGREQUEST,
+  '__module__' : 'rightsizing_pb2'
+  # @@protoc_insertion_point(class_scope:rightsizing.BatchRightsizingRequest)
+  })
+_sym_db.RegisterMessage(BatchRightsizingRequest)
+
+BatchRightsizingResponse = _reflection.GeneratedProtocolMessageType('BatchRightsizingResponse', (_message.Message,), {
+  'DESCRIPTOR' : _BATCHRIGHTSIZINGRESPONSE,
+  '__module__' : 'rightsizing_pb2'
+  # @@protoc_insertion_point(class_scope:rightsizing.BatchRightsizingResponse)
+  })
+_sym_db.RegisterMessage(BatchRightsizingResponse)
+
 ForecastRequest = _reflection.GeneratedProtocolMessageType('ForecastRequest', (_message.Message,), {
   'DESCRIPTOR' : _FORECASTREQUEST,
   '__module__' : 'rightsizing_pb2'
@@ -385,8 +502,8 @@ _RIGHTSIZING = _descriptor.ServiceDescriptor(
   index=0,
   serialized_options=None,
   create_key=_descriptor._internal_create_key,
-  serialized_start=570,
-  serialized_end=667,
+  serialized_start=823,
+  serialized_end=1111,
   methods=[
   _descriptor.MethodDescriptor(
     name='Rightsizing',
@@ -398,6 +515,26 @@ _RIGHTSIZING = _descriptor.ServiceDescriptor(
     serialized_options=None,
     create_key=_descriptor._internal_create_key,
   ),
+  _descriptor.MethodDescriptor(
+    name='RightsizingStream',
+    full_name='rightsizing.Rightsizing.RightsizingStream',
+    index=1,
+    containing_service=None,
+    input_type=_RIGHTSIZINGREQUEST,
+    output_type=_RIGHTSIZINGRESPONSE,
+    serialized_options=None,
+    create_key=_descriptor._internal_create_key,
+  ),
+  _descriptor.MethodDescriptor(
+    name='BatchRightsizing',
+    full_name='rightsizing.Rightsizing.BatchRightsizing',
+    index=2,
+    containing_service=None,
+    input_type=_BATCHRIGHTSIZINGREQUEST,
+    output_type=_BATCHRIGHTSIZINGRESPONSE,
+    serialized_options=None,
+    create_key=_descriptor._internal_create_key,
+  ),
 ])
 _sym_db.RegisterServiceDescriptor(_RIGHTSIZING)
 
@@ -411,8 +548,8 @@ _FORECAST = _descriptor.ServiceDescriptor(
   index=1,
   serialized_options=None,
   create_key=_descriptor._internal_create_key,
-  serialized_start=669,
-  serialized_end=754,
+  serialized_start=1114,
+  serialized_end=1282,
   methods=[
   _descriptor.MethodDescriptor(
     name='Forecast',
@@ -424,6 +561,16 @@ _FORECAST = _descriptor.ServiceDescriptor(
     serialized_options=None,
     create_key=_descriptor._internal_create_key,
   ),
+  _descriptor.MethodDescriptor(
+    name='ForecastStream',
+    full_name='rightsizing.Forecast.ForecastStream',
+    index=1,
+    containing_service=None,
+    input_type=_FORECASTREQUEST,
+    output_type=_FORECASTRESPONSE,
+    serialized_options=None,
+    create_key=_descriptor._internal_create_key,
+  ),
 ])
 _sym_db.RegisterServiceDescriptor(_FORECAST)
 
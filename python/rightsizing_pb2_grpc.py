@@ -19,6 +19,16 @@ class RightsizingStub(object):
                 request_serializer=rightsizing__pb2.RightsizingRequest.SerializeToString,
                 response_deserializer=rightsizing__pb2.RightsizingResponse.FromString,
                 )
+        self.RightsizingStream = channel.stream_unary(
+                '/rightsizing.Rightsizing/RightsizingStream',
+                request_serializer=rightsizing__pb2.RightsizingRequest.SerializeToString,
+                response_deserializer=rightsizing__pb2.RightsizingResponse.FromString,
+                )
+        self.BatchRightsizing = channel.unary_unary(
+                '/rightsizing.Rightsizing/BatchRightsizing',
+                request_serializer=rightsizing__pb2.BatchRightsizingRequest.SerializeToString,
+                response_deserializer=rightsizing__pb2.BatchRightsizingResponse.FromString,
+                )
 
 
 class RightsizingServicer(object):
@@ -30,6 +40,23 @@ class RightsizingServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def RightsizingStream(self, request_iterator, context):
+        """RightsizingStream is Rightsizing with the data split into chunks, for
+        series larger than the message size limit. The id of the first chunk is used.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def BatchRightsizing(self, request, context):
+        """BatchRightsizing recommends many series with one call. The responses
+        are tagged with the id of their request, and carry the error in the
+        message if the series could not be recommended.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_RightsizingServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -38,6 +65,16 @@ def add_RightsizingServicer_to_server(servicer, server):
                     request_deserializer=rightsizing__pb2.RightsizingRequest.FromString,
                     response_serializer=rightsizing__pb2.RightsizingResponse.SerializeToString,
             ),
+            'RightsizingStream': grpc.stream_unary_rpc_method_handler(
+                    servicer.RightsizingStream,
+                    request_deserializer=rightsizing__pb2.RightsizingRequest.FromString,
+                    response_serializer=rightsizing__pb2.RightsizingResponse.SerializeToString,
+            ),
+            'BatchRightsizing': grpc.unary_unary_rpc_method_handler(
+                    servicer.BatchRightsizing,
+                    request_deserializer=rightsizing__pb2.BatchRightsizingRequest.FromString,
+                    response_serializer=rightsizing__pb2.BatchRightsizingResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'rightsizing.Rightsizing', rpc_method_handlers)
@@ -65,6 +102,40 @@ class Rightsizing(object):
             options, channel_credentials,
             insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
 
+    @staticmethod
+    def RightsizingStream(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_unary(request_iterator, target, '/rightsizing.Rightsizing/RightsizingStream',
+            rightsizing__pb2.RightsizingRequest.SerializeToString,
+            rightsizing__pb2.RightsizingResponse.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
+    @staticmethod
+    def BatchRightsizing(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(request, target, '/rightsizing.Rightsizing/BatchRightsizing',
+            rightsizing__pb2.BatchRightsizingRequest.SerializeToString,
+            rightsizing__pb2.BatchRightsizingResponse.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
 
 class ForecastStub(object):
     """Missing associated documentation comment in .proto file."""
@@ -80,6 +151,11 @@ class ForecastStub(object):
                 request_serializer=rightsizing__pb2.ForecastRequest.SerializeToString,
                 response_deserializer=rightsizing__pb2.ForecastResponse.FromString,
                 )
+        self.ForecastStream = channel.stream_unary(
+                '/rightsizing.Forecast/ForecastStream',
+                request_serializer=rightsizing__pb2.ForecastRequest.SerializeToString,
+                response_deserializer=rightsizing__pb2.ForecastResponse.FromString,
+                )
 
 
 class ForecastServicer(object):
@@ -91,6 +167,14 @@ class ForecastServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def ForecastStream(self, request_iterator, context):
+        """ForecastStream is Forecast with the data split into chunks, for series
+        larger than the message size limit. The options of the first chunk are used.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_ForecastServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -99,6 +183,11 @@ def add_ForecastServicer_to_server(servicer, server):
                     request_deserializer=rightsizing__pb2.ForecastRequest.FromString,
                     response_serializer=rightsizing__pb2.ForecastResponse.SerializeToString,
             ),
+            'ForecastStream': grpc.stream_unary_rpc_method_handler(
+                    servicer.ForecastStream,
+                    request_deserializer=rightsizing__pb2.ForecastRequest.FromString,
+                    response_serializer=rightsizing__pb2.ForecastResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'rightsizing.Forecast', rpc_method_handlers)
@@ -125,3 +214,20 @@ class Forecast(object):
             rightsizing__pb2.ForecastResponse.FromString,
             options, channel_credentials,
             insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
+
+    @staticmethod
+    def ForecastStream(request_iterator,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.stream_unary(request_iterator, target, '/rightsizing.Forecast/ForecastStream',
+            rightsizing__pb2.ForecastRequest.SerializeToString,
+            rightsizing__pb2.ForecastResponse.FromString,
+            options, channel_credentials,
+            insecure, call_credentials, compression, wait_for_ready, timeout, metadata)
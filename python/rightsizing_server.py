@@ -1,3 +1,4 @@
+import os
 from concurrent import futures
 
 import grpc
@@ -16,7 +17,10 @@ class Forecast(rightsizing_pb2_grpc.Forecast):
         # timestamps, values = list(zip(*data))
         df = pd.DataFrame(time_series_data, columns=["ds", "y"])
 
-        forecast = analyze.forecasting(df)
+        forecast = analyze.forecasting(df, horizon=request.horizon, step=request.step,
+                                       interval_width=request.interval_width,
+                                       seasonality=list(request.seasonality),
+                                       origin=request.origin)
 
         response = rightsizing_pb2.ForecastResponse(id=request.id)
         result = response.result
@@ -29,6 +33,13 @@ class Forecast(rightsizing_pb2_grpc.Forecast):
             data.data.extend(analysis_data)
         return response
 
+    def ForecastStream(self, request_iterator, context):
+        # the first chunk holds the options, the rest only the data
+        request = next(request_iterator, rightsizing_pb2.ForecastRequest())
+        for chunk in request_iterator:
+            request.data.extend(chunk.data)
+        return self.Forecast(request, context)
+
 
 class Rightsizing(rightsizing_pb2_grpc.Rightsizing):
     def Rightsizing(self, request, context):
@@ -36,13 +47,63 @@ class Rightsizing(rightsizing_pb2_grpc.Rightsizing):
         response = rightsizing_pb2.RightsizingResponse(id=request.id, result=optimized_usage)
         return response
 
+    def RightsizingStream(self, request_iterator, context):
+        request = next(request_iterator, rightsizing_pb2.RightsizingRequest())
+        for chunk in request_iterator:
+            request.data.extend(chunk.data)
+        return self.Rightsizing(request, context)
+
+    def BatchRightsizing(self, request, context):
+        response = rightsizing_pb2.BatchRightsizingResponse()
+        for series in request.requests:
+            # a failing series does not fail the others
+            try:
+                response.responses.append(self.Rightsizing(series, context))
+            except Exception as e:
+                response.responses.add(id=series.id, message=str(e))
+        return response
+
+
+# grpc.health.v1.HealthCheckResponse(status=SERVING), serialized so that the
+# health checking protocol does not need grpcio-health-checking
+HEALTH_SERVING = b'\x08\x01'
+
+
+def health_handler():
+    return grpc.method_handlers_generic_handler('grpc.health.v1.Health', {
+        'Check': grpc.unary_unary_rpc_method_handler(lambda request, context: HEALTH_SERVING),
+    })
+
+
+def read_file(path):
+    with open(path, 'rb') as f:
+        return f.read()
+
+
+def server_credentials():
+    """TLS if TLS_CERT_FILE and TLS_KEY_FILE are set, mutual TLS if
+    TLS_CLIENT_CA_FILE is set as well"""
+    cert_file, key_file = os.getenv('TLS_CERT_FILE'), os.getenv('TLS_KEY_FILE')
+    if not cert_file or not key_file:
+        return None
+    client_ca_file = os.getenv('TLS_CLIENT_CA_FILE')
+    return grpc.ssl_server_credentials(
+        [(read_file(key_file), read_file(cert_file))],
+        root_certificates=read_file(client_ca_file) if client_ca_file else None,
+        require_client_auth=bool(client_ca_file))
+
 
 def serve():
     server = grpc.server(futures.ThreadPoolExecutor(max_workers=10))
     # Add servicer to server
     rightsizing_pb2_grpc.add_ForecastServicer_to_server(Forecast(), server)
     rightsizing_pb2_grpc.add_RightsizingServicer_to_server(Rightsizing(), server)
-    server.add_insecure_port('[::]:50051')
+    server.add_generic_rpc_handlers((health_handler(),))
+    credentials = server_credentials()
+    if credentials:
+        server.add_secure_port('[::]:50051', credentials)
+    else:
+        server.add_insecure_port('[::]:50051')
     server.start()
     server.wait_for_termination()
 